@@ -2,21 +2,30 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"gorm.io/gorm"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	_ "testEffectiveMobile/docs"
+	"testEffectiveMobile/internal/auth"
 	"testEffectiveMobile/internal/controller"
+	"testEffectiveMobile/internal/models"
 	"testEffectiveMobile/internal/repository"
 	"testEffectiveMobile/internal/service"
 	"testEffectiveMobile/internal/service/mocks"
 	"testEffectiveMobile/internal/utils/config"
+	"testEffectiveMobile/internal/utils/content"
+	"testEffectiveMobile/internal/utils/langdetect"
 	"testEffectiveMobile/internal/utils/logger"
+	"testEffectiveMobile/internal/utils/ratelimit"
 	"testEffectiveMobile/internal/utils/storage"
-	"time"
 )
 
 // @title Test task Effective Mobile API
@@ -24,26 +33,97 @@ import (
 // @description This is a test task for Effective Mobile
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT in the form "Bearer <token>"
 
 func main() {
 	//Инициализация логгера, конфига, хранилища
 	cfg := config.MustLoad()
 	log := logger.NewLogger(cfg.Logger.Level)
-	storage.MustLoadPostgres(cfg.Database)
+	db := storage.MustLoadPostgres(cfg.Database)
 
 	//Инициализация слоев приложения
-	songRepo := repository.NewRepository(log, storage.MustLoadPostgres(cfg.Database))
+	repos := service.Repositories{
+		Songs:        repository.NewRepository(log, db),
+		Translations: repository.NewTranslationRepository(log, db),
+		Annotations:  repository.NewAnnotationRepository(log, db),
+		Suggestions:  repository.NewSuggestionRepository(log, db),
+		Users:        repository.NewUserRepository(log, db),
+		Audit:        repository.NewAuditRepository(log, db),
+	}
+	uow := repository.NewUnitOfWork(log, db)
 	apiClient := mocks.NewAPIClientMock()
-	songService := service.NewService(songRepo, log, apiClient)
+	classifier := content.MustLoadClassifier(cfg.Content.ExplicitWordsDir)
+	detector := langdetect.MustLoadDetector()
+	songService := service.NewService(repos, uow, log, apiClient, classifier, detector)
 	songController := controller.NewController(songService, log)
+	policy, err := controller.NewPolicy(log, cfg.Auth.RolePermissions)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load role permissions: %s", err.Error()))
+	}
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(log, db), repos.Audit, log, cfg.Auth.AdminKey)
+	apiKeyController := controller.NewAPIKeyController(apiKeyService, policy, log)
+	var tokens service.TokenIssuer
+	if issuer := auth.MustLoadTokenIssuer(cfg.Auth); issuer != nil {
+		tokens = issuer
+	} else {
+		log.Warn("user login is disabled: JWT_ALGORITHM is not set")
+	}
+	userService := service.NewUserService(repos.Users, repos.Audit, uow, log, tokens)
+	userController := controller.NewUserController(userService, log)
+	auditController := controller.NewAuditController(service.NewAuditService(repos.Audit, log), log)
+	tenantRepository := repository.NewTenantRepository(log, db)
+	tenantService := service.NewTenantService(tenantRepository, repos.Audit, log)
+	tenantController := controller.NewTenantController(tenantService, log)
 
 	//Загрузка роутов
-	router := LoadRoutes(songController)
+	var middlewares Middlewares
+	if cfg.Auth.Enabled {
+		middlewares.Authenticate = controller.Authenticate(log, auth.MustLoadAuthenticators(cfg.Auth, apiKeyService)...)
+	} else {
+		log.Warn("authentication is disabled")
+	}
+	if cfg.RateLimit.Enabled {
+		store := mustLoadRateLimitStore(cfg.RateLimit, log, db)
+		limit := func(scope string, requests int, key func(r *http.Request) string) func(http.Handler) http.Handler {
+			return controller.RateLimit(log, store, scope, ratelimit.Limit{Requests: requests, Window: cfg.RateLimit.Window},
+				key, cfg.RateLimit.FailOpen)
+		}
+		middlewares.AuthLimit = limit("auth", cfg.RateLimit.AuthRequests, controller.IPKey)
+		middlewares.LoginLimit = limit("login", cfg.RateLimit.LoginRequests, controller.IPKey)
+		middlewares.ReadLimit = limit("read", cfg.RateLimit.ReadRequests, controller.ClientKey)
+		middlewares.WriteLimit = limit("write", cfg.RateLimit.WriteRequests, controller.ClientKey)
+	}
+	middlewares.CORS = controller.CORS(controller.CORSPolicy{
+		AllowedOrigins: cfg.CORS.AllowedOrigins,
+		AllowedMethods: cfg.CORS.AllowedMethods,
+		AllowedHeaders: cfg.CORS.AllowedHeaders,
+		ExposedHeaders: cfg.CORS.ExposedHeaders,
+		MaxAge:         cfg.CORS.MaxAge,
+	})
+	middlewares.Tenant = controller.ResolveTenant(log, tenantService, policy, cfg.Auth.DefaultTenant)
+	middlewares.Idempotency = controller.Idempotency(log, repository.NewIdempotencyStore(log, db), cfg.Server.IdempotencyTTL)
+	if cfg.Server.MaxBodySize <= 0 {
+		panic("MAX_BODY_SIZE must be positive")
+	}
+	router := LoadRoutes(songController, apiKeyController, userController, auditController, tenantController, middlewares,
+		policy, cfg.Server)
 
-	//Запуск сервера
+	//Запуск сервера. Контексты всех запросов наследуются от baseCtx, чтобы при остановке
+	//сервера можно было прервать зависшие запросы к БД и внешнему API
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 	var server = http.Server{
 		Addr:    ":" + cfg.Server.Port,
 		Handler: router,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
 	log.Info("Server started on port " + cfg.Server.Port)
 	go func() {
@@ -52,26 +132,172 @@ func main() {
 		}
 	}()
 
+	//Проверка текстов песен, добавленных до появления проверок. Пока она идет, такие песни
+	//не попадают в выборку по признаку откровенного содержания и по языку
+	backfill := service.NewContentBackfill(repos.Songs, tenantRepository, log, classifier, detector)
+	go func() {
+		if err := backfill.Run(baseCtx); err != nil {
+			log.Error("Content backfill error: " + err.Error())
+		}
+	}()
+
 	//Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 	log.Info("Server shutting down")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
 		log.Error("Server forced to shutdown")
+		cancelRequests()
 	}
 	log.Info("Server shutdown")
 }
 
-func LoadRoutes(controller *controller.SongController) *chi.Mux {
+// Middlewares - проверки запросов, которые LoadRoutes добавляет к маршрутам. nil отключает проверку
+type Middlewares struct {
+	// CORS применяется ко всем маршрутам до аутентификации, чтобы предварительные запросы браузера ее не проходили
+	CORS func(http.Handler) http.Handler
+	// AuthLimit ограничивает запросы с одного IP-адреса до аутентификации, LoginLimit - ко входу и регистрации
+	AuthLimit    func(http.Handler) http.Handler
+	LoginLimit   func(http.Handler) http.Handler
+	Authenticate func(http.Handler) http.Handler
+	// ReadLimit применяется к читающим маршрутам, WriteLimit - к изменяющим
+	ReadLimit  func(http.Handler) http.Handler
+	WriteLimit func(http.Handler) http.Handler
+	// Idempotency применяется к маршрутам создания
+	Idempotency func(http.Handler) http.Handler
+	// Tenant определяет арендатора на маршрутах библиотеки песен и пользователя
+	Tenant func(http.Handler) http.Handler
+}
+
+// LoadRoutes регистрирует маршруты. Все маршруты, кроме документации, регистрации и входа, проходят
+// ограничение частоты запросов по IP-адресу, аутентификацию, затем ограничение частоты запросов клиента
+// и проверку разрешения, нужного маршруту. Маршруты песен и пользователя работают с библиотекой арендатора,
+// административные - со всеми арендаторами
+func LoadRoutes(songController *controller.SongController, apiKeyController *controller.APIKeyController,
+	userController *controller.UserController, auditController *controller.AuditController,
+	tenantController *controller.TenantController, middlewares Middlewares, policy *controller.Policy,
+	cfg config.ServerConfig) *chi.Mux {
 	router := chi.NewRouter()
-	router.Get("/swagger/*", httpSwagger.Handler())
-	router.Get("/songs", controller.GetSongs)
-	router.Post("/songs", controller.CreateSong)
-	router.Get("/songs/{id}/verses", controller.GetVersesByID)
-	router.Delete("/songs/{id}", controller.DeleteSong)
-	router.Put("/songs/{id}", controller.UpdateSong)
+	router.Use(middleware.RequestID)
+	router.Use(middleware.Recoverer)
+	if middlewares.CORS != nil {
+		router.Use(middlewares.CORS)
+	}
+	router.Use(controller.SecurityHeaders(cfg.HSTSMaxAge))
+	router.Use(controller.MaxBodySize(cfg.MaxBodySize))
+	router.Use(middleware.Timeout(cfg.RequestTimeout))
+	router.With(controller.DocsSecurityHeaders).Get("/swagger/*", httpSwagger.Handler())
+	read := chain(middlewares.ReadLimit, policy.Require(models.PermissionRead))
+	write := chain(middlewares.WriteLimit, policy.Require(models.PermissionWrite))
+	create := chain(write, middlewares.Idempotency)
+	remove := chain(middlewares.WriteLimit, policy.Require(models.PermissionDelete))
+	// Администрирование общее для всех арендаторов, поэтому клиентам, привязанным к арендатору, оно недоступно
+	admin := chain(middlewares.WriteLimit, policy.Require(models.PermissionAdmin), policy.RequireUnbound)
+	adminCreate := chain(admin, middlewares.Idempotency)
+	// Избранное, историю прослушиваний и оценки пользователь меняет с разрешением на чтение, это его собственные данные
+	own := chain(middlewares.WriteLimit, policy.Require(models.PermissionRead))
+	play := chain(own, middlewares.Idempotency)
+	// Вход и регистрация доступны без аутентификации, поэтому ограничиваются по IP-адресу
+	public := chain(middlewares.LoginLimit, middlewares.WriteLimit, controller.AuditContext)
+	suggest := chain(middlewares.WriteLimit, policy.Require(models.PermissionSuggest), middlewares.Idempotency)
+	moderate := chain(middlewares.WriteLimit, policy.Require(models.PermissionModerate))
+	// Пользователь регистрируется в арендаторе из заголовка X-Tenant или арендаторе по умолчанию
+	router.With(chain(public, middlewares.Tenant)).Post("/auth/register", userController.Register)
+	router.With(public).Post("/auth/login", userController.Login)
+	router.Group(func(router chi.Router) {
+		if middlewares.AuthLimit != nil {
+			router.Use(middlewares.AuthLimit)
+		}
+		if middlewares.Authenticate != nil {
+			router.Use(middlewares.Authenticate)
+		}
+		router.Use(controller.AuditContext)
+		router.Group(func(router chi.Router) {
+			if middlewares.Tenant != nil {
+				router.Use(middlewares.Tenant)
+			}
+			router.With(read).Get("/songs", songController.GetSongs)
+			router.With(create).Post("/songs", songController.CreateSong)
+			router.With(read).Get("/songs/languages", songController.GetLanguageFacets)
+			router.With(read).Get("/songs/{id}/verses", songController.GetVersesByID)
+			router.With(read).Get("/songs/{id}/verses/{n}", songController.GetVerse)
+			router.With(read).Get("/songs/{id}/lyrics", songController.GetLyrics)
+			router.With(read).Get("/songs/{id}/translations", songController.ListTranslations)
+			router.With(create).Post("/songs/{id}/translations", songController.CreateTranslation)
+			router.With(write).Put("/songs/{id}/translations/{lang}", songController.UpdateTranslation)
+			router.With(read).Get("/songs/{id}/annotations", songController.ListAnnotations)
+			router.With(create).Post("/songs/{id}/annotations", songController.CreateAnnotation)
+			router.With(read).Get("/songs/{id}/annotations/{annotationID}", songController.GetAnnotation)
+			router.With(write).Put("/songs/{id}/annotations/{annotationID}", songController.UpdateAnnotation)
+			router.With(remove).Delete("/songs/{id}/annotations/{annotationID}", songController.DeleteAnnotation)
+			router.With(read).Get("/songs/{id}/lyrics/annotated", songController.GetAnnotatedLyrics)
+			router.With(read).Get("/songs/{id}/suggestions", songController.ListSongSuggestions)
+			router.With(suggest).Post("/songs/{id}/suggestions", songController.CreateSuggestion)
+			router.With(read).Get("/songs/{id}/suggestions/{suggestionID}", songController.GetSuggestion)
+			router.With(read).Get("/songs/{id}/suggestions/{suggestionID}/diff", songController.GetSuggestionDiff)
+			router.With(moderate).Post("/songs/{id}/suggestions/{suggestionID}/approve", songController.ApproveSuggestion)
+			router.With(moderate).Post("/songs/{id}/suggestions/{suggestionID}/reject", songController.RejectSuggestion)
+			router.With(moderate).Get("/suggestions", songController.ListSuggestions)
+			router.With(read).Get("/songs/{id}/revisions", songController.ListRevisions)
+			router.With(read).Get("/songs/{id}/stats", songController.GetSongStats)
+			router.With(read).Get("/songs/{id}/similar", songController.GetSimilarSongs)
+			router.With(write).Put("/songs/{id}/explicit", songController.SetExplicit)
+			router.With(write).Patch("/songs/{id}/language", songController.SetLanguage)
+			router.With(read).Get("/stats/words", songController.GetWordUsage)
+			router.With(read).Get("/charts", songController.GetChart)
+			router.With(read).Get("/songs/{id}/lyrics/synced", songController.GetSyncedLyrics)
+			router.With(write).Put("/songs/{id}/lyrics/synced", songController.PutSyncedLyrics)
+			router.With(read).Get("/songs/{id}/chords", songController.GetChords)
+			router.With(write).Put("/songs/{id}/chords", songController.PutChords)
+			router.With(read).Get("/songs/{id}", songController.GetSong)
+			router.With(read).Head("/songs/{id}", songController.GetSong)
+			router.With(remove).Delete("/songs/{id}", songController.DeleteSong)
+			router.With(write).Put("/songs/{id}", songController.UpdateSong)
+			router.With(read).Get("/me", userController.GetMe)
+			router.With(read).Get("/me/favorites", userController.GetFavorites)
+			router.With(own).Put("/me/favorites/{songID}", userController.AddFavorite)
+			router.With(own).Delete("/me/favorites/{songID}", userController.RemoveFavorite)
+			router.With(read).Get("/me/history", userController.GetHistory)
+			router.With(play).Post("/me/history", userController.RecordPlay)
+			router.With(own).Put("/me/ratings/{songID}", userController.RateSong)
+			router.With(own).Delete("/me/ratings/{songID}", userController.DeleteRating)
+		})
+		router.With(admin).Get("/admin/api-keys", apiKeyController.ListAPIKeys)
+		router.With(adminCreate).Post("/admin/api-keys", apiKeyController.CreateAPIKey)
+		router.With(admin).Delete("/admin/api-keys/{id}", apiKeyController.RevokeAPIKey)
+		router.With(admin).Get("/admin/tenants", tenantController.ListTenants)
+		router.With(adminCreate).Post("/admin/tenants", tenantController.CreateTenant)
+		router.With(admin).Get("/audit", auditController.GetAuditLog)
+	})
 	return router
 }
+
+// chain объединяет middleware в одно, пропуская nil. Запрос проходит их в порядке перечисления
+func chain(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			if middlewares[i] != nil {
+				next = middlewares[i](next)
+			}
+		}
+		return next
+	}
+}
+
+// mustLoadRateLimitStore создает хранилище счетчиков запросов, выбранное в конфигурации
+func mustLoadRateLimitStore(cfg config.RateLimitConfig, log *slog.Logger, db *gorm.DB) ratelimit.Store {
+	if cfg.Window <= 0 {
+		panic("RATE_LIMIT_WINDOW must be positive")
+	}
+	switch cfg.Store {
+	case "memory":
+		return ratelimit.NewMemoryStore()
+	case "postgres":
+		return repository.NewRateLimitStore(log, db)
+	default:
+		panic(fmt.Sprintf("unknown RATE_LIMIT_STORE %q, expected memory or postgres", cfg.Store))
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"testEffectiveMobile/internal/controller"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/config"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// songServiceStub отвечает на все запросы пустыми, но корректными данными
+type songServiceStub struct{}
+
+func (songServiceStub) FilterSongs(context.Context, models.SongFilter, int, int) ([]models.Song, error) {
+	return []models.Song{}, nil
+}
+func (songServiceStub) CreateSong(context.Context, string, string) (uint, error) { return 1, nil }
+func (songServiceStub) GetSong(context.Context, int, []string) (*models.SongDetails, error) {
+	return &models.SongDetails{}, nil
+}
+func (songServiceStub) GetVersesWithPagination(context.Context, int, int, int) (*models.VersePage, error) {
+	return &models.VersePage{}, nil
+}
+func (songServiceStub) GetLinesWithPagination(context.Context, int, int, int) (*models.VersePage, error) {
+	return &models.VersePage{}, nil
+}
+func (songServiceStub) GetTranslatedVerses(context.Context, int, string, int, int) (*models.VersePage, error) {
+	return &models.VersePage{}, nil
+}
+func (songServiceStub) GetVerse(context.Context, int, int) (*models.Verse, error) {
+	return &models.Verse{}, nil
+}
+func (songServiceStub) GetLyrics(context.Context, int) (*models.Lyrics, error) {
+	return &models.Lyrics{}, nil
+}
+func (songServiceStub) ImportSyncedLyrics(context.Context, int, string) (*models.SyncedLyrics, error) {
+	return &models.SyncedLyrics{}, nil
+}
+func (songServiceStub) GetSyncedLyrics(context.Context, int) (*models.SyncedLyrics, error) {
+	return &models.SyncedLyrics{}, nil
+}
+func (songServiceStub) ExportSyncedLyrics(context.Context, int) (string, error) { return "", nil }
+func (songServiceStub) GetSyncedLyricsAt(context.Context, int, int, int) (*models.SyncedLyricsPosition, error) {
+	return &models.SyncedLyricsPosition{}, nil
+}
+func (songServiceStub) ImportChords(context.Context, int, string) (*models.Chords, error) {
+	return &models.Chords{}, nil
+}
+func (songServiceStub) GetChords(context.Context, int, int) (*models.Chords, error) {
+	return &models.Chords{}, nil
+}
+func (songServiceStub) RenderChords(context.Context, int, int) (string, error) { return "", nil }
+func (songServiceStub) Chart(context.Context, string, int) (*models.Chart, error) {
+	return &models.Chart{}, nil
+}
+func (songServiceStub) CreateTranslation(context.Context, int, string, []string) (*models.Translation, error) {
+	return &models.Translation{}, nil
+}
+func (songServiceStub) UpdateTranslation(context.Context, int, string, []string) (*models.Translation, error) {
+	return &models.Translation{}, nil
+}
+func (songServiceStub) ListTranslations(context.Context, int) ([]models.Translation, error) {
+	return []models.Translation{}, nil
+}
+func (songServiceStub) CreateAnnotation(context.Context, int, *models.Annotation) error { return nil }
+func (songServiceStub) UpdateAnnotation(context.Context, int, *models.Annotation) error { return nil }
+func (songServiceStub) GetAnnotation(context.Context, int, int) (*models.Annotation, error) {
+	return &models.Annotation{}, nil
+}
+func (songServiceStub) ListAnnotations(context.Context, int) ([]models.Annotation, error) {
+	return []models.Annotation{}, nil
+}
+func (songServiceStub) DeleteAnnotation(context.Context, int, int) error { return nil }
+func (songServiceStub) GetAnnotatedLyrics(context.Context, int) (*models.AnnotatedLyrics, error) {
+	return &models.AnnotatedLyrics{}, nil
+}
+func (songServiceStub) CreateSuggestion(context.Context, int, *models.Suggestion) error { return nil }
+func (songServiceStub) GetSuggestion(context.Context, int, int) (*models.Suggestion, error) {
+	return &models.Suggestion{}, nil
+}
+func (songServiceStub) ListSuggestions(context.Context, models.SuggestionFilter, int, int) ([]models.Suggestion, error) {
+	return []models.Suggestion{}, nil
+}
+func (songServiceStub) DiffSuggestion(context.Context, int, int) (*models.SuggestionDiff, error) {
+	return &models.SuggestionDiff{}, nil
+}
+func (songServiceStub) ApproveSuggestion(context.Context, int, int, string) (*models.Suggestion, error) {
+	return &models.Suggestion{}, nil
+}
+func (songServiceStub) RejectSuggestion(context.Context, int, int, string, string) (*models.Suggestion, error) {
+	return &models.Suggestion{}, nil
+}
+func (songServiceStub) ListRevisions(context.Context, int) ([]models.Revision, error) {
+	return []models.Revision{}, nil
+}
+func (songServiceStub) GetSongStats(context.Context, int, int) (*models.SongStats, error) {
+	return &models.SongStats{}, nil
+}
+func (songServiceStub) GetWordUsage(context.Context, string) (*models.WordUsage, error) {
+	return &models.WordUsage{}, nil
+}
+func (songServiceStub) GetSimilarSongs(context.Context, int, int) ([]models.SimilarSong, error) {
+	return []models.SimilarSong{}, nil
+}
+func (songServiceStub) DeleteSong(context.Context, int) error          { return nil }
+func (songServiceStub) UpdateSong(context.Context, *models.Song) error { return nil }
+func (songServiceStub) SetExplicitOverride(context.Context, int, *bool) (*models.Song, error) {
+	return &models.Song{}, nil
+}
+func (songServiceStub) SetLanguage(context.Context, int, *string) (*models.Song, error) {
+	return &models.Song{}, nil
+}
+func (songServiceStub) LanguageFacets(context.Context, models.SongFilter) ([]models.LanguageFacet, error) {
+	return []models.LanguageFacet{}, nil
+}
+
+// roleAuthenticator аутентифицирует клиента с ролью из заголовка X-Test-Role и арендатором из X-Test-Tenant
+type roleAuthenticator struct{}
+
+func (roleAuthenticator) Authenticate(r *http.Request) (*models.Principal, error) {
+	role := r.Header.Get("X-Test-Role")
+	return &models.Principal{Subject: role, Method: models.AuthMethodAPIKey, Roles: []string{role},
+		Tenant: r.Header.Get("X-Test-Tenant")}, nil
+}
+
+func TestSongRoutesRolePermissions(t *testing.T) {
+	var auth config.AuthConfig
+	if err := env.Parse(&auth); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := controller.NewPolicy(discardLog, auth.RolePermissions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	middlewares := Middlewares{Authenticate: controller.Authenticate(discardLog, roleAuthenticator{})}
+	router := LoadRoutes(controller.NewController(songServiceStub{}, discardLog), nil, nil, nil, nil, middlewares,
+		policy, config.ServerConfig{MaxBodySize: 1 << 20, RequestTimeout: 10 * time.Second})
+
+	const (
+		reader = "reader"
+		editor = "editor"
+		admin  = "admin"
+	)
+	routes := []struct {
+		method string
+		path   string
+		body   string
+		// allowed - роли, которым маршрут доступен, остальные получают 403
+		allowed []string
+	}{
+		{http.MethodGet, "/songs", "", []string{reader, editor, admin}},
+		{http.MethodPost, "/songs", `{"group": "Muse", "song": "Uprising"}`, []string{editor, admin}},
+		{http.MethodGet, "/songs/languages", "", []string{reader, editor, admin}},
+		{http.MethodGet, "/songs/1/verses", "", []string{reader, editor, admin}},
+		{http.MethodGet, "/songs/1/verses/1", "", []string{reader, editor, admin}},
+		{http.MethodGet, "/songs/1/lyrics", "", []string{reader, editor, admin}},
+		{http.MethodGet, "/songs/1/translations", "", []string{reader, editor, admin}},
+		{http.MethodPost, "/songs/1/translations", `{"language": "en", "verses": ["one"]}`, []string{editor, admin}},
+		{http.MethodPut, "/songs/1/translations/en", `{"language": "en", "verses": ["one"]}`, []string{editor, admin}},
+		{http.MethodGet, "/songs/1/annotations", "", []string{reader, editor, admin}},
+		{http.MethodPost, "/songs/1/annotations", `{"verse": 1, "line": 1, "kind": "explanation", "body": "note"}`,
+			[]string{editor, admin}},
+		{http.MethodGet, "/songs/1/annotations/1", "", []string{reader, editor, admin}},
+		{http.MethodPut, "/songs/1/annotations/1", `{"verse": 1, "line": 1, "kind": "explanation", "body": "note"}`,
+			[]string{editor, admin}},
+		{http.MethodDelete, "/songs/1/annotations/1", "", []string{admin}},
+		{http.MethodGet, "/songs/1/lyrics/annotated", "", []string{reader, editor, admin}},
+		{http.MethodGet, "/songs/1/suggestions", "", []string{reader, editor, admin}},
+		{http.MethodPost, "/songs/1/suggestions", `{"text": "new text"}`, []string{reader, editor, admin}},
+		{http.MethodGet, "/songs/1/suggestions/1", "", []string{reader, editor, admin}},
+		{http.MethodGet, "/songs/1/suggestions/1/diff", "", []string{reader, editor, admin}},
+		{http.MethodPost, "/songs/1/suggestions/1/approve", "", []string{editor, admin}},
+		{http.MethodPost, "/songs/1/suggestions/1/reject", `{"reason": "spam"}`, []string{editor, admin}},
+		{http.MethodGet, "/suggestions", "", []string{editor, admin}},
+		{http.MethodGet, "/songs/1/revisions", "", []string{reader, editor, admin}},
+		{http.MethodGet, "/songs/1/stats", "", []string{reader, editor, admin}},
+		{http.MethodGet, "/songs/1/similar", "", []string{reader, editor, admin}},
+		{http.MethodPut, "/songs/1/explicit", `{"explicit": true}`, []string{editor, admin}},
+		{http.MethodPatch, "/songs/1/language", `{"language": "en"}`, []string{editor, admin}},
+		{http.MethodGet, "/stats/words?word=love", "", []string{reader, editor, admin}},
+		{http.MethodGet, "/charts", "", []string{reader, editor, admin}},
+		{http.MethodGet, "/songs/1/lyrics/synced", "", []string{reader, editor, admin}},
+		{http.MethodPut, "/songs/1/lyrics/synced", "[00:01.00]line", []string{editor, admin}},
+		{http.MethodGet, "/songs/1/chords", "", []string{reader, editor, admin}},
+		{http.MethodPut, "/songs/1/chords", "[C]line", []string{editor, admin}},
+		{http.MethodGet, "/songs/1", "", []string{reader, editor, admin}},
+		{http.MethodHead, "/songs/1", "", []string{reader, editor, admin}},
+		{http.MethodDelete, "/songs/1", "", []string{admin}},
+		{http.MethodPut, "/songs/1", `{"group": "Muse", "song": "Uprising", "release_date": "2009-09-07",
+			"text": "Paranoia is in bloom", "link": "https://example.com"}`, []string{editor, admin}},
+	}
+	for _, route := range routes {
+		for _, role := range []string{reader, editor, admin} {
+			t.Run(role+" "+route.method+" "+route.path, func(t *testing.T) {
+				r := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+				r.Header.Set("X-Test-Role", role)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+
+				allowed := false
+				for _, allowedRole := range route.allowed {
+					allowed = allowed || allowedRole == role
+				}
+				switch {
+				case allowed && (w.Code < 200 || w.Code > 299):
+					t.Errorf("status = %d, want 2xx: %s", w.Code, w.Body)
+				case !allowed && w.Code != http.StatusForbidden:
+					t.Errorf("status = %d, want 403: %s", w.Code, w.Body)
+				}
+			})
+		}
+	}
+}
+
+func TestAdminRoutesRejectTenantBoundAdmin(t *testing.T) {
+	policy, err := controller.NewPolicy(discardLog, "admin=*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	middlewares := Middlewares{Authenticate: controller.Authenticate(discardLog, roleAuthenticator{})}
+	// Контроллеры администрирования не нужны: запрос привязанного клиента должен отклоняться до них
+	router := LoadRoutes(controller.NewController(songServiceStub{}, discardLog), nil, nil, nil, nil, middlewares,
+		policy, config.ServerConfig{MaxBodySize: 1 << 20, RequestTimeout: 10 * time.Second})
+
+	routes := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/admin/api-keys", ""},
+		{http.MethodPost, "/admin/api-keys", `{"name": "ci", "role": "admin"}`},
+		{http.MethodDelete, "/admin/api-keys/1", ""},
+		{http.MethodGet, "/admin/tenants", ""},
+		{http.MethodPost, "/admin/tenants", `{"slug": "other", "name": "Other"}`},
+		{http.MethodGet, "/audit", ""},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			r := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+			r.Header.Set("X-Test-Role", "admin")
+			r.Header.Set("X-Test-Tenant", "acme")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403: %s", w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestRoutesSecurityHeaders(t *testing.T) {
+	policy, err := controller.NewPolicy(discardLog, "admin=*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	middlewares := Middlewares{Authenticate: controller.Authenticate(discardLog, roleAuthenticator{})}
+	router := LoadRoutes(controller.NewController(songServiceStub{}, discardLog), nil, nil, nil, nil, middlewares,
+		policy, config.ServerConfig{MaxBodySize: 64, RequestTimeout: 10 * time.Second})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantCSP    string
+	}{
+		{path: "/songs", wantStatus: http.StatusOK, wantCSP: "default-src 'none'"},
+		{path: "/swagger/index.html", wantStatus: http.StatusOK, wantCSP: "script-src 'self' 'unsafe-inline'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set("X-Test-Role", "admin")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if csp := w.Header().Get("Content-Security-Policy"); !strings.Contains(csp, tt.wantCSP) {
+				t.Errorf("Content-Security-Policy = %q, want %q", csp, tt.wantCSP)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/songs", strings.NewReader(`{"group": "`+strings.Repeat("a", 64)+`"}`))
+	r.Header.Set("X-Test-Role", "admin")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST /songs over MAX_BODY_SIZE: status = %d, want 413: %s", w.Code, w.Body)
+	}
+}
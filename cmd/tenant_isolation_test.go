@@ -0,0 +1,330 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"testEffectiveMobile/internal/auth"
+	"testEffectiveMobile/internal/controller"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/repository"
+	"testEffectiveMobile/internal/service"
+	"testEffectiveMobile/internal/service/mocks"
+	"testEffectiveMobile/internal/utils/config"
+	"testEffectiveMobile/internal/utils/content"
+	"testEffectiveMobile/internal/utils/langdetect"
+	"testEffectiveMobile/internal/utils/storage/storagetest"
+)
+
+const (
+	testAdminKey = "test-admin-key"
+	// testOwnerRole получает все разрешения на песни, но не admin, поэтому ключ с этой ролью можно привязать
+	// к арендатору
+	testOwnerRole = "owner"
+)
+
+// newTestRouter собирает приложение поверх db так же, как main, с входом по JWT и ключом администратора
+func newTestRouter(t *testing.T, db *gorm.DB) *chi.Mux {
+	t.Helper()
+	var cfg config.Config
+	if err := env.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Auth.AdminKey = testAdminKey
+	cfg.Auth.RolePermissions += ";" + testOwnerRole + "=songs:read,songs:suggest,songs:write,songs:moderate,songs:delete"
+	cfg.Auth.JWTAlgorithm = auth.AlgorithmHS256
+	cfg.Auth.JWTSecret = "test-jwt-secret"
+
+	repos := service.Repositories{
+		Songs:        repository.NewRepository(discardLog, db),
+		Translations: repository.NewTranslationRepository(discardLog, db),
+		Annotations:  repository.NewAnnotationRepository(discardLog, db),
+		Suggestions:  repository.NewSuggestionRepository(discardLog, db),
+		Users:        repository.NewUserRepository(discardLog, db),
+		Audit:        repository.NewAuditRepository(discardLog, db),
+	}
+	uow := repository.NewUnitOfWork(discardLog, db)
+	songService := service.NewService(repos, uow, discardLog, mocks.NewAPIClientMock(),
+		content.MustLoadClassifier(cfg.Content.ExplicitWordsDir), langdetect.MustLoadDetector())
+	policy, err := controller.NewPolicy(discardLog, cfg.Auth.RolePermissions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(discardLog, db), repos.Audit, discardLog,
+		cfg.Auth.AdminKey)
+	userService := service.NewUserService(repos.Users, repos.Audit, uow, discardLog, auth.MustLoadTokenIssuer(cfg.Auth))
+	tenantService := service.NewTenantService(repository.NewTenantRepository(discardLog, db), repos.Audit, discardLog)
+
+	middlewares := Middlewares{
+		Authenticate: controller.Authenticate(discardLog, auth.MustLoadAuthenticators(cfg.Auth, apiKeyService)...),
+		Tenant:       controller.ResolveTenant(discardLog, tenantService, policy, cfg.Auth.DefaultTenant),
+	}
+	return LoadRoutes(controller.NewController(songService, discardLog),
+		controller.NewAPIKeyController(apiKeyService, policy, discardLog),
+		controller.NewUserController(userService, discardLog),
+		controller.NewAuditController(service.NewAuditService(repos.Audit, discardLog), discardLog),
+		controller.NewTenantController(tenantService, discardLog), middlewares, policy, cfg.Server)
+}
+
+// testClient отправляет запросы к приложению с заголовками одного клиента
+type testClient struct {
+	t       *testing.T
+	router  http.Handler
+	headers map[string]string
+}
+
+func (c testClient) do(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	c.t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	for name, value := range c.headers {
+		r.Header.Set(name, value)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, r)
+	return w
+}
+
+// ok выполняет запрос, который должен завершиться успешно, и разбирает ответ в result
+func (c testClient) ok(result any, method, path, body string, headers ...string) {
+	c.t.Helper()
+	w := c.do(method, path, body, headers...)
+	if w.Code < 200 || w.Code > 299 {
+		c.t.Fatalf("%s %s: status = %d: %s", method, path, w.Code, w.Body)
+	}
+	if result != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), result); err != nil {
+			c.t.Fatalf("%s %s: decode response: %s", method, path, err)
+		}
+	}
+}
+
+// registerUser регистрирует пользователя в арендаторе tenantSlug и возвращает клиента с его токеном
+func registerUser(t *testing.T, router http.Handler, email, tenantSlug string) testClient {
+	t.Helper()
+	anonymous := testClient{t: t, router: router}
+	credentials := fmt.Sprintf(`{"email": %q, "password": "password123"}`, email)
+	anonymous.ok(nil, http.MethodPost, "/auth/register", credentials, controller.TenantHeader, tenantSlug)
+	var token models.AccessToken
+	anonymous.ok(&token, http.MethodPost, "/auth/login", credentials)
+	return testClient{t: t, router: router, headers: map[string]string{"Authorization": "Bearer " + token.AccessToken}}
+}
+
+// tenantKey создает ключ API с ролью owner, привязанный к арендатору tenantSlug, и возвращает клиента с ним
+func tenantKey(admin testClient, tenantSlug string) testClient {
+	admin.t.Helper()
+	var key models.CreatedAPIKey
+	admin.ok(&key, http.MethodPost, "/admin/api-keys",
+		fmt.Sprintf(`{"name": "%s owner", "role": %q, "tenant": %q}`, tenantSlug, testOwnerRole, tenantSlug))
+	return testClient{t: admin.t, router: admin.router, headers: map[string]string{"X-API-Key": key.Key}}
+}
+
+// hidden сообщает, что ответ не раскрывает данные: 404 или пустой список
+func hidden(w *httptest.ResponseRecorder) bool {
+	return w.Code == http.StatusNotFound || w.Code == http.StatusOK && strings.TrimSpace(w.Body.String()) == "[]"
+}
+
+const foreignText = "Is this the real life\nIs this just fantasy"
+
+func TestTenantIsolation(t *testing.T) {
+	router := newTestRouter(t, storagetest.Open(t))
+	admin := testClient{t: t, router: router, headers: map[string]string{"X-API-Key": testAdminKey}}
+	admin.ok(nil, http.MethodPost, "/admin/tenants", `{"slug": "acme", "name": "Acme"}`)
+
+	var own, foreign map[string]uint
+	admin.ok(&own, http.MethodPost, "/songs", `{"group": "Muse", "song": "Uprising"}`,
+		controller.TenantHeader, "default")
+	admin.ok(&foreign, http.MethodPost, "/songs", `{"group": "Queen", "song": "Bohemian Rhapsody"}`,
+		controller.TenantHeader, "acme")
+	ownID, foreignID := own["song_id"], foreign["song_id"]
+
+	alice := registerUser(t, router, "alice@example.com", "default")
+	bob := registerUser(t, router, "bob@example.com", "acme")
+	// owner может все с песнями арендатора default, поэтому его запросы к чужим песням отклоняются
+	// по арендатору, а не по роли
+	owner := tenantKey(admin, "default")
+	acme := tenantKey(admin, "acme")
+
+	// Данные песни арендатора acme, к которым затем обращаются клиенты арендатора default
+	song := func(path string) string { return fmt.Sprintf("/songs/%d"+path, foreignID) }
+	acme.ok(nil, http.MethodPut, song(""), `{"group": "Queen", "song": "Bohemian Rhapsody", "release_date": "1975-10-31",
+		"text": "`+foreignText+`", "link": "https://example.com"}`)
+	acme.ok(nil, http.MethodPost, song("/translations"), `{"language": "ru", "verses": ["Это настоящая жизнь"]}`)
+	var annotation models.Annotation
+	acme.ok(&annotation, http.MethodPost, song("/annotations"),
+		`{"verse": 1, "line": 1, "kind": "explanation", "body": "note"}`)
+	acme.ok(nil, http.MethodPut, song("/lyrics/synced"), "[00:01.00]Is this the real life")
+	acme.ok(nil, http.MethodPut, song("/chords"), "[C]Is this the real life")
+	var suggestion models.Suggestion
+	bob.ok(&suggestion, http.MethodPost, song("/suggestions"), `{"text": "Is this just fantasy"}`)
+	bob.ok(nil, http.MethodPost, "/me/history", fmt.Sprintf(`{"song_id": %d}`, foreignID))
+	bob.ok(nil, http.MethodPut, fmt.Sprintf("/me/favorites/%d", foreignID), "")
+	bob.ok(nil, http.MethodPut, fmt.Sprintf("/me/ratings/%d", foreignID), `{"rating": 5}`)
+	alice.ok(nil, http.MethodPost, "/me/history", fmt.Sprintf(`{"song_id": %d}`, ownID))
+	var foreignBefore models.SongDetails
+	bob.ok(&foreignBefore, http.MethodGet, song(""), "")
+	annotationPath := fmt.Sprintf("/annotations/%d", annotation.ID)
+	suggestionPath := fmt.Sprintf("/suggestions/%d", suggestion.ID)
+
+	t.Run("read", func(t *testing.T) {
+		var songs []models.Song
+		alice.ok(&songs, http.MethodGet, "/songs", "")
+		if len(songs) != 1 || songs[0].ID != ownID {
+			t.Errorf("songs = %+v, want only song %d", songs, ownID)
+		}
+		paths := []string{song(""), song("/verses"), song("/verses?lang=ru"), song("/verses/1"), song("/lyrics"),
+			song("/lyrics/annotated"), song("/lyrics/synced"), song("/chords"), song("/translations"),
+			song("/annotations"), song(annotationPath), song("/suggestions"), song(suggestionPath),
+			song(suggestionPath + "/diff"), song("/revisions"), song("/stats"), song("/similar"),
+			// Чужие аннотации и предложения не должны находиться и через песню своего арендатора
+			fmt.Sprintf("/songs/%d%s", ownID, annotationPath), fmt.Sprintf("/songs/%d%s", ownID, suggestionPath)}
+		for _, client := range []testClient{alice, owner} {
+			for _, path := range paths {
+				if w := client.do(http.MethodGet, path, ""); !hidden(w) {
+					t.Errorf("GET %s: status = %d, want 404 or empty list: %s", path, w.Code, w.Body)
+				}
+			}
+		}
+		var suggestions []models.Suggestion
+		owner.ok(&suggestions, http.MethodGet, "/suggestions", "")
+		for _, s := range suggestions {
+			if s.SongID == foreignID {
+				t.Errorf("moderation queue contains suggestion %d of another tenant", s.ID)
+			}
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		writes := []struct {
+			client testClient
+			method string
+			path   string
+			body   string
+		}{
+			{owner, http.MethodPut, song(""), `{"group": "Queen", "song": "Bohemian Rhapsody", "release_date": "1975-10-31",
+				"text": "Is this the real life", "link": "https://example.com"}`},
+			{owner, http.MethodPut, song("/explicit"), `{"explicit": true}`},
+			{owner, http.MethodPatch, song("/language"), `{"language": "de"}`},
+			{owner, http.MethodPost, song("/translations"), `{"language": "de", "verses": ["eins"]}`},
+			{owner, http.MethodPut, song("/translations/ru"), `{"language": "ru", "verses": ["один"]}`},
+			{owner, http.MethodPost, song("/annotations"), `{"verse": 1, "line": 1, "kind": "explanation", "body": "x"}`},
+			{owner, http.MethodPut, song(annotationPath), `{"verse": 1, "line": 1, "kind": "explanation", "body": "x"}`},
+			{owner, http.MethodPut, fmt.Sprintf("/songs/%d%s", ownID, annotationPath),
+				`{"verse": 1, "line": 1, "kind": "explanation", "body": "x"}`},
+			{owner, http.MethodDelete, song(annotationPath), ""},
+			{owner, http.MethodPut, song("/lyrics/synced"), "[00:02.00]Caught in a landslide"},
+			{owner, http.MethodPut, song("/chords"), "[Am]Caught in a landslide"},
+			{owner, http.MethodPost, song("/suggestions"), `{"text": "Is this just fantasy"}`},
+			{owner, http.MethodPost, song(suggestionPath + "/approve"), ""},
+			{owner, http.MethodPost, fmt.Sprintf("/songs/%d%s/approve", ownID, suggestionPath), ""},
+			{owner, http.MethodPost, song(suggestionPath + "/reject"), `{"reason": "spam"}`},
+			{owner, http.MethodDelete, song(""), ""},
+			{alice, http.MethodPost, song("/suggestions"), `{"text": "Is this just fantasy"}`},
+			{alice, http.MethodPost, "/me/history", fmt.Sprintf(`{"song_id": %d}`, foreignID)},
+			{alice, http.MethodPut, fmt.Sprintf("/me/favorites/%d", foreignID), ""},
+			{alice, http.MethodDelete, fmt.Sprintf("/me/favorites/%d", foreignID), ""},
+			{alice, http.MethodPut, fmt.Sprintf("/me/ratings/%d", foreignID), `{"rating": 1}`},
+			{alice, http.MethodDelete, fmt.Sprintf("/me/ratings/%d", foreignID), ""},
+		}
+		for _, write := range writes {
+			if w := write.client.do(write.method, write.path, write.body); w.Code != http.StatusNotFound {
+				t.Errorf("%s %s: status = %d, want 404: %s", write.method, write.path, w.Code, w.Body)
+			}
+		}
+
+		var foreignAfter models.SongDetails
+		bob.ok(&foreignAfter, http.MethodGet, song(""), "")
+		if foreignAfter.Text != foreignBefore.Text || !foreignAfter.UpdatedAt.Equal(foreignBefore.UpdatedAt) ||
+			foreignAfter.ExplicitOverride != nil || foreignAfter.LanguageManual ||
+			foreignAfter.RatingCount != foreignBefore.RatingCount {
+			t.Errorf("foreign song changed: %+v, was %+v", foreignAfter, foreignBefore)
+		}
+		var translations []models.Translation
+		bob.ok(&translations, http.MethodGet, song("/translations"), "")
+		if len(translations) != 1 || translations[0].Language != "ru" || translations[0].Verses[0] != "Это настоящая жизнь" {
+			t.Errorf("foreign song translations = %+v", translations)
+		}
+		var foreignAnnotation models.Annotation
+		bob.ok(&foreignAnnotation, http.MethodGet, song(annotationPath), "")
+		if foreignAnnotation.Body != "note" {
+			t.Errorf("foreign annotation = %+v", foreignAnnotation)
+		}
+		var synced models.SyncedLyrics
+		bob.ok(&synced, http.MethodGet, song("/lyrics/synced"), "")
+		if len(synced.Lines) != 1 || synced.Lines[0].Text != "Is this the real life" {
+			t.Errorf("foreign synced lyrics = %+v", synced)
+		}
+		var chords models.Chords
+		bob.ok(&chords, http.MethodGet, song("/chords"), "")
+		if len(chords.Lines) != 1 || len(chords.Lines[0].Chords) != 1 || chords.Lines[0].Chords[0].Chord != "C" {
+			t.Errorf("foreign chords = %+v", chords)
+		}
+		var suggestions []models.Suggestion
+		bob.ok(&suggestions, http.MethodGet, song("/suggestions"), "")
+		if len(suggestions) != 1 || suggestions[0].Status != models.SuggestionPending {
+			t.Errorf("foreign song suggestions = %+v, want only bob's pending suggestion", suggestions)
+		}
+		var favorites []models.FavoriteSong
+		bob.ok(&favorites, http.MethodGet, "/me/favorites", "")
+		if len(favorites) != 1 {
+			t.Errorf("bob's favorites = %+v, want the foreign song", favorites)
+		}
+	})
+
+	t.Run("favorites and history", func(t *testing.T) {
+		var favorites []models.FavoriteSong
+		alice.ok(&favorites, http.MethodGet, "/me/favorites", "")
+		if len(favorites) != 0 {
+			t.Errorf("favorites = %+v, want none", favorites)
+		}
+		var history []models.HistoryEntry
+		alice.ok(&history, http.MethodGet, "/me/history", "")
+		if len(history) != 1 || history[0].ID != ownID {
+			t.Errorf("history = %+v, want only song %d", history, ownID)
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		var chart models.Chart
+		alice.ok(&chart, http.MethodGet, "/charts", "")
+		for _, entry := range chart.Entries {
+			if entry.ID != ownID {
+				t.Errorf("chart contains song %d of another tenant", entry.ID)
+			}
+		}
+		var usage models.WordUsage
+		alice.ok(&usage, http.MethodGet, "/stats/words?word=the", "")
+		for _, song := range usage.Songs {
+			if song.SongID != ownID {
+				t.Errorf("word usage contains song %d of another tenant", song.SongID)
+			}
+		}
+	})
+
+	t.Run("spoofed tenant header", func(t *testing.T) {
+		for _, client := range []testClient{alice, owner} {
+			for _, path := range []string{"/songs", song("")} {
+				if w := client.do(http.MethodGet, path, "", controller.TenantHeader, "acme"); w.Code != http.StatusForbidden {
+					t.Errorf("GET %s: status = %d, want 403: %s", path, w.Code, w.Body)
+				}
+			}
+		}
+		path := fmt.Sprintf("/me/favorites/%d", foreignID)
+		if w := alice.do(http.MethodPut, path, "", controller.TenantHeader, "acme"); w.Code != http.StatusForbidden {
+			t.Errorf("PUT %s: status = %d, want 403: %s", path, w.Code, w.Body)
+		}
+		if w := owner.do(http.MethodDelete, song(""), "", controller.TenantHeader, "acme"); w.Code != http.StatusForbidden {
+			t.Errorf("DELETE %s: status = %d, want 403: %s", song(""), w.Code, w.Body)
+		}
+	})
+}
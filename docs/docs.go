@@ -15,132 +15,182 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/songs": {
+        "/admin/api-keys": {
             "get": {
-                "description": "Get a list of songs with filters and pagination",
-                "summary": "Get songs with params",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Group name",
-                        "name": "group",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Song name",
-                        "name": "name",
-                        "in": "query"
-                    },
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Song id",
-                        "name": "id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
+                        "ApiKeyAuth": []
                     },
                     {
-                        "type": "integer",
-                        "description": "Page size",
-                        "name": "page_size",
-                        "in": "query"
+                        "BearerAuth": []
                     }
                 ],
+                "description": "List API keys including revoked ones. Key values are not returned",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List API keys",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/testEffectiveMobile_internal_models.Song"
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.APIKey"
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Failures"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a song with enrichment",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a static API key. The key is returned only in this response, only its hash is stored. A key with a tenant can access only that tenant's song library and cannot have a role with the admin permission",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Create song with enrichment",
+                "summary": "Create API key",
                 "parameters": [
                     {
-                        "description": "Name and group of the song",
+                        "description": "Key name",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_controller.Request"
+                            "$ref": "#/definitions/internal_controller.APIKeyRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request, the stored response is replayed for repeated keys",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.CreateSongResponse"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.CreatedAPIKey"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Failures"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Failures"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
                         }
                     }
                 }
             }
         },
-        "/songs/{id}": {
-            "put": {
-                "description": "Update song by id. You can provide partial updates, for example {\"text\": \"new text\"}.",
-                "consumes": [
-                    "application/json"
+        "/admin/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Revoke an API key. Requests with this key are rejected from now on",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Update song by id (partial updates allowed)",
+                "summary": "Revoke API key",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Song id",
+                        "description": "API key id",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Partial Song object",
-                        "name": "request",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.SongWithoutID"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "song updated",
+                        "description": "api key revoked",
                         "schema": {
                             "type": "string"
                         }
@@ -148,62 +198,254 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Failures"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/tenants": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List tenants",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List tenants",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.Tenant"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Failures"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete song by id",
-                "summary": "Delete song by id",
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a tenant with its own song library. Admins and API keys without a tenant select it with the X-Tenant header, other clients are bound to it by their API key or token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create tenant",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Song id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Tenant slug and name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.TenantRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request, the stored response is replayed for repeated keys",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "song deleted",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Tenant"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Failures"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Failures"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
                         }
                     }
                 }
             }
         },
-        "/songs/{id}/verses": {
+        "/audit": {
             "get": {
-                "description": "Get verses by song id with pagination",
-                "summary": "Get verses by song id",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get create, update and delete operations with the client, request id, IP and the resource state before and after the change, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Audit log",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Song id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "Client subject, e.g. user:1, api-key:2 or admin",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "create, update or delete",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "song, translation, annotation, synced_lyrics, chords, suggestion, api_key, user, tenant, favorite, play or rating",
+                        "name": "resource",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource id. Translations use \u003csong id\u003e/\u003clanguage\u003e, favorites and ratings use \u003cuser id\u003e/\u003csong id\u003e",
+                        "name": "resource_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the time range in RFC 3339, inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the time range in RFC 3339, exclusive",
+                        "name": "to",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
@@ -220,24 +462,4227 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "verses",
+                        "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.AuditEntry"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Failures"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Failures"
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Check the email and password and issue a JWT for the Authorization: Bearer header.\nReturns 503 if JWT issuing is not configured",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Log in",
+                "parameters": [
+                    {
+                        "description": "Email and password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.AccessToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Create a user account with the reader role in the tenant from X-Tenant or the default tenant. The user's tokens are bound to this tenant. The password is stored as a bcrypt hash",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Register user",
+                "parameters": [
+                    {
+                        "description": "Email and password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.RegisterRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tenant slug, the default tenant is used if empty",
+                        "name": "X-Tenant",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/charts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the most popular songs over a period. Score is the number of plays plus 2 points for every rating point above 3 (minus below 3) given during the period",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get top songs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Period: day, week, month, year or all (default week)",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of songs, 1-100 (default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Chart"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the account of the user the token was issued to",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/favorites": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the current user's favorite songs with pagination, most recently added first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get favorite songs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.FavoriteSong"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/favorites/{songID}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a song to the current user's favorites. Adding the same song again is not an error",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add song to favorites",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "songID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "song added to favorites",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a song from the current user's favorites",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Remove song from favorites",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "songID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "song removed from favorites",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the songs played by the current user with pagination, most recent first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get listening history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.HistoryEntry"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a play of the song to the current user's listening history",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Record song play",
+                "parameters": [
+                    {
+                        "description": "Played song",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.PlayRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request, the stored response is replayed for repeated keys",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Play"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/ratings/{songID}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rate a song from 1 to 5. A user has one rating per song, rating again replaces it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Rate song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "songID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rating",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.RatingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.SongRating"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the current user's rating of the song",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete song rating",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "songID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.SongRating"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of songs with filters and pagination",
+                "summary": "Get songs with params",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by explicit content flag",
+                        "name": "explicit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by language code",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order: popularity (most played first) or rating (highest average rating first)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.Song"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a song with enrichment",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create song with enrichment",
+                "parameters": [
+                    {
+                        "description": "Name and group of the song",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.Request"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request, the stored response is replayed for repeated keys",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.CreateSongResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/languages": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the number of songs in each language. Accepts the same filters as GET /songs, the language filter is ignored",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Count songs by language",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by explicit content flag",
+                        "name": "explicit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.LanguageFacet"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a single song with computed fields. HEAD returns the same headers without a body",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get song by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated expansions: verses",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.SongDetails"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update song by id. You can provide partial updates, for example {\"text\": \"new text\"}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update song by id (partial updates allowed)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Partial Song object",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.UpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "song updated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete song by id",
+                "summary": "Delete song by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "song deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a single song with computed fields. HEAD returns the same headers without a body",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get song by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated expansions: verses",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.SongDetails"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/annotations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all annotations of the song ordered by position in the text",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List song annotations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.Annotation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Attach an explanation or reference to a verse line or a character range within it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Annotate a fragment of lyrics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Annotation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.AnnotationRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request, the stored response is replayed for repeated keys",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Annotation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/annotations/{annotationID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a single annotation of the song",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get annotation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Annotation id",
+                        "name": "annotationID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Annotation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace annotation text and position. The fragment is re-read from the current song text",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update annotation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Annotation id",
+                        "name": "annotationID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Annotation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.AnnotationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Annotation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete annotation of the song",
+                "summary": "Delete annotation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Annotation id",
+                        "name": "annotationID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "annotation deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/chords": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the chord sheet of the song, optionally transposed. Sharps or flats are chosen by the resulting key.\nWith format=text returns plain text with chords aligned above lyric lines",
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "summary": "Get chords",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Semitones to transpose by, from -11 to 11 (e.g. +2 or -3)",
+                        "name": "transpose",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set to text to render plain text",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Chords"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the chord sheet of the song. Chords are written in square brackets inside lyric lines ([Am]text),\ndirectives title, artist, key, comment and start/end of verse, chorus and bridge are supported",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Import chords in ChordPro format",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ChordPro text",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Chords"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/explicit": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the explicit flag manually. {\"explicit\": null} removes the override and the flag is detected from the lyrics again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Override explicit content flag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Explicit override",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.ExplicitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/language": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the language of the lyrics manually. {\"language\": null} removes the correction and the language is detected from the lyrics again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Correct song language",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Language code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.LanguageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get song lyrics split into typed sections (verse, chorus, bridge, ...) in order of appearance",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get structured lyrics by song id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Lyrics"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics/annotated": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get song verses where annotated fragments are marked inline, together with the annotations.\nOrphaned annotations (whose fragment was removed from the text) are listed but not marked",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get lyrics with annotation markers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.AnnotatedLyrics"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics/synced": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get time-synced lyrics of the song. With at=mm:ss returns the line playing at that moment and surrounding lines,\nwith format=lrc returns the lyrics as an LRC file",
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "summary": "Get synced lyrics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Playback position in mm:ss or mm:ss.xx format",
+                        "name": "at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of lines before and after the current one (default 2)",
+                        "name": "context",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set to lrc to export LRC file",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.SyncedLyricsPosition"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace time-synced lyrics of the song with lines from an LRC file. Timestamps must go in non-decreasing order",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Import synced lyrics in LRC format",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "LRC file",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.SyncedLyrics"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get versions of the song text recorded when suggestions were approved, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List lyrics revisions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.Revision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/similar": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get songs ranked by TF-IDF cosine similarity of lyrics combined with same artist and release year proximity",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get similar songs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of songs, 1-50 (default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.SimilarSong"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get word, line, verse and unique word counts of the song and its most frequent words excluding stop words",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get lyrics statistics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of most frequent words, 1-100 (default 10)",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.SongStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/suggestions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get suggestions for the song with pagination, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List song suggestions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status: pending, approved or rejected",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.Suggestion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Propose a new text of the song. The change is applied only after a moderator approves it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Suggest lyrics fix",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Proposed text",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.SuggestionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request, the stored response is replayed for repeated keys",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Suggestion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/suggestions/{suggestionID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a single suggestion of the song",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get suggestion",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Suggestion id",
+                        "name": "suggestionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Suggestion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/suggestions/{suggestionID}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply the suggested text to the song as PUT /songs/{id} does and record a revision. Fails with 409 if the text changed after the suggestion was made or the suggestion is already reviewed",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Approve suggestion",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Suggestion id",
+                        "name": "suggestionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Suggestion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/suggestions/{suggestionID}/diff": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Line diff of the suggested text against the current text of the song. stale=true means the text changed after the suggestion was made and it can no longer be approved. ?format=text returns the diff as plain text with \"+ \" and \"- \" line prefixes",
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "summary": "Compare suggestion with current text",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Suggestion id",
+                        "name": "suggestionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format: json (default) or text",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.SuggestionDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/suggestions/{suggestionID}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reject the suggestion with a reason shown to its author",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Reject suggestion",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Suggestion id",
+                        "name": "suggestionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.RejectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Suggestion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/translations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all translations of the song",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List song translations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.Translation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a translation of the song lyrics. Verses are aligned with the original by index, so their number must match",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create song translation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Translation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.TranslationRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request, the stored response is replayed for repeated keys",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Translation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/translations/{lang}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace verses of an existing translation. The language in the body is ignored",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update song translation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language code",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Translation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller.TranslationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Translation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/verses": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get song text with pagination by verses (default) or by lines (mode=lines). With lang returns original verses\npaired with the translation by index. Pages past the end are empty. The body is an array of page items,\nnavigation data is returned in headers",
+                "summary": "Get verses by song id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pagination mode: verses or lines",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Translation language code",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "verses with translation (lang)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.VersePair"
+                            }
+                        },
+                        "headers": {
+                            "X-Page": {
+                                "type": "integer",
+                                "description": "Page number"
+                            },
+                            "X-Page-Size": {
+                                "type": "integer",
+                                "description": "Page size"
+                            },
+                            "X-Pagination-Mode": {
+                                "type": "string",
+                                "description": "Pagination mode: verses or lines"
+                            },
+                            "X-Total-Lines": {
+                                "type": "integer",
+                                "description": "Number of lines in the song"
+                            },
+                            "X-Total-Pages": {
+                                "type": "integer",
+                                "description": "Number of pages"
+                            },
+                            "X-Total-Verses": {
+                                "type": "integer",
+                                "description": "Number of verses in the song"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/verses/{n}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get one verse of the song by its number (starting from 1)",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get single verse",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Verse number",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Verse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/words": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get songs whose lyrics contain the word and how many times it is used in each, most frequent first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Find songs using a word",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Word to look up",
+                        "name": "word",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.WordUsage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/suggestions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get suggestions for all songs with pagination, oldest first. Use status=pending for the moderation queue",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Moderation queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by status: pending, approved or rejected",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/testEffectiveMobile_internal_models.Suggestion"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/testEffectiveMobile_internal_models.Problem"
                         }
                     }
                 }
@@ -245,50 +4690,1130 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "internal_controller.APIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "role": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "tenant": {
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "internal_controller.AnnotationRequest": {
+            "type": "object",
+            "required": [
+                "body",
+                "kind"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "end": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "explanation",
+                        "reference"
+                    ]
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "integer"
+                },
+                "verse": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controller.ExplicitRequest": {
+            "type": "object",
+            "properties": {
+                "explicit": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "internal_controller.LanguageRequest": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller.PlayRequest": {
+            "type": "object",
+            "properties": {
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controller.RatingRequest": {
+            "type": "object",
+            "properties": {
+                "rating": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controller.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
+                }
+            }
+        },
+        "internal_controller.RejectRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
         "internal_controller.Request": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "internal_controller.SuggestionRequest": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 50000
+                }
+            }
+        },
+        "internal_controller.TenantRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "slug"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "internal_controller.TranslationRequest": {
+            "type": "object",
+            "required": [
+                "language"
+            ],
+            "properties": {
+                "language": {
+                    "type": "string"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_controller.UpdateRequest": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "link": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 50000
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.APIKey": {
+            "description": "ключ доступа к API",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "tenant": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.AccessToken": {
+            "description": "токен доступа",
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.AnnotatedLine": {
+            "description": "строка текста с маркерами аннотаций",
+            "type": "object",
+            "properties": {
+                "marked": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.AnnotatedSegment"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.AnnotatedLyrics": {
+            "description": "текст песни с аннотациями",
+            "type": "object",
+            "properties": {
+                "annotations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.Annotation"
+                    }
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.AnnotatedVerse"
+                    }
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.AnnotatedSegment": {
+            "description": "часть строки с аннотациями",
+            "type": "object",
+            "properties": {
+                "annotation_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.AnnotatedVerse": {
+            "description": "куплет с аннотированными строками",
+            "type": "object",
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.AnnotatedLine"
+                    }
+                },
+                "number": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Annotation": {
+            "description": "аннотация к фрагменту текста",
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "orphaned": {
+                    "type": "boolean"
+                },
+                "quote": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "verse": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.AuditEntry": {
+            "description": "запись журнала аудита",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "client_ip": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "resource": {
+                    "type": "string"
+                },
+                "resource_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Chart": {
+            "description": "чарт песен",
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.ChartEntry"
+                    }
+                },
+                "period": {
+                    "type": "string"
+                },
+                "since": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.ChartEntry": {
+            "description": "место в чарте",
+            "type": "object",
+            "properties": {
+                "average_rating": {
+                    "type": "number"
+                },
+                "plays": {
+                    "description": "Plays, Ratings и AverageRating считаются только за период чарта",
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "ratings": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "song": {
+                    "$ref": "#/definitions/testEffectiveMobile_internal_models.Song"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.ChordLine": {
+            "description": "строка текста с аккордами",
+            "type": "object",
+            "properties": {
+                "chords": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.ChordPosition"
+                    }
+                },
+                "comment": {
+                    "type": "boolean"
+                },
+                "section": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.ChordPosition": {
+            "description": "аккорд над строкой",
+            "type": "object",
+            "properties": {
+                "chord": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Chords": {
+            "description": "текст песни с аккордами",
+            "type": "object",
+            "properties": {
+                "artist": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.ChordLine"
+                    }
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "transpose": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.ContentFlagReason": {
+            "description": "причина пометки песни как откровенной",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "word": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.CreateSongResponse": {
+            "type": "object",
+            "properties": {
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.CreatedAPIKey": {
+            "description": "созданный ключ доступа к API",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "tenant": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.DiffLine": {
+            "description": "строка сравнения текстов",
+            "type": "object",
+            "properties": {
+                "new_line": {
+                    "type": "integer"
+                },
+                "old_line": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.FavoriteSong": {
+            "description": "песня в избранном",
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "explicit": {
+                    "description": "Explicit - итоговый признак откровенного содержания: ручное значение ExplicitOverride, если оно задано,\nиначе результат проверки текста, причины которого перечислены в ExplicitReasons. nil, пока текст\nпесни, добавленной до появления проверки, не проверен",
+                    "type": "boolean"
+                },
+                "explicit_override": {
+                    "type": "boolean"
+                },
+                "explicit_reasons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.ContentFlagReason"
+                    }
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "description": "Language - код языка текста, определенный автоматически с уверенностью LanguageConfidence\nили заданный вручную (LanguageManual). Пустой, если язык определить не удалось, и nil, пока язык\nпесни, добавленной до появления определения, не определен",
+                    "type": "string"
+                },
+                "language_confidence": {
+                    "type": "number"
+                },
+                "language_manual": {
+                    "type": "boolean"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "play_count": {
+                    "description": "PlayCount, RatingCount и RatingAverage - счетчики, которые база обновляет при прослушиваниях и оценках\nпользователей. Через модель они только читаются, чтобы обновление песни их не перезаписывало",
+                    "type": "integer"
+                },
+                "rating_average": {
+                    "type": "number"
+                },
+                "rating_count": {
+                    "type": "integer"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.HistoryEntry": {
+            "description": "запись истории прослушиваний",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "explicit": {
+                    "description": "Explicit - итоговый признак откровенного содержания: ручное значение ExplicitOverride, если оно задано,\nиначе результат проверки текста, причины которого перечислены в ExplicitReasons. nil, пока текст\nпесни, добавленной до появления проверки, не проверен",
+                    "type": "boolean"
+                },
+                "explicit_override": {
+                    "type": "boolean"
+                },
+                "explicit_reasons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.ContentFlagReason"
+                    }
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "description": "Language - код языка текста, определенный автоматически с уверенностью LanguageConfidence\nили заданный вручную (LanguageManual). Пустой, если язык определить не удалось, и nil, пока язык\nпесни, добавленной до появления определения, не определен",
+                    "type": "string"
+                },
+                "language_confidence": {
+                    "type": "number"
+                },
+                "language_manual": {
+                    "type": "boolean"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "play_count": {
+                    "description": "PlayCount, RatingCount и RatingAverage - счетчики, которые база обновляет при прослушиваниях и оценках\nпользователей. Через модель они только читаются, чтобы обновление песни их не перезаписывало",
+                    "type": "integer"
+                },
+                "played_at": {
+                    "type": "string"
+                },
+                "rating_average": {
+                    "type": "number"
+                },
+                "rating_count": {
+                    "type": "integer"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.LanguageFacet": {
+            "description": "количество песен на языке",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "language": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Lyrics": {
+            "description": "структурированный текст песни",
+            "type": "object",
+            "properties": {
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.LyricsSection"
+                    }
+                },
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.LyricsSection": {
+            "description": "часть текста песни",
+            "type": "object",
+            "properties": {
+                "label": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "repeat": {
+                    "type": "boolean"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Play": {
+            "description": "прослушивание песни",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "played_at": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Problem": {
+            "description": "ошибка",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Revision": {
+            "description": "версия текста песни",
+            "type": "object",
+            "properties": {
+                "approved_by": {
+                    "type": "string"
+                },
+                "author": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "previous_text": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "suggestion_id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.SimilarSong": {
+            "description": "похожая песня",
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "lyrics_score": {
+                    "type": "number"
+                },
+                "same_artist": {
+                    "type": "boolean"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "year_score": {
+                    "type": "number"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Song": {
+            "description": "песня",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "explicit": {
+                    "description": "Explicit - итоговый признак откровенного содержания: ручное значение ExplicitOverride, если оно задано,\nиначе результат проверки текста, причины которого перечислены в ExplicitReasons. nil, пока текст\nпесни, добавленной до появления проверки, не проверен",
+                    "type": "boolean"
+                },
+                "explicit_override": {
+                    "type": "boolean"
+                },
+                "explicit_reasons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.ContentFlagReason"
+                    }
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "description": "Language - код языка текста, определенный автоматически с уверенностью LanguageConfidence\nили заданный вручную (LanguageManual). Пустой, если язык определить не удалось, и nil, пока язык\nпесни, добавленной до появления определения, не определен",
+                    "type": "string"
+                },
+                "language_confidence": {
+                    "type": "number"
+                },
+                "language_manual": {
+                    "type": "boolean"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "play_count": {
+                    "description": "PlayCount, RatingCount и RatingAverage - счетчики, которые база обновляет при прослушиваниях и оценках\nпользователей. Через модель они только читаются, чтобы обновление песни их не перезаписывало",
+                    "type": "integer"
+                },
+                "rating_average": {
+                    "type": "number"
+                },
+                "rating_count": {
+                    "type": "integer"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.SongDetails": {
+            "description": "песня с вычисляемыми полями",
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "explicit": {
+                    "description": "Explicit - итоговый признак откровенного содержания: ручное значение ExplicitOverride, если оно задано,\nиначе результат проверки текста, причины которого перечислены в ExplicitReasons. nil, пока текст\nпесни, добавленной до появления проверки, не проверен",
+                    "type": "boolean"
+                },
+                "explicit_override": {
+                    "type": "boolean"
+                },
+                "explicit_reasons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.ContentFlagReason"
+                    }
+                },
                 "group": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "description": "Language - код языка текста, определенный автоматически с уверенностью LanguageConfidence\nили заданный вручную (LanguageManual). Пустой, если язык определить не удалось, и nil, пока язык\nпесни, добавленной до появления определения, не определен",
+                    "type": "string"
+                },
+                "language_confidence": {
+                    "type": "number"
+                },
+                "language_manual": {
+                    "type": "boolean"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "play_count": {
+                    "description": "PlayCount, RatingCount и RatingAverage - счетчики, которые база обновляет при прослушиваниях и оценках\nпользователей. Через модель они только читаются, чтобы обновление песни их не перезаписывало",
+                    "type": "integer"
+                },
+                "rating_average": {
+                    "type": "number"
+                },
+                "rating_count": {
+                    "type": "integer"
+                },
+                "release_date": {
+                    "type": "string"
+                },
                 "song": {
                     "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "verse_count": {
+                    "type": "integer"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "word_count": {
+                    "type": "integer"
                 }
             }
         },
-        "testEffectiveMobile_internal_models.CreateSongResponse": {
+        "testEffectiveMobile_internal_models.SongRating": {
+            "description": "оценка песни",
             "type": "object",
             "properties": {
+                "rating": {
+                    "type": "integer"
+                },
+                "rating_average": {
+                    "type": "number"
+                },
+                "rating_count": {
+                    "type": "integer"
+                },
                 "song_id": {
                     "type": "integer"
                 }
             }
         },
-        "testEffectiveMobile_internal_models.Failures": {
+        "testEffectiveMobile_internal_models.SongStats": {
+            "description": "статистика текста песни",
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
+                "line_count": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "top_words": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.WordFrequency"
+                    }
+                },
+                "unique_words": {
+                    "type": "integer"
+                },
+                "verse_count": {
+                    "type": "integer"
+                },
+                "word_count": {
+                    "type": "integer"
                 }
             }
         },
-        "testEffectiveMobile_internal_models.Song": {
-            "description": "песня",
+        "testEffectiveMobile_internal_models.SongWordCount": {
+            "description": "употребления слова в песне",
             "type": "object",
             "properties": {
+                "count": {
+                    "type": "integer"
+                },
                 "group": {
                     "type": "string"
                 },
+                "song": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Suggestion": {
+            "description": "предложение правки текста",
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "link": {
+                "reason": {
                     "type": "string"
                 },
-                "releaseDate": {
+                "reviewed_at": {
                     "type": "string"
                 },
-                "song": {
+                "reviewed_by": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "status": {
                     "type": "string"
                 },
                 "text": {
@@ -296,26 +5821,256 @@ const docTemplate = `{
                 }
             }
         },
-        "testEffectiveMobile_internal_models.SongWithoutID": {
+        "testEffectiveMobile_internal_models.SuggestionDiff": {
+            "description": "сравнение предложения с текущим текстом",
             "type": "object",
             "properties": {
-                "group": {
+                "added": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.DiffLine"
+                    }
+                },
+                "removed": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "stale": {
+                    "type": "boolean"
+                },
+                "suggestion_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.SyncedLine": {
+            "description": "строка текста с временной меткой",
+            "type": "object",
+            "properties": {
+                "position": {
+                    "type": "integer"
+                },
+                "text": {
                     "type": "string"
                 },
-                "link": {
+                "time": {
+                    "type": "string"
+                },
+                "time_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.SyncedLyrics": {
+            "description": "синхронизированный текст песни",
+            "type": "object",
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.SyncedLine"
+                    }
+                },
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.SyncedLyricsPosition": {
+            "description": "текущая и соседние строки синхронизированного текста",
+            "type": "object",
+            "properties": {
+                "at_ms": {
+                    "type": "integer"
+                },
+                "current": {
+                    "$ref": "#/definitions/testEffectiveMobile_internal_models.SyncedLine"
+                },
+                "next": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.SyncedLine"
+                    }
+                },
+                "previous": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.SyncedLine"
+                    }
+                },
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Tenant": {
+            "description": "арендатор",
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
-                "releaseDate": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
                 },
-                "song": {
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Translation": {
+            "description": "перевод текста песни",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.User": {
+            "description": "пользователь",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "tenant": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.Verse": {
+            "description": "куплет песни",
+            "type": "object",
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "total_verses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.VerseLine": {
+            "description": "строка текста песни",
+            "type": "object",
+            "properties": {
+                "line": {
+                    "type": "integer"
+                },
                 "text": {
                     "type": "string"
+                },
+                "verse": {
+                    "type": "integer"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.VersePair": {
+            "description": "куплет оригинала и перевода",
+            "type": "object",
+            "properties": {
+                "index": {
+                    "type": "integer"
+                },
+                "original": {
+                    "type": "string"
+                },
+                "translation": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.WordFrequency": {
+            "description": "частота слова",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "word": {
+                    "type": "string"
+                }
+            }
+        },
+        "testEffectiveMobile_internal_models.WordUsage": {
+            "description": "употребления слова в библиотеке",
+            "type": "object",
+            "properties": {
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/testEffectiveMobile_internal_models.SongWordCount"
+                    }
+                },
+                "total_occurrences": {
+                    "type": "integer"
+                },
+                "total_songs": {
+                    "type": "integer"
+                },
+                "word": {
+                    "type": "string"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT in the form \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testEffectiveMobile/internal/models"
+)
+
+// APIKeyHeader - заголовок со статическим ключом API
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyVerifier находит клиента по значению ключа
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*models.Principal, error)
+}
+
+// APIKeyAuthenticator аутентифицирует клиента по заголовку X-API-Key
+type APIKeyAuthenticator struct {
+	keys APIKeyVerifier
+}
+
+func NewAPIKeyAuthenticator(keys APIKeyVerifier) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{keys: keys}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*models.Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, nil
+	}
+	return a.keys.VerifyAPIKey(r.Context(), key)
+}
@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"fmt"
+	"testEffectiveMobile/internal/controller"
+	"testEffectiveMobile/internal/utils/config"
+)
+
+// MustLoadAuthenticators возвращает способы аутентификации в порядке проверки: ключ API, затем JWT,
+// если задан алгоритм. Выдает панику при неверной настройке JWT
+func MustLoadAuthenticators(cfg config.AuthConfig, keys APIKeyVerifier) []controller.Authenticator {
+	authenticators := []controller.Authenticator{NewAPIKeyAuthenticator(keys)}
+	if cfg.JWTAlgorithm == "" {
+		return authenticators
+	}
+	jwtAuthenticator, err := NewJWTAuthenticator(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("failed to configure JWT authentication: %s", err.Error()))
+	}
+	return append(authenticators, jwtAuthenticator)
+}
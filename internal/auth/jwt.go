@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"os"
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/config"
+)
+
+// Поддерживаемые алгоритмы подписи JWT
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// claims - поля токена, из которых строится Principal. Tenant привязывает токен к арендатору
+type claims struct {
+	jwt.RegisteredClaims
+	Name   string   `json:"name,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+	Tenant string   `json:"tenant,omitempty"`
+}
+
+// JWTAuthenticator аутентифицирует клиента по заголовку Authorization: Bearer <token>
+type JWTAuthenticator struct {
+	parser *jwt.Parser
+	key    any
+}
+
+// NewJWTAuthenticator создает проверку токенов, подписанных алгоритмом cfg.JWTAlgorithm.
+// Срок действия (exp) обязателен, issuer и audience проверяются, если заданы
+func NewJWTAuthenticator(cfg config.AuthConfig) (*JWTAuthenticator, error) {
+	var key any
+	switch cfg.JWTAlgorithm {
+	case AlgorithmHS256:
+		if cfg.JWTSecret == "" {
+			return nil, fmt.Errorf("JWT_SECRET is required for %s", AlgorithmHS256)
+		}
+		key = []byte(cfg.JWTSecret)
+	case AlgorithmRS256:
+		pem, err := os.ReadFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read JWT public key: %w", err)
+		}
+		key, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parse JWT public key: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", cfg.JWTAlgorithm)
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{cfg.JWTAlgorithm}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.JWTIssuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		options = append(options, jwt.WithAudience(cfg.JWTAudience))
+	}
+	return &JWTAuthenticator{parser: jwt.NewParser(options...), key: key}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*models.Principal, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, nil
+	}
+	var tokenClaims claims
+	_, err := a.parser.ParseWithClaims(strings.TrimSpace(token), &tokenClaims, func(*jwt.Token) (any, error) {
+		return a.key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w: %s", models.ErrUnauthorized, err.Error())
+	}
+	if tokenClaims.Subject == "" {
+		return nil, fmt.Errorf("token has no subject: %w", models.ErrUnauthorized)
+	}
+	return &models.Principal{
+		Subject: tokenClaims.Subject,
+		Name:    tokenClaims.Name,
+		Method:  models.AuthMethodJWT,
+		Roles:   tokenClaims.Roles,
+		Tenant:  tokenClaims.Tenant,
+	}, nil
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/config"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-jwt-secret"
+
+// writeRSAKeys создает пару ключей RS256 и возвращает закрытый ключ, путь к PEM-файлу открытого ключа и его содержимое
+func writeRSAKeys(t *testing.T) (*rsa.PrivateKey, string, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	path := filepath.Join(t.TempDir(), "public.pem")
+	if err := os.WriteFile(path, publicPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return key, path, publicPEM
+}
+
+// validClaims возвращает поля токена, которые принимает проверка с issuer и audience из newAuthenticator
+func validClaims() claims {
+	return claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user:1",
+			Issuer:    "songs",
+			Audience:  jwt.ClaimStrings{"songs-api"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Roles:  []string{models.RoleReader},
+		Tenant: "acme",
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, tokenClaims claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, tokenClaims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func authenticate(t *testing.T, authenticator *JWTAuthenticator, token string) (*models.Principal, error) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/songs", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return authenticator.Authenticate(r)
+}
+
+func newAuthenticator(t *testing.T, cfg config.AuthConfig) *JWTAuthenticator {
+	t.Helper()
+	cfg.JWTIssuer, cfg.JWTAudience = "songs", "songs-api"
+	authenticator, err := NewJWTAuthenticator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authenticator
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	hs256 := newAuthenticator(t, config.AuthConfig{JWTAlgorithm: AlgorithmHS256, JWTSecret: testSecret})
+
+	modified := func(change func(c *claims)) claims {
+		c := validClaims()
+		change(&c)
+		return c
+	}
+	tests := []struct {
+		name   string
+		claims claims
+		valid  bool
+	}{
+		{name: "valid", claims: validClaims(), valid: true},
+		{name: "missing exp", claims: modified(func(c *claims) { c.ExpiresAt = nil })},
+		{name: "expired", claims: modified(func(c *claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour)) })},
+		{name: "not yet valid", claims: modified(func(c *claims) { c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour)) })},
+		{name: "wrong issuer", claims: modified(func(c *claims) { c.Issuer = "other" })},
+		{name: "missing issuer", claims: modified(func(c *claims) { c.Issuer = "" })},
+		{name: "wrong audience", claims: modified(func(c *claims) { c.Audience = jwt.ClaimStrings{"other-api"} })},
+		{name: "missing audience", claims: modified(func(c *claims) { c.Audience = nil })},
+		{name: "missing subject", claims: modified(func(c *claims) { c.Subject = "" })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticate(t, hs256, sign(t, jwt.SigningMethodHS256, []byte(testSecret), tt.claims))
+			if !tt.valid {
+				if !errors.Is(err, models.ErrUnauthorized) {
+					t.Fatalf("Authenticate() error = %v, want %v", err, models.ErrUnauthorized)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if principal.Subject != "user:1" || principal.Method != models.AuthMethodJWT || principal.Tenant != "acme" ||
+				len(principal.Roles) != 1 || principal.Roles[0] != models.RoleReader {
+				t.Errorf("principal = %+v", principal)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticatorPinsAlgorithm(t *testing.T) {
+	privateKey, publicKeyFile, publicPEM := writeRSAKeys(t)
+	hs256 := newAuthenticator(t, config.AuthConfig{JWTAlgorithm: AlgorithmHS256, JWTSecret: testSecret})
+	rs256 := newAuthenticator(t, config.AuthConfig{JWTAlgorithm: AlgorithmRS256, JWTPublicKeyFile: publicKeyFile})
+
+	if _, err := authenticate(t, rs256, sign(t, jwt.SigningMethodRS256, privateKey, validClaims())); err != nil {
+		t.Fatalf("RS256 token: Authenticate() error = %v", err)
+	}
+	tests := []struct {
+		name          string
+		authenticator *JWTAuthenticator
+		token         string
+	}{
+		// Открытый ключ RS256 известен всем, поэтому подписанный им как секретом HS256 токен должен отклоняться
+		{name: "HS256 signed with RS256 public key", authenticator: rs256,
+			token: sign(t, jwt.SigningMethodHS256, publicPEM, validClaims())},
+		{name: "RS256 token for HS256", authenticator: hs256,
+			token: sign(t, jwt.SigningMethodRS256, privateKey, validClaims())},
+		{name: "HS512 with the same secret", authenticator: hs256,
+			token: sign(t, jwt.SigningMethodHS512, []byte(testSecret), validClaims())},
+		{name: "unsigned", authenticator: hs256,
+			token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, validClaims())},
+		{name: "wrong secret", authenticator: hs256,
+			token: sign(t, jwt.SigningMethodHS256, []byte("other-secret"), validClaims())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := authenticate(t, tt.authenticator, tt.token); !errors.Is(err, models.ErrUnauthorized) {
+				t.Errorf("Authenticate() error = %v, want %v", err, models.ErrUnauthorized)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticatorWithoutBearerToken(t *testing.T) {
+	authenticator := newAuthenticator(t, config.AuthConfig{JWTAlgorithm: AlgorithmHS256, JWTSecret: testSecret})
+	r := httptest.NewRequest(http.MethodGet, "/songs", nil)
+	r.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	principal, err := authenticator.Authenticate(r)
+	if principal != nil || err != nil {
+		t.Errorf("Authenticate() = %+v, %v, want no principal and no error", principal, err)
+	}
+}
+
+func TestIssuedTokenIsAccepted(t *testing.T) {
+	cfg := config.AuthConfig{JWTAlgorithm: AlgorithmHS256, JWTSecret: testSecret, JWTIssuer: "songs",
+		JWTAudience: "songs-api", JWTTTL: time.Minute}
+	issuer, err := NewTokenIssuer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.IssueToken(&models.Principal{Subject: "user:1", Roles: []string{models.RoleEditor}, Tenant: "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	principal, err := authenticate(t, newAuthenticator(t, cfg), token.AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if principal.Subject != "user:1" || principal.Tenant != "acme" {
+		t.Errorf("principal = %+v", principal)
+	}
+}
+
+func TestNewJWTAuthenticatorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AuthConfig
+	}{
+		{name: "unknown algorithm", cfg: config.AuthConfig{JWTAlgorithm: "none", JWTSecret: testSecret}},
+		{name: "HS256 without secret", cfg: config.AuthConfig{JWTAlgorithm: AlgorithmHS256}},
+		{name: "RS256 without key file", cfg: config.AuthConfig{JWTAlgorithm: AlgorithmRS256,
+			JWTPublicKeyFile: filepath.Join(t.TempDir(), "missing.pem")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewJWTAuthenticator(tt.cfg); err == nil {
+				t.Error("NewJWTAuthenticator() error = nil")
+			}
+		})
+	}
+}
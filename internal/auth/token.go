@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/config"
+	"time"
+)
+
+// TokenIssuer выдает JWT, которые принимает JWTAuthenticator с той же конфигурацией
+type TokenIssuer struct {
+	method   jwt.SigningMethod
+	key      any
+	issuer   string
+	audience string
+	ttl      time.Duration
+}
+
+// NewTokenIssuer создает выдачу токенов, подписанных алгоритмом cfg.JWTAlgorithm. Для RS256
+// нужен закрытый ключ JWT_PRIVATE_KEY_FILE
+func NewTokenIssuer(cfg config.AuthConfig) (*TokenIssuer, error) {
+	if cfg.JWTTTL <= 0 {
+		return nil, fmt.Errorf("JWT_TTL must be positive")
+	}
+	issuer := &TokenIssuer{issuer: cfg.JWTIssuer, audience: cfg.JWTAudience, ttl: cfg.JWTTTL}
+	switch cfg.JWTAlgorithm {
+	case AlgorithmHS256:
+		if cfg.JWTSecret == "" {
+			return nil, fmt.Errorf("JWT_SECRET is required for %s", AlgorithmHS256)
+		}
+		issuer.method, issuer.key = jwt.SigningMethodHS256, []byte(cfg.JWTSecret)
+	case AlgorithmRS256:
+		pem, err := os.ReadFile(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read JWT private key: %w", err)
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parse JWT private key: %w", err)
+		}
+		issuer.method, issuer.key = jwt.SigningMethodRS256, key
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", cfg.JWTAlgorithm)
+	}
+	return issuer, nil
+}
+
+// MustLoadTokenIssuer возвращает выдачу токенов или nil, если JWT не настроены. Выдает панику при неверной настройке
+func MustLoadTokenIssuer(cfg config.AuthConfig) *TokenIssuer {
+	if cfg.JWTAlgorithm == "" {
+		return nil
+	}
+	issuer, err := NewTokenIssuer(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("failed to configure JWT issuing: %s", err.Error()))
+	}
+	return issuer
+}
+
+// IssueToken подписывает токен для клиента principal со сроком действия из конфигурации
+func (t *TokenIssuer) IssueToken(principal *models.Principal) (*models.AccessToken, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.ttl)
+	tokenClaims := claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   principal.Subject,
+			Issuer:    t.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Name:   principal.Name,
+		Roles:  principal.Roles,
+		Tenant: principal.Tenant,
+	}
+	if t.audience != "" {
+		tokenClaims.Audience = jwt.ClaimStrings{t.audience}
+	}
+	token, err := jwt.NewWithClaims(t.method, tokenClaims).SignedString(t.key)
+	if err != nil {
+		return nil, fmt.Errorf("sign token: %w", err)
+	}
+	return &models.AccessToken{AccessToken: token, TokenType: "Bearer", ExpiresAt: expiresAt.UTC().Truncate(time.Second)}, nil
+}
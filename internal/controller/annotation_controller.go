@@ -0,0 +1,287 @@
+package controller
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/validator"
+)
+
+// AnnotationRequest задает фрагмент текста и пояснение к нему. Номера куплета и строки начинаются с 1,
+// диапазон символов [start, end) задается в символах строки; start = end = 0 означает всю строку
+type AnnotationRequest struct {
+	Verse int    `json:"verse"`
+	Line  int    `json:"line"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Kind  string `json:"kind" validate:"required,oneof=explanation reference"`
+	Body  string `json:"body" validate:"required,max=5000"`
+}
+
+func (a AnnotationRequest) toModel() *models.Annotation {
+	return &models.Annotation{
+		Verse: a.Verse,
+		Line:  a.Line,
+		Start: a.Start,
+		End:   a.End,
+		Kind:  a.Kind,
+		Body:  a.Body,
+	}
+}
+
+// ListAnnotations godoc
+// @Summary List song annotations
+// @Description Get all annotations of the song ordered by position in the text
+// @Produce json
+// @Param id path int true "Song id"
+// @Success 200 {array} models.Annotation
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/annotations [get]
+func (c *SongController) ListAnnotations(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.ListAnnotations"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	annotations, err := c.songService.ListAnnotations(r.Context(), id)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, annotations)
+}
+
+// CreateAnnotation godoc
+// @Summary Annotate a fragment of lyrics
+// @Description Attach an explanation or reference to a verse line or a character range within it
+// @Accept json
+// @Produce json
+// @Param id path int true "Song id"
+// @Param request body AnnotationRequest true "Annotation"
+// @Param Idempotency-Key header string false "Key to safely retry the request, the stored response is replayed for repeated keys"
+// @Success 201 {object} models.Annotation
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/annotations [post]
+func (c *SongController) CreateAnnotation(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.CreateAnnotation"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	var request AnnotationRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	annotation := request.toModel()
+	if err := c.songService.CreateAnnotation(r.Context(), id, annotation); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, annotation)
+}
+
+// GetAnnotation godoc
+// @Summary Get annotation
+// @Description Get a single annotation of the song
+// @Produce json
+// @Param id path int true "Song id"
+// @Param annotationID path int true "Annotation id"
+// @Success 200 {object} models.Annotation
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/annotations/{annotationID} [get]
+func (c *SongController) GetAnnotation(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetAnnotation"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, annotationID, ok := annotationParams(w, r, log)
+	if !ok {
+		return
+	}
+	annotation, err := c.songService.GetAnnotation(r.Context(), id, annotationID)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, annotation)
+}
+
+// UpdateAnnotation godoc
+// @Summary Update annotation
+// @Description Replace annotation text and position. The fragment is re-read from the current song text
+// @Accept json
+// @Produce json
+// @Param id path int true "Song id"
+// @Param annotationID path int true "Annotation id"
+// @Param request body AnnotationRequest true "Annotation"
+// @Success 200 {object} models.Annotation
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/annotations/{annotationID} [put]
+func (c *SongController) UpdateAnnotation(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.UpdateAnnotation"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, annotationID, ok := annotationParams(w, r, log)
+	if !ok {
+		return
+	}
+	var request AnnotationRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	annotation := request.toModel()
+	annotation.ID = uint(annotationID)
+	if err := c.songService.UpdateAnnotation(r.Context(), id, annotation); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, annotation)
+}
+
+// DeleteAnnotation godoc
+// @Summary Delete annotation
+// @Description Delete annotation of the song
+// @Param id path int true "Song id"
+// @Param annotationID path int true "Annotation id"
+// @Success 200 {string} string "annotation deleted"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/annotations/{annotationID} [delete]
+func (c *SongController) DeleteAnnotation(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.DeleteAnnotation"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, annotationID, ok := annotationParams(w, r, log)
+	if !ok {
+		return
+	}
+	if err := c.songService.DeleteAnnotation(r.Context(), id, annotationID); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, map[string]string{"message": "annotation deleted"})
+}
+
+// GetAnnotatedLyrics godoc
+// @Summary Get lyrics with annotation markers
+// @Description Get song verses where annotated fragments are marked inline, together with the annotations.
+// @Description Orphaned annotations (whose fragment was removed from the text) are listed but not marked
+// @Produce json
+// @Param id path int true "Song id"
+// @Success 200 {object} models.AnnotatedLyrics
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics/annotated [get]
+func (c *SongController) GetAnnotatedLyrics(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetAnnotatedLyrics"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	annotated, err := c.songService.GetAnnotatedLyrics(r.Context(), id)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, annotated)
+}
+
+// annotationParams разбирает id песни и аннотации из пути. При ошибке отвечает 400 и возвращает false
+func annotationParams(w http.ResponseWriter, r *http.Request, log *slog.Logger) (int, int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return 0, 0, false
+	}
+	annotationID, err := strconv.Atoi(chi.URLParam(r, "annotationID"))
+	if err != nil {
+		log.Debug("failed to get annotation id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid annotation id")
+		return 0, 0, false
+	}
+	return id, annotationID, true
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/validator"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, name, role, tenant string) (*models.CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	VerifyAPIKey(ctx context.Context, key string) (*models.Principal, error)
+}
+
+type APIKeyController struct {
+	log           *slog.Logger
+	apiKeyService APIKeyService
+	// policy - настройка разрешений, роль создаваемого ключа должна быть в ней задана
+	policy *Policy
+}
+
+func NewAPIKeyController(apiKeyService APIKeyService, policy *Policy, log *slog.Logger) *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: apiKeyService,
+		policy:        policy,
+		log:           log,
+	}
+}
+
+// APIKeyRequest содержит название, роль и арендатора создаваемого ключа. По умолчанию ключ получает роль reader
+// и не привязан к арендатору. Роль должна быть задана в AUTH_ROLE_PERMISSIONS, роль с разрешением admin
+// не дается ключу с арендатором
+type APIKeyRequest struct {
+	Name   string `json:"name" validate:"required,max=255"`
+	Role   string `json:"role" validate:"max=64"`
+	Tenant string `json:"tenant" validate:"max=64,slug"`
+}
+
+// CreateAPIKey godoc
+// @Summary Create API key
+// @Description Create a static API key. The key is returned only in this response, only its hash is stored. A key with a tenant can access only that tenant's song library and cannot have a role with the admin permission
+// @Accept json
+// @Produce json
+// @Param request body APIKeyRequest true "Key name"
+// @Param Idempotency-Key header string false "Key to safely retry the request, the stored response is replayed for repeated keys"
+// @Success 201 {object} models.CreatedAPIKey
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys [post]
+func (c *APIKeyController) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.APIKeyController.CreateAPIKey"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	var request APIKeyRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	if request.Role != "" && !c.policy.HasRole(request.Role) {
+		RenderError(w, r, log, models.NewValidationError("role",
+			"must be one of: "+strings.Join(c.policy.Roles(), ", ")))
+		return
+	}
+	// Привязанный к арендатору клиент не проходит администрирование, поэтому такой ключ администратора бесполезен
+	if request.Tenant != "" && c.policy.Allowed(&models.Principal{Roles: []string{request.Role}}, models.PermissionAdmin) {
+		RenderError(w, r, log, models.NewValidationError("role", "must not have the admin permission for a key with a tenant"))
+		return
+	}
+	key, err := c.apiKeyService.CreateAPIKey(r.Context(), request.Name, request.Role, request.Tenant)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, key)
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description List API keys including revoked ones. Key values are not returned
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys [get]
+func (c *APIKeyController) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.APIKeyController.ListAPIKeys"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	var keys []models.APIKey
+	keys, err := c.apiKeyService.ListAPIKeys(r.Context())
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke API key
+// @Description Revoke an API key. Requests with this key are rejected from now on
+// @Produce json
+// @Param id path int true "API key id"
+// @Success 200 {string} string "api key revoked"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys/{id} [delete]
+func (c *APIKeyController) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.APIKeyController.RevokeAPIKey"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid api key id")
+		return
+	}
+	if err := c.apiKeyService.RevokeAPIKey(r.Context(), id); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, map[string]string{"message": "api key revoked"})
+}
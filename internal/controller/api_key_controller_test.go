@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"testing"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// apiKeyServiceStub создает ключи с переданной ролью. Остальные методы APIKeyService не используются
+type apiKeyServiceStub struct {
+	APIKeyService
+}
+
+func (apiKeyServiceStub) CreateAPIKey(_ context.Context, name, role, _ string) (*models.CreatedAPIKey, error) {
+	return &models.CreatedAPIKey{APIKey: models.APIKey{Name: name, Role: role}, Key: "key"}, nil
+}
+
+func TestCreateAPIKeyValidatesRoleAgainstPolicy(t *testing.T) {
+	policy, err := NewPolicy(discardLog, "reader=songs:read;curator=songs:read,songs:moderate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller := NewAPIKeyController(apiKeyServiceStub{}, policy, discardLog)
+	tests := []struct {
+		role string
+		want int
+	}{
+		{role: "", want: http.StatusCreated},
+		{role: "reader", want: http.StatusCreated},
+		{role: "curator", want: http.StatusCreated},
+		{role: "admin", want: http.StatusUnprocessableEntity},
+		{role: "editor", want: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			body := `{"name": "ci", "role": "` + tt.role + `"}`
+			w := httptest.NewRecorder()
+			controller.CreateAPIKey(w, httptest.NewRequest(http.MethodPost, "/admin/api-keys", strings.NewReader(body)))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestCreateAPIKeyRejectsTenantAdmin(t *testing.T) {
+	policy, err := NewPolicy(discardLog, "reader=songs:read;admin=*;keys=admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller := NewAPIKeyController(apiKeyServiceStub{}, policy, discardLog)
+	tests := []struct {
+		role   string
+		tenant string
+		want   int
+	}{
+		{role: "admin", want: http.StatusCreated},
+		{role: "reader", tenant: "acme", want: http.StatusCreated},
+		{role: "", tenant: "acme", want: http.StatusCreated},
+		{role: "admin", tenant: "acme", want: http.StatusUnprocessableEntity},
+		{role: "keys", tenant: "acme", want: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.tenant, func(t *testing.T) {
+			body := `{"name": "ci", "role": "` + tt.role + `", "tenant": "` + tt.tenant + `"}`
+			w := httptest.NewRecorder()
+			controller.CreateAPIKey(w, httptest.NewRequest(http.MethodPost, "/admin/api-keys", strings.NewReader(body)))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/validator"
+	"time"
+)
+
+type AuditService interface {
+	ListAuditEntries(ctx context.Context, filter models.AuditFilter, page, pageSize int) ([]models.AuditEntry, error)
+}
+
+type AuditController struct {
+	log          *slog.Logger
+	auditService AuditService
+}
+
+func NewAuditController(auditService AuditService, log *slog.Logger) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+		log:          log,
+	}
+}
+
+type auditSourceKey struct{}
+
+// AuditSourceFromContext возвращает источник изменения, сохраненный middleware AuditContext. Вне запроса,
+// например в фоновых задачах, источником считается system
+func AuditSourceFromContext(ctx context.Context) models.AuditSource {
+	source, ok := ctx.Value(auditSourceKey{}).(models.AuditSource)
+	if !ok {
+		return models.AuditSource{Actor: "system"}
+	}
+	return source
+}
+
+// AuditContext сохраняет в контексте запроса клиента, id запроса и IP-адрес для журнала аудита и
+// возвращает id запроса в заголовке X-Request-Id. Должен стоять после Authenticate и middleware.RequestID
+func AuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source := models.AuditSource{
+			Actor:     requestSubject(r),
+			RequestID: middleware.GetReqID(r.Context()),
+			ClientIP:  clientIP(r),
+		}
+		if source.RequestID != "" {
+			w.Header().Set(middleware.RequestIDHeader, source.RequestID)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), auditSourceKey{}, source)))
+	})
+}
+
+// AuditRequest содержит фильтры журнала аудита
+type AuditRequest struct {
+	Actor      string `json:"actor" validate:"max=255"`
+	Action     string `json:"action" validate:"oneof=create update delete"`
+	Resource   string `json:"resource" validate:"oneof=song translation annotation synced_lyrics chords suggestion api_key user tenant favorite play rating"`
+	ResourceID string `json:"resource_id" validate:"max=64"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+// GetAuditLog godoc
+// @Summary Audit log
+// @Description Get create, update and delete operations with the client, request id, IP and the resource state before and after the change, newest first
+// @Produce json
+// @Param actor query string false "Client subject, e.g. user:1, api-key:2 or admin"
+// @Param action query string false "create, update or delete"
+// @Param resource query string false "song, translation, annotation, synced_lyrics, chords, suggestion, api_key, user, tenant, favorite, play or rating"
+// @Param resource_id query string false "Resource id. Translations use <song id>/<language>, favorites and ratings use <user id>/<song id>"
+// @Param from query string false "Start of the time range in RFC 3339, inclusive"
+// @Param to query string false "End of the time range in RFC 3339, exclusive"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {array} models.AuditEntry
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /audit [get]
+func (c *AuditController) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.AuditController.GetAuditLog"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	query := r.URL.Query()
+	request := AuditRequest{
+		Actor:      query.Get("actor"),
+		Action:     query.Get("action"),
+		Resource:   query.Get("resource"),
+		ResourceID: query.Get("resource_id"),
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	filter := models.AuditFilter{
+		Actor:      request.Actor,
+		Action:     request.Action,
+		Resource:   request.Resource,
+		ResourceID: request.ResourceID,
+	}
+	var err error
+	if filter.From, err = parseAuditTime("from", request.From); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	if filter.To, err = parseAuditTime("to", request.To); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	page, pageSize := GetPages(query.Get("page"), query.Get("page_size"))
+	entries, err := c.auditService.ListAuditEntries(r.Context(), filter, page, pageSize)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, entries)
+}
+
+// parseAuditTime разбирает границу интервала в формате RFC 3339. Пустое значение не ограничивает интервал
+func parseAuditTime(field, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, models.NewValidationError(field, "must be a time in RFC 3339 format, e.g. 2024-11-01T00:00:00Z")
+	}
+	return &t, nil
+}
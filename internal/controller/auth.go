@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testEffectiveMobile/internal/models"
+)
+
+// Authenticator проверяет учетные данные одного вида. Если в запросе нет учетных данных этого вида,
+// возвращает nil, nil, чтобы их проверил следующий Authenticator. Неверные учетные данные - ошибка
+// с models.ErrUnauthorized
+type Authenticator interface {
+	Authenticate(r *http.Request) (*models.Principal, error)
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext возвращает клиента, аутентифицированного middleware Authenticate, или nil
+func PrincipalFromContext(ctx context.Context) *models.Principal {
+	principal, _ := ctx.Value(principalKey{}).(*models.Principal)
+	return principal
+}
+
+// requestSubject возвращает субъект клиента запроса или anonymous, если аутентификация отключена
+func requestSubject(r *http.Request) string {
+	if principal := PrincipalFromContext(r.Context()); principal != nil {
+		return principal.Subject
+	}
+	return "anonymous"
+}
+
+// WithPrincipal возвращает контекст с аутентифицированным клиентом
+func WithPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Authenticate пропускает запрос дальше, только если один из authenticators распознал клиента.
+// Клиент сохраняется в контексте запроса и доступен через PrincipalFromContext
+func Authenticate(log *slog.Logger, authenticators ...Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "controller.Authenticate"
+			log := log.With(
+				slog.String("op", op),
+				slog.String("path", r.URL.Path),
+			)
+			for _, authenticator := range authenticators {
+				principal, err := authenticator.Authenticate(r)
+				if err != nil {
+					log.Debug("authentication failed", slog.String("err", err.Error()))
+					RenderError(w, r, log, err)
+					return
+				}
+				if principal != nil {
+					log.Debug("request authenticated",
+						slog.String("subject", principal.Subject),
+						slog.String("method", principal.Method),
+						slog.Any("roles", principal.Roles),
+					)
+					next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+					return
+				}
+			}
+			RenderError(w, r, log, fmt.Errorf("credentials are required: %w", models.ErrUnauthorized))
+		})
+	}
+}
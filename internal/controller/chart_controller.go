@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/validator"
+)
+
+const (
+	defaultChartLimit = 10
+	maxChartLimit     = 100
+)
+
+// ChartRequest содержит период чарта
+type ChartRequest struct {
+	Period string `json:"period" validate:"oneof=day week month year all"`
+}
+
+// GetChart godoc
+// @Summary Get top songs
+// @Description Get the most popular songs over a period. Score is the number of plays plus 2 points for every rating point above 3 (minus below 3) given during the period
+// @Produce json
+// @Param period query string false "Period: day, week, month, year or all (default week)"
+// @Param limit query int false "Number of songs, 1-100 (default 10)"
+// @Success 200 {object} models.Chart
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /charts [get]
+func (c *SongController) GetChart(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetChart"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	request := ChartRequest{Period: r.URL.Query().Get("period")}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	if request.Period == "" {
+		request.Period = models.ChartPeriodWeek
+	}
+	limit := defaultChartLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxChartLimit {
+			RenderError(w, r, log, models.NewValidationError("limit", fmt.Sprintf("must be between 1 and %d", maxChartLimit)))
+			return
+		}
+	}
+	chart, err := c.songService.Chart(r.Context(), request.Period, limit)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, chart)
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"testEffectiveMobile/internal/models"
+)
+
+// maxChordProSize ограничивает размер загружаемого текста с аккордами. Более длинные тексты отклоняются с 413
+const maxChordProSize = 1 << 20
+
+// maxTranspose - наибольший сдвиг при транспонировании в полутонах
+const maxTranspose = 11
+
+// PutChords godoc
+// @Summary Import chords in ChordPro format
+// @Description Replace the chord sheet of the song. Chords are written in square brackets inside lyric lines ([Am]text),
+// @Description directives title, artist, key, comment and start/end of verse, chorus and bridge are supported
+// @Accept plain
+// @Produce json
+// @Param id path int true "Song id"
+// @Param request body string true "ChordPro text"
+// @Success 200 {object} models.Chords
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/chords [put]
+func (c *SongController) PutChords(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.PutChords"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxChordProSize))
+	if err != nil {
+		log.Warn("failed to read body", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "failed to read request body")
+		return
+	}
+	chords, err := c.songService.ImportChords(r.Context(), id, string(body))
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, chords)
+}
+
+// GetChords godoc
+// @Summary Get chords
+// @Description Get the chord sheet of the song, optionally transposed. Sharps or flats are chosen by the resulting key.
+// @Description With format=text returns plain text with chords aligned above lyric lines
+// @Produce json
+// @Produce plain
+// @Param id path int true "Song id"
+// @Param transpose query int false "Semitones to transpose by, from -11 to 11 (e.g. +2 or -3)"
+// @Param format query string false "Set to text to render plain text"
+// @Success 200 {object} models.Chords
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/chords [get]
+func (c *SongController) GetChords(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetChords"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	transpose := 0
+	// Неэкранированный + в строке запроса приходит пробелом, поэтому ?transpose=+2 читается как " 2"
+	if transposeStr := strings.TrimSpace(r.URL.Query().Get("transpose")); transposeStr != "" {
+		transpose, err = strconv.Atoi(transposeStr)
+		if err != nil || transpose < -maxTranspose || transpose > maxTranspose {
+			RenderError(w, r, log, models.NewValidationError("transpose", "must be an integer from -11 to 11"))
+			return
+		}
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		text, err := c.songService.RenderChords(r.Context(), id, transpose)
+		if err != nil {
+			RenderError(w, r, log, err)
+			return
+		}
+		render.PlainText(w, r, text)
+		return
+	}
+	chords, err := c.songService.GetChords(r.Context(), id, transpose)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, chords)
+}
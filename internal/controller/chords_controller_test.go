@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"context"
+	"testEffectiveMobile/internal/models"
+	"testing"
+)
+
+func (s *songBodyStub) ImportChords(_ context.Context, _ int, text string) (*models.Chords, error) {
+	s.imported = text
+	return &models.Chords{}, nil
+}
+
+func TestPutChordsRejectsOversizedSheet(t *testing.T) {
+	service := &songBodyStub{}
+	controller := NewController(service, discardLog)
+	testUploadLimit(t, "/songs/{id}/chords", maxChordProSize, controller.PutChords, service)
+}
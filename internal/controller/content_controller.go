@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+)
+
+// ExplicitRequest задает признак откровенного содержания вручную. null возвращает автоматическое определение
+type ExplicitRequest struct {
+	Explicit *bool `json:"explicit"`
+}
+
+// SetExplicit godoc
+// @Summary Override explicit content flag
+// @Description Set the explicit flag manually. {"explicit": null} removes the override and the flag is detected from the lyrics again
+// @Accept json
+// @Produce json
+// @Param id path int true "Song id"
+// @Param request body ExplicitRequest true "Explicit override" example({"explicit": true})
+// @Success 200 {object} models.Song
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/explicit [put]
+func (c *SongController) SetExplicit(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.SetExplicit"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	var request ExplicitRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	var song *models.Song
+	song, err = c.songService.SetExplicitOverride(r.Context(), id, request.Explicit)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, song)
+}
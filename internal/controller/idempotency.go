@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/tenant"
+	"testEffectiveMobile/internal/utils/idempotency"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader - заголовок с ключом идемпотентности запроса
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// maxIdempotencyKeyLength ограничивает длину ключа идемпотентности
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize - наибольший размер тела запроса и ответа, которые сохраняются для повтора
+	maxIdempotentBodySize = 1 << 20
+)
+
+// Idempotency повторяет сохраненный ответ, если запрос с тем же заголовком Idempotency-Key уже выполнялся.
+// Ключи принадлежат клиенту и хранятся ttl. Повтор ключа с другим телом запроса или пока первый запрос
+// еще выполняется отклоняется с 409. Ответы 5xx не сохраняются, чтобы после сбоя запрос можно было повторить
+func Idempotency(log *slog.Logger, store idempotency.Store, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "controller.Idempotency"
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			log := log.With(
+				slog.String("op", op),
+				slog.String("idempotency_key", key),
+			)
+			if len(key) > maxIdempotencyKeyLength {
+				RenderError(w, r, log, models.NewValidationError(IdempotencyKeyHeader, "must be at most 255 characters long"))
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodySize+1))
+			if err != nil {
+				log.Warn("failed to read body", slog.String("err", err.Error()))
+				RenderBodyError(w, r, err, "failed to read request body")
+				return
+			}
+			if len(body) > maxIdempotentBodySize {
+				renderPayloadTooLarge(w, r, maxIdempotentBodySize)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			record := &models.IdempotencyKey{
+				Key:         idempotencyScope(r) + ":" + key,
+				Fingerprint: idempotency.Fingerprint(r.Method, r.URL.Path, body),
+				ExpiresAt:   time.Now().Add(ttl),
+			}
+			stored, created, err := store.Begin(r.Context(), record)
+			if err != nil {
+				RenderError(w, r, log, err)
+				return
+			}
+			if !created {
+				replayIdempotent(w, r, log, stored, record.Fingerprint)
+				return
+			}
+
+			// Если обработчик паникует, запись удаляется, иначе повтор запроса с тем же ключом отклонялся бы
+			// как выполняющийся до истечения ttl
+			defer func() {
+				if p := recover(); p != nil {
+					if err := store.Delete(context.WithoutCancel(r.Context()), record.Key); err != nil {
+						log.Warn("failed to delete idempotency key after panic", slog.String("err", err.Error()))
+					}
+					panic(p)
+				}
+			}()
+
+			var response bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&response)
+			next.ServeHTTP(ww, r)
+
+			// Запрос мог быть прерван по таймауту, а запись все равно нужно сохранить или удалить
+			ctx := context.WithoutCancel(r.Context())
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError || response.Len() > maxIdempotentBodySize {
+				_ = store.Delete(ctx, record.Key)
+				return
+			}
+			record.Status = status
+			record.ContentType = ww.Header().Get("Content-Type")
+			record.Body = response.Bytes()
+			_ = store.Complete(ctx, record)
+		})
+	}
+}
+
+// replayIdempotent отвечает на повтор запроса сохраненным ответом
+func replayIdempotent(w http.ResponseWriter, r *http.Request, log *slog.Logger, stored *models.IdempotencyKey, fingerprint string) {
+	switch {
+	case stored.Fingerprint != fingerprint:
+		log.Debug("idempotency key reused with different request")
+		RenderProblem(w, r, NewProblem(http.StatusConflict, CodeIdempotencyKeyReused,
+			"idempotency key was already used with a different request"))
+	case stored.Status == 0:
+		log.Debug("idempotent request is in progress")
+		RenderProblem(w, r, NewProblem(http.StatusConflict, CodeIdempotencyInProgress,
+			"a request with this idempotency key is still in progress"))
+	default:
+		log.Debug("replaying idempotent response")
+		if stored.ContentType != "" {
+			w.Header().Set("Content-Type", stored.ContentType)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(stored.Status)
+		_, _ = w.Write(stored.Body)
+	}
+}
+
+// idempotencyScope разделяет ключи разных клиентов и арендаторов
+func idempotencyScope(r *http.Request) string {
+	return requestSubject(r) + ":" + strconv.FormatUint(uint64(tenant.FromContext(r.Context())), 10)
+}
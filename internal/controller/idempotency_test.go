@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testEffectiveMobile/internal/models"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// memoryIdempotencyStore хранит записи о запросах в памяти
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyKey
+}
+
+func (s *memoryIdempotencyStore) Begin(_ context.Context, record *models.IdempotencyKey) (*models.IdempotencyKey, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored, ok := s.records[record.Key]; ok {
+		return &stored, false, nil
+	}
+	s.records[record.Key] = *record
+	return record, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, record *models.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.Key] = *record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func TestIdempotencyReleasesKeyAfterPanic(t *testing.T) {
+	store := &memoryIdempotencyStore{records: make(map[string]models.IdempotencyKey)}
+	calls := 0
+	handler := middleware.Recoverer(Idempotency(discardLog, store, time.Hour)(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+			w.WriteHeader(http.StatusCreated)
+		})))
+	send := func() int {
+		r := httptest.NewRequest(http.MethodPost, "/admin/tenants", strings.NewReader(`{"slug": "acme"}`))
+		r.Header.Set(IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if status := send(); status != http.StatusInternalServerError {
+		t.Fatalf("first request status = %d, want 500", status)
+	}
+	if status := send(); status != http.StatusCreated {
+		t.Fatalf("retry status = %d, want 201", status)
+	}
+	if status := send(); status != http.StatusCreated || calls != 2 {
+		t.Fatalf("replay status = %d, handler calls = %d, want 201 and 2", status, calls)
+	}
+}
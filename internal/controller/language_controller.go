@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/validator"
+)
+
+// LanguageRequest задает язык песни вручную. null возвращает автоматическое определение по тексту
+type LanguageRequest struct {
+	Language *string `json:"language"`
+}
+
+// languageCode - непустой код языка из LanguageRequest для проверки валидатором
+type languageCode struct {
+	Language string `json:"language" validate:"required,lang"`
+}
+
+// SetLanguage godoc
+// @Summary Correct song language
+// @Description Set the language of the lyrics manually. {"language": null} removes the correction and the language is detected from the lyrics again
+// @Accept json
+// @Produce json
+// @Param id path int true "Song id"
+// @Param request body LanguageRequest true "Language code" example({"language": "en"})
+// @Success 200 {object} models.Song
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/language [patch]
+func (c *SongController) SetLanguage(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.SetLanguage"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	var request LanguageRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if request.Language != nil {
+		code := languageCode{Language: *request.Language}
+		if err := validator.Validate(&code); err != nil {
+			RenderError(w, r, log, err)
+			return
+		}
+		request.Language = &code.Language
+	}
+	var song *models.Song
+	song, err = c.songService.SetLanguage(r.Context(), id, request.Language)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, song)
+}
+
+// GetLanguageFacets godoc
+// @Summary Count songs by language
+// @Description Get the number of songs in each language. Accepts the same filters as GET /songs, the language filter is ignored
+// @Produce json
+// @Param group query string false "Group name"
+// @Param name query string false "Song name"
+// @Param explicit query boolean false "Filter by explicit content flag"
+// @Success 200 {array} models.LanguageFacet
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/languages [get]
+func (c *SongController) GetLanguageFacets(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetLanguageFacets"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	filter, err := GetSongFilter(r)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	facets, err := c.songService.LanguageFacets(r.Context(), filter)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, facets)
+}
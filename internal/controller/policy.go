@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"testEffectiveMobile/internal/models"
+)
+
+// Policy сопоставляет роли клиентов с разрешениями
+type Policy struct {
+	log         *slog.Logger
+	permissions map[string][]string
+}
+
+// NewPolicy разбирает настройку вида role=perm1,perm2;role2=perm3. Разрешение * дает роли все разрешения
+func NewPolicy(log *slog.Logger, rolePermissions string) (*Policy, error) {
+	permissions := make(map[string][]string)
+	for _, entry := range strings.Split(rolePermissions, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, list, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid role permissions %q: expected role=permission,...", entry)
+		}
+		for _, permission := range strings.Split(list, ",") {
+			if permission = strings.TrimSpace(permission); permission != "" {
+				permissions[role] = append(permissions[role], permission)
+			}
+		}
+	}
+	return &Policy{log: log, permissions: permissions}, nil
+}
+
+// HasRole сообщает, задана ли роль role в настройке разрешений
+func (p *Policy) HasRole(role string) bool {
+	_, ok := p.permissions[role]
+	return ok
+}
+
+// Roles возвращает роли из настройки разрешений по алфавиту
+func (p *Policy) Roles() []string {
+	roles := make([]string, 0, len(p.permissions))
+	for role := range p.permissions {
+		roles = append(roles, role)
+	}
+	slices.Sort(roles)
+	return roles
+}
+
+// Allowed сообщает, есть ли у клиента разрешение хотя бы через одну из его ролей
+func (p *Policy) Allowed(principal *models.Principal, permission string) bool {
+	for _, role := range principal.Roles {
+		granted := p.permissions[role]
+		if slices.Contains(granted, permission) || slices.Contains(granted, models.PermissionAll) {
+			return true
+		}
+	}
+	return false
+}
+
+// Require пропускает запрос, только если у клиента есть разрешение permission, иначе отвечает 403.
+// Если аутентификация отключена и клиента в контексте нет, запрос пропускается
+func (p *Policy) Require(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "controller.Policy.Require"
+			principal := PrincipalFromContext(r.Context())
+			if principal == nil || p.Allowed(principal, permission) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			log := p.log.With(
+				slog.String("op", op),
+				slog.String("subject", principal.Subject),
+				slog.String("permission", permission),
+			)
+			log.Debug("access denied")
+			RenderError(w, r, log, fmt.Errorf("permission %q is required: %w", permission, models.ErrForbidden))
+		})
+	}
+}
+
+// RequireUnbound пропускает запрос, только если клиент не привязан к арендатору, иначе отвечает 403.
+// Ключи API, арендаторы и журнал аудита общие для всех арендаторов, поэтому клиенту одного арендатора
+// они недоступны даже с ролью admin
+func (p *Policy) RequireUnbound(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const op = "controller.Policy.RequireUnbound"
+		principal := PrincipalFromContext(r.Context())
+		if principal == nil || principal.Tenant == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		log := p.log.With(
+			slog.String("op", op),
+			slog.String("subject", principal.Subject),
+			slog.String("tenant", principal.Tenant),
+		)
+		log.Debug("access denied")
+		RenderError(w, r, log, fmt.Errorf("client of tenant %q cannot manage all tenants: %w", principal.Tenant,
+			models.ErrForbidden))
+	})
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testEffectiveMobile/internal/models"
+)
+
+// Стабильные коды ошибок, на которые могут опираться клиенты
+const (
+	CodeBadRequest            = "bad_request"
+	CodeValidationFailed      = "validation_failed"
+	CodeNotFound              = "not_found"
+	CodeUnauthorized          = "unauthorized"
+	CodeForbidden             = "forbidden"
+	CodeRateLimited           = "rate_limited"
+	CodeConflict              = "conflict"
+	CodeUpstreamUnavailable   = "upstream_unavailable"
+	CodeServiceUnavailable    = "service_unavailable"
+	CodeTimeout               = "timeout"
+	CodeInternal              = "internal_error"
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+	CodePayloadTooLarge       = "payload_too_large"
+)
+
+const problemTypePrefix = "urn:problem-type:"
+
+// NewProblem собирает ответ об ошибке с типом, производным от кода
+func NewProblem(status int, code, detail string) models.Problem {
+	return models.Problem{
+		Type:   problemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// RenderProblem отправляет ответ с Content-Type application/problem+json
+func RenderProblem(w http.ResponseWriter, r *http.Request, problem models.Problem) {
+	problem.Instance = r.URL.Path
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// RenderBadRequest отвечает 400 на синтаксически некорректный запрос (невалидный JSON, id и т.п.)
+func RenderBadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	RenderProblem(w, r, NewProblem(http.StatusBadRequest, CodeBadRequest, detail))
+}
+
+// RenderBodyError отвечает на ошибку чтения тела запроса: 413, если тело превысило ограничение MaxBodySize,
+// иначе 400 с detail
+func RenderBodyError(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		renderPayloadTooLarge(w, r, maxBytesErr.Limit)
+		return
+	}
+	RenderBadRequest(w, r, detail)
+}
+
+// RenderError сопоставляет ошибку сервисного слоя с HTTP-статусом и кодом ошибки
+func RenderError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		problem := NewProblem(http.StatusUnprocessableEntity, CodeValidationFailed, "request contains invalid fields")
+		problem.Errors = validationErr.Fields
+		RenderProblem(w, r, problem)
+	case errors.Is(err, models.ErrNotFound):
+		RenderProblem(w, r, NewProblem(http.StatusNotFound, CodeNotFound, err.Error()))
+	case errors.Is(err, models.ErrUnauthorized):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+		RenderProblem(w, r, NewProblem(http.StatusUnauthorized, CodeUnauthorized, err.Error()))
+	case errors.Is(err, models.ErrForbidden):
+		RenderProblem(w, r, NewProblem(http.StatusForbidden, CodeForbidden, err.Error()))
+	case errors.Is(err, models.ErrConflict):
+		RenderProblem(w, r, NewProblem(http.StatusConflict, CodeConflict, err.Error()))
+	case errors.Is(err, models.ErrUpstreamUnavailable):
+		log.Warn("upstream unavailable", slog.String("err", err.Error()))
+		RenderProblem(w, r, NewProblem(http.StatusBadGateway, CodeUpstreamUnavailable, "song info service is unavailable"))
+	case errors.Is(err, models.ErrUnavailable):
+		log.Warn("service unavailable", slog.String("err", err.Error()))
+		RenderProblem(w, r, NewProblem(http.StatusServiceUnavailable, CodeServiceUnavailable, err.Error()))
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Warn("request timed out", slog.String("err", err.Error()))
+		RenderProblem(w, r, NewProblem(http.StatusGatewayTimeout, CodeTimeout, "request timed out"))
+	default:
+		log.Error("internal error", slog.String("err", err.Error()))
+		RenderProblem(w, r, NewProblem(http.StatusInternalServerError, CodeInternal, "internal server error"))
+	}
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/utils/ratelimit"
+	"time"
+)
+
+// RateLimit ограничивает количество запросов клиента к маршрутам группы scope. Клиент определяется функцией
+// key (ClientKey или IPKey). Ответ содержит заголовки RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset,
+// при превышении лимита возвращается 429. Если хранилище счетчиков недоступно, при failOpen запрос
+// пропускается без ограничения, иначе отклоняется с 503
+func RateLimit(log *slog.Logger, store ratelimit.Store, scope string, limit ratelimit.Limit,
+	key func(r *http.Request) string, failOpen bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "controller.RateLimit"
+			client := key(r)
+			log := log.With(
+				slog.String("op", op),
+				slog.String("scope", scope),
+				slog.String("client", client),
+			)
+			now := time.Now()
+			windowStart := ratelimit.WindowStart(now, limit.Window)
+			count, err := store.Increment(r.Context(), scope+":"+client, windowStart)
+			if err != nil {
+				if failOpen {
+					log.Warn("rate limit store is unavailable, request is not limited", slog.String("err", err.Error()))
+					next.ServeHTTP(w, r)
+					return
+				}
+				log.Error("rate limit store is unavailable, request is rejected", slog.String("err", err.Error()))
+				RenderProblem(w, r, NewProblem(http.StatusServiceUnavailable, CodeServiceUnavailable,
+					"rate limiting is temporarily unavailable"))
+				return
+			}
+			reset := int(windowStart.Add(limit.Window).Sub(now).Seconds() + 0.5)
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Window.Seconds())))
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(max(limit.Requests-count, 0)))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(reset))
+			if count > limit.Requests {
+				log.Debug("rate limit exceeded")
+				w.Header().Set("Retry-After", strconv.Itoa(reset))
+				RenderProblem(w, r, NewProblem(http.StatusTooManyRequests, CodeRateLimited,
+					fmt.Sprintf("rate limit of %d requests per %s exceeded", limit.Requests, limit.Window)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientKey возвращает ключ клиента для RateLimit: субъект аутентификации или, без аутентификации, IP-адрес
+func ClientKey(r *http.Request) string {
+	if principal := PrincipalFromContext(r.Context()); principal != nil {
+		return "subject:" + principal.Subject
+	}
+	return IPKey(r)
+}
+
+// IPKey возвращает ключ клиента для RateLimit по IP-адресу. Используется до аутентификации, чтобы
+// ограничить подбор учетных данных
+func IPKey(r *http.Request) string {
+	return "ip:" + clientIP(r)
+}
+
+// clientIP возвращает IP-адрес клиента без порта
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
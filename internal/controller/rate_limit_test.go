@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testEffectiveMobile/internal/utils/ratelimit"
+	"testing"
+	"time"
+)
+
+// failingStore - хранилище счетчиков, которое всегда недоступно
+type failingStore struct{}
+
+func (failingStore) Increment(context.Context, string, time.Time) (int, error) {
+	return 0, errors.New("connection refused")
+}
+
+func okHandler(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestRateLimitStoreUnavailable(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 1, Window: time.Minute}
+	tests := []struct {
+		name     string
+		failOpen bool
+		want     int
+	}{
+		{name: "fail open", failOpen: true, want: http.StatusOK},
+		{name: "fail closed", failOpen: false, want: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RateLimit(discardLog, failingStore{}, "read", limit, ClientKey, tt.failOpen)(http.HandlerFunc(okHandler))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/songs", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitByIPIgnoresCredentials(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 2, Window: time.Minute}
+	handler := RateLimit(discardLog, ratelimit.NewMemoryStore(), "login", limit, IPKey, true)(http.HandlerFunc(okHandler))
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		r := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		// Разные учетные данные не дают обойти ограничение по IP-адресу
+		r.Header.Set("X-API-Key", "guess-"+string(rune('a'+i)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("request %d: status = %d, want %d", i+1, w.Code, want)
+		}
+	}
+}
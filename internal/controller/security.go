@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy - настройки доступа к API из браузера с других источников
+type CORSPolicy struct {
+	// AllowedOrigins - разрешенные источники, * разрешает любой
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders - заголовки ответа, доступные скриптам клиента
+	ExposedHeaders []string
+	// MaxAge - время кэширования ответа на предварительный запрос, 0 - не задавать
+	MaxAge time.Duration
+}
+
+// CORS разрешает запросы из браузера с источников policy.AllowedOrigins. Предварительный запрос (OPTIONS
+// с Access-Control-Request-Method) обрабатывается здесь и не доходит до аутентификации; если источник,
+// метод или заголовки не разрешены, возвращается 403. Обычные запросы с неразрешенных источников
+// обрабатываются без заголовков CORS, и браузер не отдает ответ скрипту.
+// Если список источников пуст, возвращает nil
+func CORS(policy CORSPolicy) func(http.Handler) http.Handler {
+	origins := normalizeList(policy.AllowedOrigins, strings.ToLower)
+	if len(origins) == 0 {
+		return nil
+	}
+	methods := normalizeList(policy.AllowedMethods, strings.ToUpper)
+	headers := normalizeList(policy.AllowedHeaders, http.CanonicalHeaderKey)
+	anyOrigin := slices.Contains(origins, "*")
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	exposeHeaders := strings.Join(normalizeList(policy.ExposedHeaders, http.CanonicalHeaderKey), ", ")
+	maxAge := strconv.Itoa(int(policy.MaxAge.Seconds()))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Add("Vary", "Origin")
+			allowed := anyOrigin || slices.Contains(origins, strings.ToLower(origin))
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				if allowed {
+					setAllowOrigin(w, origin, anyOrigin)
+					if exposeHeaders != "" {
+						w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+					}
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !allowed {
+				renderCORSForbidden(w, r, fmt.Sprintf("origin %q is not allowed", origin))
+				return
+			}
+			method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+			if !slices.Contains(methods, method) {
+				renderCORSForbidden(w, r, fmt.Sprintf("method %s is not allowed", method))
+				return
+			}
+			for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+				header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+				if header != "" && !slices.Contains(headers, header) {
+					renderCORSForbidden(w, r, fmt.Sprintf("header %s is not allowed", header))
+					return
+				}
+			}
+			setAllowOrigin(w, origin, anyOrigin)
+			w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+			}
+			if policy.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func setAllowOrigin(w http.ResponseWriter, origin string, anyOrigin bool) {
+	if anyOrigin {
+		origin = "*"
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+}
+
+func renderCORSForbidden(w http.ResponseWriter, r *http.Request, detail string) {
+	RenderProblem(w, r, NewProblem(http.StatusForbidden, CodeForbidden, detail))
+}
+
+// normalizeList обрезает пробелы, приводит значения функцией normalize и убирает пустые
+func normalizeList(values []string, normalize func(string) string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, normalize(value))
+		}
+	}
+	return result
+}
+
+// apiContentSecurityPolicy запрещает браузеру загружать что-либо по ответам API и встраивать их во фреймы
+const apiContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// docsContentSecurityPolicy разрешает странице документации Swagger UI ее собственные скрипты и стили
+const docsContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'"
+
+// SecurityHeaders добавляет к ответам заголовки, ограничивающие их обработку браузером. При hstsMaxAge > 0
+// добавляет Strict-Transport-Security, чтобы браузер обращался к сервису только по HTTPS
+func SecurityHeaders(hstsMaxAge time.Duration) func(http.Handler) http.Handler {
+	hsts := fmt.Sprintf("max-age=%d; includeSubDomains", int(hstsMaxAge.Seconds()))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-Frame-Options", "DENY")
+			w.Header().Set("Referrer-Policy", "no-referrer")
+			w.Header().Set("Content-Security-Policy", apiContentSecurityPolicy)
+			if hstsMaxAge > 0 {
+				w.Header().Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DocsSecurityHeaders ослабляет Content-Security-Policy для страницы документации. Должен стоять после SecurityHeaders
+func DocsSecurityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", docsContentSecurityPolicy)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// MaxBodySize ограничивает размер тела запроса limit байтами. Запрос с большим Content-Length сразу
+// отклоняется с 413, а тело без длины обрывается на limit байтах - обработчик получает ошибку
+// *http.MaxBytesError и отвечает 413 через RenderBodyError
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				renderPayloadTooLarge(w, r, limit)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func renderPayloadTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	RenderProblem(w, r, NewProblem(http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+		fmt.Sprintf("request body must be at most %d bytes", limit)))
+}
@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nextRecorder отвечает 200 и запоминает, дошел ли запрос до обработчика
+type nextRecorder struct {
+	called bool
+}
+
+func (n *nextRecorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	n.called = true
+	w.WriteHeader(http.StatusOK)
+}
+
+var testCORSPolicy = CORSPolicy{
+	AllowedOrigins: []string{"https://app.example.com", " "},
+	AllowedMethods: []string{"get", "PUT"},
+	AllowedHeaders: []string{"authorization", "X-Tenant"},
+	ExposedHeaders: []string{"x-request-id", "Retry-After"},
+	MaxAge:         10 * time.Minute,
+}
+
+func TestCORSPreflight(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     CORSPolicy
+		origin     string
+		method     string
+		headers    string
+		wantStatus int
+		wantOrigin string
+	}{
+		{name: "allowed", origin: "https://app.example.com", method: "PUT", headers: "authorization, x-tenant",
+			wantStatus: http.StatusNoContent, wantOrigin: "https://app.example.com"},
+		{name: "origin in other case", origin: "HTTPS://APP.EXAMPLE.COM", method: "GET",
+			wantStatus: http.StatusNoContent, wantOrigin: "HTTPS://APP.EXAMPLE.COM"},
+		{name: "method in lower case", origin: "https://app.example.com", method: "put",
+			wantStatus: http.StatusNoContent, wantOrigin: "https://app.example.com"},
+		{name: "any origin", policy: CORSPolicy{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}},
+			origin: "https://other.example.com", method: "GET", wantStatus: http.StatusNoContent, wantOrigin: "*"},
+		{name: "origin not allowed", origin: "https://evil.example.com", method: "GET", wantStatus: http.StatusForbidden},
+		{name: "origin with other port", origin: "https://app.example.com:8443", method: "GET",
+			wantStatus: http.StatusForbidden},
+		{name: "method not allowed", origin: "https://app.example.com", method: "DELETE", wantStatus: http.StatusForbidden},
+		{name: "header not allowed", origin: "https://app.example.com", method: "GET", headers: "Authorization, X-Debug",
+			wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.policy
+			if policy.AllowedOrigins == nil {
+				policy = testCORSPolicy
+			}
+			next := &nextRecorder{}
+			r := httptest.NewRequest(http.MethodOptions, "/songs", nil)
+			r.Header.Set("Origin", tt.origin)
+			r.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				r.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			w := httptest.NewRecorder()
+			CORS(policy)(next).ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if next.called {
+				t.Error("preflight request reached the handler")
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			// Ответ зависит от источника, метода и заголовков, поэтому кэши должны различать их и при отказе
+			vary := w.Header().Values("Vary")
+			for _, header := range []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"} {
+				if !slices.Contains(vary, header) {
+					t.Errorf("Vary = %q, want %s", vary, header)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSPreflightHeaders(t *testing.T) {
+	r := httptest.NewRequest(http.MethodOptions, "/songs", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	r.Header.Set("Access-Control-Request-Method", "GET")
+	w := httptest.NewRecorder()
+	CORS(testCORSPolicy)(&nextRecorder{}).ServeHTTP(w, r)
+
+	want := map[string]string{
+		"Access-Control-Allow-Methods": "GET, PUT",
+		"Access-Control-Allow-Headers": "Authorization, X-Tenant",
+		"Access-Control-Max-Age":       "600",
+	}
+	for header, value := range want {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "" {
+		t.Errorf("preflight Access-Control-Expose-Headers = %q, want none", got)
+	}
+}
+
+func TestCORSRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		wantOrigin  string
+		wantExposed string
+		wantVary    bool
+	}{
+		{name: "allowed origin", method: http.MethodGet, origin: "https://app.example.com",
+			wantOrigin: "https://app.example.com", wantExposed: "X-Request-Id, Retry-After", wantVary: true},
+		{name: "origin not allowed", method: http.MethodGet, origin: "https://evil.example.com", wantVary: true},
+		{name: "no origin", method: http.MethodGet},
+		// OPTIONS без Access-Control-Request-Method - обычный запрос, а не предварительный
+		{name: "options without request method", method: http.MethodOptions, origin: "https://app.example.com",
+			wantOrigin: "https://app.example.com", wantExposed: "X-Request-Id, Retry-After", wantVary: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &nextRecorder{}
+			r := httptest.NewRequest(tt.method, "/songs", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			CORS(testCORSPolicy)(next).ServeHTTP(w, r)
+
+			if !next.called || w.Code != http.StatusOK {
+				t.Fatalf("request did not reach the handler: status %d", w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Expose-Headers"); got != tt.wantExposed {
+				t.Errorf("Access-Control-Expose-Headers = %q, want %q", got, tt.wantExposed)
+			}
+			if got := slices.Contains(w.Header().Values("Vary"), "Origin"); got != tt.wantVary {
+				t.Errorf("Vary = %q, want Origin: %t", w.Header().Values("Vary"), tt.wantVary)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != "" {
+				t.Errorf("Access-Control-Allow-Methods = %q, want none", got)
+			}
+		})
+	}
+}
+
+func TestCORSDisabledWithoutOrigins(t *testing.T) {
+	if CORS(CORSPolicy{AllowedOrigins: []string{"", " "}, AllowedMethods: []string{"GET"}}) != nil {
+		t.Error("CORS() returned middleware for an empty origin list")
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		hsts     time.Duration
+		wantHSTS string
+	}{
+		{name: "without HSTS"},
+		{name: "with HSTS", hsts: 24 * time.Hour, wantHSTS: "max-age=86400; includeSubDomains"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			SecurityHeaders(tt.hsts)(&nextRecorder{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/songs", nil))
+
+			want := map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "no-referrer",
+				"Content-Security-Policy":   apiContentSecurityPolicy,
+				"Strict-Transport-Security": tt.wantHSTS,
+			}
+			for header, value := range want {
+				if got := w.Header().Get(header); got != value {
+					t.Errorf("%s = %q, want %q", header, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestDocsSecurityHeadersOverrideCSP(t *testing.T) {
+	handler := SecurityHeaders(0)(DocsSecurityHeaders(&nextRecorder{}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/index.html", nil))
+
+	if got := w.Header().Values("Content-Security-Policy"); len(got) != 1 || got[0] != docsContentSecurityPolicy {
+		t.Errorf("Content-Security-Policy = %q, want only %q", got, docsContentSecurityPolicy)
+	}
+	// Остальные заголовки документации не ослабляются
+	if got := w.Header().Get("X-Frame-Options"); got != "DENY" {
+		t.Errorf("X-Frame-Options = %q, want DENY", got)
+	}
+}
+
+// readBody отвечает телом запроса или ошибкой чтения так же, как обработчики API
+func readBody(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RenderBodyError(w, r, err, "failed to read request body")
+		return
+	}
+	w.Write(body)
+}
+
+func TestMaxBodySize(t *testing.T) {
+	const limit = 16
+	tests := []struct {
+		name     string
+		size     int
+		streamed bool
+		want     int
+	}{
+		{name: "content length at limit", size: limit, want: http.StatusOK},
+		{name: "content length over limit", size: limit + 1, want: http.StatusRequestEntityTooLarge},
+		{name: "streamed at limit", size: limit, streamed: true, want: http.StatusOK},
+		{name: "streamed over limit", size: limit + 1, streamed: true, want: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.Repeat("a", tt.size)
+			r := httptest.NewRequest(http.MethodPost, "/songs", strings.NewReader(body))
+			if tt.streamed {
+				// Тело без Content-Length, как при chunked передаче
+				r.Body = io.NopCloser(struct{ io.Reader }{strings.NewReader(body)})
+				r.ContentLength = -1
+			}
+			reached := false
+			handler := MaxBodySize(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+				readBody(w, r)
+			}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			switch {
+			case tt.want == http.StatusOK && w.Body.String() != body:
+				t.Errorf("body = %q, want %q", w.Body, body)
+			case tt.want != http.StatusOK && w.Header().Get("Content-Type") != "application/problem+json":
+				t.Errorf("Content-Type = %q, want application/problem+json", w.Header().Get("Content-Type"))
+			}
+			// Запрос с большим Content-Length отклоняется до обработчика, без длины - при чтении тела
+			if !tt.streamed && tt.want != http.StatusOK && reached {
+				t.Error("request with a large Content-Length reached the handler")
+			}
+		})
+	}
+}
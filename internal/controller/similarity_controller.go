@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+)
+
+// Количество похожих песен в ответе по умолчанию и максимальное
+const (
+	defaultSimilarLimit = 10
+	maxSimilarLimit     = 50
+)
+
+// GetSimilarSongs godoc
+// @Summary Get similar songs
+// @Description Get songs ranked by TF-IDF cosine similarity of lyrics combined with same artist and release year proximity
+// @Produce json
+// @Param id path int true "Song id"
+// @Param limit query int false "Number of songs, 1-50 (default 10)"
+// @Success 200 {array} models.SimilarSong
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/similar [get]
+func (c *SongController) GetSimilarSongs(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetSimilarSongs"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	limit := defaultSimilarLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxSimilarLimit {
+			RenderError(w, r, log, models.NewValidationError("limit", fmt.Sprintf("must be between 1 and %d", maxSimilarLimit)))
+			return
+		}
+	}
+	similar, err := c.songService.GetSimilarSongs(r.Context(), id, limit)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, similar)
+}
@@ -1,20 +1,58 @@
 package controller
 
 import (
+	"context"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/validator"
 )
 
 type SongService interface {
-	FilterSongs(group, name string, page, pageSize, id int) ([]models.Song, error)
-	CreateSong(group, name string) (uint, error)
-	GetVersesWithPagination(id, page, pageSize int) ([]string, error)
-	DeleteSong(id int) error
-	UpdateSong(song *models.Song) error
+	FilterSongs(ctx context.Context, filter models.SongFilter, page, pageSize int) ([]models.Song, error)
+	CreateSong(ctx context.Context, group, name string) (uint, error)
+	GetSong(ctx context.Context, id int, include []string) (*models.SongDetails, error)
+	GetVersesWithPagination(ctx context.Context, id, page, pageSize int) (*models.VersePage, error)
+	GetLinesWithPagination(ctx context.Context, id, page, pageSize int) (*models.VersePage, error)
+	GetTranslatedVerses(ctx context.Context, id int, language string, page, pageSize int) (*models.VersePage, error)
+	GetVerse(ctx context.Context, id, number int) (*models.Verse, error)
+	GetLyrics(ctx context.Context, id int) (*models.Lyrics, error)
+	ImportSyncedLyrics(ctx context.Context, id int, lrcText string) (*models.SyncedLyrics, error)
+	GetSyncedLyrics(ctx context.Context, id int) (*models.SyncedLyrics, error)
+	ExportSyncedLyrics(ctx context.Context, id int) (string, error)
+	GetSyncedLyricsAt(ctx context.Context, id, atMs, contextLines int) (*models.SyncedLyricsPosition, error)
+	ImportChords(ctx context.Context, id int, source string) (*models.Chords, error)
+	GetChords(ctx context.Context, id, transpose int) (*models.Chords, error)
+	RenderChords(ctx context.Context, id, transpose int) (string, error)
+	Chart(ctx context.Context, period string, limit int) (*models.Chart, error)
+	CreateTranslation(ctx context.Context, songID int, language string, verses []string) (*models.Translation, error)
+	UpdateTranslation(ctx context.Context, songID int, language string, verses []string) (*models.Translation, error)
+	ListTranslations(ctx context.Context, songID int) ([]models.Translation, error)
+	CreateAnnotation(ctx context.Context, songID int, annotation *models.Annotation) error
+	UpdateAnnotation(ctx context.Context, songID int, annotation *models.Annotation) error
+	GetAnnotation(ctx context.Context, songID, id int) (*models.Annotation, error)
+	ListAnnotations(ctx context.Context, songID int) ([]models.Annotation, error)
+	DeleteAnnotation(ctx context.Context, songID, id int) error
+	GetAnnotatedLyrics(ctx context.Context, songID int) (*models.AnnotatedLyrics, error)
+	CreateSuggestion(ctx context.Context, songID int, suggestion *models.Suggestion) error
+	GetSuggestion(ctx context.Context, songID, id int) (*models.Suggestion, error)
+	ListSuggestions(ctx context.Context, filter models.SuggestionFilter, page, pageSize int) ([]models.Suggestion, error)
+	DiffSuggestion(ctx context.Context, songID, id int) (*models.SuggestionDiff, error)
+	ApproveSuggestion(ctx context.Context, songID, id int, moderator string) (*models.Suggestion, error)
+	RejectSuggestion(ctx context.Context, songID, id int, moderator, reason string) (*models.Suggestion, error)
+	ListRevisions(ctx context.Context, songID int) ([]models.Revision, error)
+	GetSongStats(ctx context.Context, id, top int) (*models.SongStats, error)
+	GetWordUsage(ctx context.Context, word string) (*models.WordUsage, error)
+	GetSimilarSongs(ctx context.Context, id, limit int) ([]models.SimilarSong, error)
+	DeleteSong(ctx context.Context, id int) error
+	UpdateSong(ctx context.Context, song *models.Song) error
+	SetExplicitOverride(ctx context.Context, id int, override *bool) (*models.Song, error)
+	SetLanguage(ctx context.Context, id int, language *string) (*models.Song, error)
+	LanguageFacets(ctx context.Context, filter models.SongFilter) ([]models.LanguageFacet, error)
 }
 
 type SongController struct {
@@ -30,8 +68,26 @@ func NewController(songService SongService, log *slog.Logger) *SongController {
 }
 
 type Request struct {
-	Group string `json:"group"`
-	Name  string `json:"song"`
+	Group string `json:"group" validate:"required,max=255"`
+	Name  string `json:"song" validate:"required,max=255"`
+}
+
+// UpdateRequest содержит изменяемые поля песни. Пустые поля не обновляются
+type UpdateRequest struct {
+	Group       string `json:"group" validate:"max=255"`
+	Name        string `json:"song" validate:"max=255"`
+	ReleaseDate string `json:"release_date" validate:"date,datemin=1900-01-01,notfuture"`
+	Text        string `json:"text" validate:"max=50000"`
+	Link        string `json:"link" validate:"url,max=2048"`
+}
+
+// FilterRequest содержит параметры фильтрации списка песен
+type FilterRequest struct {
+	Group    string `json:"group" validate:"max=255"`
+	Name     string `json:"name" validate:"max=255"`
+	Explicit string `json:"explicit" validate:"oneof=true false"`
+	Language string `json:"language" validate:"lang"`
+	Sort     string `json:"sort" validate:"oneof=popularity rating"`
 }
 
 // GetSongs godoc
@@ -40,41 +96,102 @@ type Request struct {
 // @Param group query string false "Group name"
 // @Param name query string false "Song name"
 // @Param id query integer false "Song id"
+// @Param explicit query boolean false "Filter by explicit content flag"
+// @Param language query string false "Filter by language code"
+// @Param sort query string false "Order: popularity (most played first) or rating (highest average rating first)"
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
 // @Success 200 {array} models.Song
-// @Failure 500 {object} models.Failures
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs [get]
 func (c *SongController) GetSongs(w http.ResponseWriter, r *http.Request) {
-	group := r.URL.Query().Get("group")
-	name := r.URL.Query().Get("name")
-	pageStr := r.URL.Query().Get("page")
-	pageSizeStr := r.URL.Query().Get("page_size")
-	idStr := r.URL.Query().Get("id")
-	id, err := strconv.Atoi(idStr)
+	const op = "controller.SongController.GetSongs"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	songFilter, err := GetSongFilter(r)
 	if err != nil {
-		id = 0
+		RenderError(w, r, log, err)
+		return
 	}
+	pageStr := r.URL.Query().Get("page")
+	pageSizeStr := r.URL.Query().Get("page_size")
 	page, pageSize := GetPages(pageStr, pageSizeStr)
 
-	songs, err := c.songService.FilterSongs(group, name, page, pageSize, id)
+	songs, err := c.songService.FilterSongs(r.Context(), songFilter, page, pageSize)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, map[string]string{"error": "internal server error"})
+		RenderError(w, r, log, err)
 		return
 	}
 	render.JSON(w, r, songs)
 }
 
+// GetSong godoc
+// @Summary Get song by id
+// @Description Get a single song with computed fields. HEAD returns the same headers without a body
+// @Produce json
+// @Param id path int true "Song id"
+// @Param include query string false "Comma-separated expansions: verses"
+// @Success 200 {object} models.SongDetails
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id} [get]
+// @Router /songs/{id} [head]
+func (c *SongController) GetSong(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetSong"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	song, err := c.songService.GetSong(r.Context(), id, GetInclude(r))
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	w.Header().Set("Last-Modified", song.UpdatedAt.UTC().Format(http.TimeFormat))
+	render.JSON(w, r, song)
+}
+
 // CreateSong godoc
 // @Summary Create song with enrichment
 // @Description Create a song with enrichment
 // @Accept json
 // @Produce json
 // @Param request body Request true "Name and group of the song"
+// @Param Idempotency-Key header string false "Key to safely retry the request, the stored response is replayed for repeated keys"
 // @Success 200 {object} models.CreateSongResponse
-// @Failure 500 {object} models.Failures
-// @Failure 400 {object} models.Failures
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Failure 502 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs [post]
 func (c *SongController) CreateSong(w http.ResponseWriter, r *http.Request) {
 	const op = "controller.SongController.CreateSong"
@@ -83,21 +200,18 @@ func (c *SongController) CreateSong(w http.ResponseWriter, r *http.Request) {
 		slog.String("op", op),
 	)
 	err := render.DecodeJSON(r.Body, &request)
-	if request.Name == "" || request.Group == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, map[string]string{"error": "invalid request"})
-		return
-	}
 	if err != nil {
 		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, map[string]string{"error": "invalid request"})
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
 		return
 	}
-	id, err := c.songService.CreateSong(request.Group, request.Name)
+	id, err := c.songService.CreateSong(r.Context(), request.Group, request.Name)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, map[string]string{"error": "internal server error"})
+		RenderError(w, r, log, err)
 		return
 	}
 	render.JSON(w, r, map[string]uint{"song_id": id})
@@ -105,13 +219,33 @@ func (c *SongController) CreateSong(w http.ResponseWriter, r *http.Request) {
 
 // GetVersesByID godoc
 // @Summary Get verses by song id
-// @Description Get verses by song id with pagination
+// @Description Get song text with pagination by verses (default) or by lines (mode=lines). With lang returns original verses
+// @Description paired with the translation by index. Pages past the end are empty. The body is an array of page items,
+// @Description navigation data is returned in headers
 // @Param id path int true "Song id"
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
+// @Param mode query string false "Pagination mode: verses or lines"
+// @Param lang query string false "Translation language code"
 // @Success 200 {array} string "verses"
-// @Failure 500 {object} models.Failures
-// @Failure 400 {object} models.Failures
+// @Success 200 {array} models.VerseLine "lines (mode=lines)"
+// @Success 200 {array} models.VersePair "verses with translation (lang)"
+// @Header 200 {string} X-Pagination-Mode "Pagination mode: verses or lines"
+// @Header 200 {integer} X-Page "Page number"
+// @Header 200 {integer} X-Page-Size "Page size"
+// @Header 200 {integer} X-Total-Pages "Number of pages"
+// @Header 200 {integer} X-Total-Verses "Number of verses in the song"
+// @Header 200 {integer} X-Total-Lines "Number of lines in the song"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs/{id}/verses [get]
 func (c *SongController) GetVersesByID(w http.ResponseWriter, r *http.Request) {
 	const op = "controller.SongController.GetVersesByID"
@@ -121,26 +255,141 @@ func (c *SongController) GetVersesByID(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		log.Debug("failed to get id", slog.String("err", err.Error()))
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, map[string]string{"error": "invalid request"})
+		RenderBadRequest(w, r, "invalid song id")
 		return
 	}
 	pageStr := r.URL.Query().Get("page")
 	pageSizeStr := r.URL.Query().Get("page_size")
+	mode := r.URL.Query().Get("mode")
+	lang := r.URL.Query().Get("lang")
 
 	page, pageSize := GetPages(pageStr, pageSizeStr)
-	verses, err := c.songService.GetVersesWithPagination(id, page, pageSize)
+	var versePage *models.VersePage
+	switch {
+	case mode != "" && mode != models.PaginationVerses && mode != models.PaginationLines:
+		err = models.NewValidationError("mode", "must be verses or lines")
+	case lang != "" && mode == models.PaginationLines:
+		err = models.NewValidationError("lang", "is not supported in lines mode")
+	case lang != "":
+		versePage, err = c.songService.GetTranslatedVerses(r.Context(), id, lang, page, pageSize)
+	case mode == models.PaginationLines:
+		versePage, err = c.songService.GetLinesWithPagination(r.Context(), id, page, pageSize)
+	default:
+		versePage, err = c.songService.GetVersesWithPagination(r.Context(), id, page, pageSize)
+	}
 	if err != nil {
-		if err.Error() == "no more verses available" {
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, map[string]string{"error": "no more verses available"})
-			return
-		}
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, map[string]string{"error": "internal server error"})
+		RenderError(w, r, log, err)
+		return
+	}
+	renderVersePage(w, r, versePage)
+}
+
+// Заголовки с данными для навигации по тексту песни
+const (
+	HeaderPaginationMode = "X-Pagination-Mode"
+	HeaderPage           = "X-Page"
+	HeaderPageSize       = "X-Page-Size"
+	HeaderTotalPages     = "X-Total-Pages"
+	HeaderTotalVerses    = "X-Total-Verses"
+	HeaderTotalLines     = "X-Total-Lines"
+)
+
+// renderVersePage отдает элементы страницы массивом, как до появления навигации, чтобы не ломать
+// существующих клиентов, а данные для навигации - в заголовках
+func renderVersePage(w http.ResponseWriter, r *http.Request, page *models.VersePage) {
+	w.Header().Set(HeaderPaginationMode, page.Mode)
+	w.Header().Set(HeaderPage, strconv.Itoa(page.Page))
+	w.Header().Set(HeaderPageSize, strconv.Itoa(page.PageSize))
+	w.Header().Set(HeaderTotalPages, strconv.Itoa(page.TotalPages))
+	w.Header().Set(HeaderTotalVerses, strconv.Itoa(page.TotalVerses))
+	w.Header().Set(HeaderTotalLines, strconv.Itoa(page.TotalLines))
+	switch {
+	case page.Translations != nil:
+		render.JSON(w, r, *page.Translations)
+	case page.Lines != nil:
+		render.JSON(w, r, *page.Lines)
+	case page.Verses != nil:
+		render.JSON(w, r, *page.Verses)
+	default:
+		render.JSON(w, r, []string{})
+	}
+}
+
+// GetVerse godoc
+// @Summary Get single verse
+// @Description Get one verse of the song by its number (starting from 1)
+// @Produce json
+// @Param id path int true "Song id"
+// @Param n path int true "Verse number"
+// @Success 200 {object} models.Verse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/verses/{n} [get]
+func (c *SongController) GetVerse(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetVerse"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	number, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil {
+		log.Debug("failed to get verse number", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid verse number")
+		return
+	}
+	verse, err := c.songService.GetVerse(r.Context(), id, number)
+	if err != nil {
+		RenderError(w, r, log, err)
 		return
 	}
-	render.JSON(w, r, verses)
+	render.JSON(w, r, verse)
+}
+
+// GetLyrics godoc
+// @Summary Get structured lyrics by song id
+// @Description Get song lyrics split into typed sections (verse, chorus, bridge, ...) in order of appearance
+// @Produce json
+// @Param id path int true "Song id"
+// @Success 200 {object} models.Lyrics
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics [get]
+func (c *SongController) GetLyrics(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetLyrics"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	lyrics, err := c.songService.GetLyrics(r.Context(), id)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, lyrics)
 }
 
 // DeleteSong godoc
@@ -148,8 +397,15 @@ func (c *SongController) GetVersesByID(w http.ResponseWriter, r *http.Request) {
 // @Description Delete song by id
 // @Param id path int true "Song id"
 // @Success 200 {string}  string    "song deleted"
-// @Failure 500 {object} models.Failures
-// @Failure 400 {object} models.Failures
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs/{id} [delete]
 func (c *SongController) DeleteSong(w http.ResponseWriter, r *http.Request) {
 	const op = "controller.SongController.DeleteSong"
@@ -159,19 +415,12 @@ func (c *SongController) DeleteSong(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		log.Debug("failed to get id", slog.String("err", err.Error()))
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, map[string]string{"error": "invalid request"})
+		RenderBadRequest(w, r, "invalid song id")
 		return
 	}
-	err = c.songService.DeleteSong(id)
+	err = c.songService.DeleteSong(r.Context(), id)
 	if err != nil {
-		if err.Error() == "song not found" {
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, map[string]string{"error": "song not found"})
-			return
-		}
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, map[string]string{"error": "internal server error"})
+		RenderError(w, r, log, err)
 		return
 	}
 	render.JSON(w, r, map[string]string{"message": "song deleted"})
@@ -183,10 +432,20 @@ func (c *SongController) DeleteSong(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Song id"
-// @Param request body models.SongWithoutID false "Partial Song object" example({"text": "new text", "group": "Muse"})
+// @Param request body UpdateRequest false "Partial Song object" example({"text": "new text", "group": "Muse"})
 // @Success 200 {string}  string    "song updated"
-// @Failure 500 {object} models.Failures
-// @Failure 400 {object} models.Failures
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs/{id} [put]
 func (c *SongController) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	const op = "controller.SongController.UpdateSong"
@@ -196,39 +455,72 @@ func (c *SongController) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		log.Debug("failed to get id", slog.String("err", err.Error()))
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, map[string]string{"error": "invalid request"})
+		RenderBadRequest(w, r, "invalid song id")
 		return
 	}
-	var song models.Song
+	var request UpdateRequest
 
-	err = render.DecodeJSON(r.Body, &song)
+	err = render.DecodeJSON(r.Body, &request)
 	if err != nil {
 		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, map[string]string{"error": "invalid request"})
+		RenderBodyError(w, r, err, "invalid JSON body")
 		return
 	}
-	song.ID = uint(id)
-	err = c.songService.UpdateSong(&song)
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	song := models.Song{
+		ID:          uint(id),
+		Group:       request.Group,
+		Song:        request.Name,
+		ReleaseDate: request.ReleaseDate,
+		Text:        request.Text,
+		Link:        request.Link,
+	}
+	err = c.songService.UpdateSong(r.Context(), &song)
 	if err != nil {
-		if err.Error() == "song not found" {
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, map[string]string{"error": "song not found"})
-			return
-		}
-		if err.Error() == "bad date format" {
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, map[string]string{"error": err.Error()})
-			return
-		}
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, map[string]string{"error": "internal server error"})
+		RenderError(w, r, log, err)
 		return
 	}
 	render.JSON(w, r, map[string]string{"message": "song updated"})
 }
 
+// GetSongFilter разбирает и проверяет параметры фильтрации списка песен
+func GetSongFilter(r *http.Request) (models.SongFilter, error) {
+	filter := FilterRequest{
+		Group:    r.URL.Query().Get("group"),
+		Name:     r.URL.Query().Get("name"),
+		Explicit: r.URL.Query().Get("explicit"),
+		Language: r.URL.Query().Get("language"),
+		Sort:     r.URL.Query().Get("sort"),
+	}
+	if err := validator.Validate(&filter); err != nil {
+		return models.SongFilter{}, err
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		id = 0
+	}
+	songFilter := models.SongFilter{ID: id, Group: filter.Group, Name: filter.Name, Language: filter.Language, Sort: filter.Sort}
+	if filter.Explicit != "" {
+		explicit := filter.Explicit == "true"
+		songFilter.Explicit = &explicit
+	}
+	return songFilter, nil
+}
+
+// GetInclude разбирает параметр include вида ?include=a,b
+func GetInclude(r *http.Request) []string {
+	var include []string
+	for _, item := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			include = append(include, item)
+		}
+	}
+	return include
+}
+
 func GetPages(page, pageSize string) (int, int) {
 	pageInt, err := strconv.Atoi(page)
 	if err != nil || pageInt < 1 {
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/validator"
+)
+
+// Количество самых частых слов в статистике по умолчанию и максимальное
+const (
+	defaultTopWords = 10
+	maxTopWords     = 100
+)
+
+// WordUsageRequest содержит слово для поиска по библиотеке
+type WordUsageRequest struct {
+	Word string `json:"word" validate:"required,max=100"`
+}
+
+// GetSongStats godoc
+// @Summary Get lyrics statistics
+// @Description Get word, line, verse and unique word counts of the song and its most frequent words excluding stop words
+// @Produce json
+// @Param id path int true "Song id"
+// @Param top query int false "Number of most frequent words, 1-100 (default 10)"
+// @Success 200 {object} models.SongStats
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/stats [get]
+func (c *SongController) GetSongStats(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetSongStats"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	top := defaultTopWords
+	if topStr := r.URL.Query().Get("top"); topStr != "" {
+		top, err = strconv.Atoi(topStr)
+		if err != nil || top < 1 || top > maxTopWords {
+			RenderError(w, r, log, models.NewValidationError("top", fmt.Sprintf("must be between 1 and %d", maxTopWords)))
+			return
+		}
+	}
+	stats, err := c.songService.GetSongStats(r.Context(), id, top)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, stats)
+}
+
+// GetWordUsage godoc
+// @Summary Find songs using a word
+// @Description Get songs whose lyrics contain the word and how many times it is used in each, most frequent first
+// @Produce json
+// @Param word query string true "Word to look up"
+// @Success 200 {object} models.WordUsage
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /stats/words [get]
+func (c *SongController) GetWordUsage(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetWordUsage"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	request := WordUsageRequest{Word: r.URL.Query().Get("word")}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	usage, err := c.songService.GetWordUsage(r.Context(), request.Word)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, usage)
+}
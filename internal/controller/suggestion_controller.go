@@ -0,0 +1,371 @@
+package controller
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/textdiff"
+	"testEffectiveMobile/internal/utils/validator"
+)
+
+// SuggestionRequest содержит предложенный текст песни целиком и необязательный комментарий к правке.
+// Текст ограничен так же, как при изменении песни
+type SuggestionRequest struct {
+	Text    string `json:"text" validate:"required,max=50000"`
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+// RejectRequest содержит причину отклонения предложения
+type RejectRequest struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// SuggestionStatusRequest содержит фильтр списка предложений по статусу
+type SuggestionStatusRequest struct {
+	Status string `json:"status" validate:"oneof=pending approved rejected"`
+}
+
+// CreateSuggestion godoc
+// @Summary Suggest lyrics fix
+// @Description Propose a new text of the song. The change is applied only after a moderator approves it
+// @Accept json
+// @Produce json
+// @Param id path int true "Song id"
+// @Param request body SuggestionRequest true "Proposed text"
+// @Param Idempotency-Key header string false "Key to safely retry the request, the stored response is replayed for repeated keys"
+// @Success 201 {object} models.Suggestion
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/suggestions [post]
+func (c *SongController) CreateSuggestion(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.CreateSuggestion"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	var request SuggestionRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	suggestion := &models.Suggestion{Text: request.Text, Comment: request.Comment, Author: requestSubject(r)}
+	if err := c.songService.CreateSuggestion(r.Context(), id, suggestion); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, suggestion)
+}
+
+// ListSongSuggestions godoc
+// @Summary List song suggestions
+// @Description Get suggestions for the song with pagination, oldest first
+// @Produce json
+// @Param id path int true "Song id"
+// @Param status query string false "Filter by status: pending, approved or rejected"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {array} models.Suggestion
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/suggestions [get]
+func (c *SongController) ListSongSuggestions(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.ListSongSuggestions"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	c.listSuggestions(w, r, log, id)
+}
+
+// ListSuggestions godoc
+// @Summary Moderation queue
+// @Description Get suggestions for all songs with pagination, oldest first. Use status=pending for the moderation queue
+// @Produce json
+// @Param status query string false "Filter by status: pending, approved or rejected"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {array} models.Suggestion
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /suggestions [get]
+func (c *SongController) ListSuggestions(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.ListSuggestions"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	c.listSuggestions(w, r, log, 0)
+}
+
+// listSuggestions отдает страницу предложений песни songID или всех песен, если songID равен 0
+func (c *SongController) listSuggestions(w http.ResponseWriter, r *http.Request, log *slog.Logger, songID int) {
+	request := SuggestionStatusRequest{Status: r.URL.Query().Get("status")}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	page, pageSize := GetPages(r.URL.Query().Get("page"), r.URL.Query().Get("page_size"))
+	filter := models.SuggestionFilter{SongID: songID, Status: request.Status}
+	suggestions, err := c.songService.ListSuggestions(r.Context(), filter, page, pageSize)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, suggestions)
+}
+
+// GetSuggestion godoc
+// @Summary Get suggestion
+// @Description Get a single suggestion of the song
+// @Produce json
+// @Param id path int true "Song id"
+// @Param suggestionID path int true "Suggestion id"
+// @Success 200 {object} models.Suggestion
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/suggestions/{suggestionID} [get]
+func (c *SongController) GetSuggestion(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetSuggestion"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, suggestionID, ok := suggestionPath(w, r, log)
+	if !ok {
+		return
+	}
+	suggestion, err := c.songService.GetSuggestion(r.Context(), id, suggestionID)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, suggestion)
+}
+
+// GetSuggestionDiff godoc
+// @Summary Compare suggestion with current text
+// @Description Line diff of the suggested text against the current text of the song. stale=true means the text changed after the suggestion was made and it can no longer be approved. ?format=text returns the diff as plain text with "+ " and "- " line prefixes
+// @Produce json
+// @Produce plain
+// @Param id path int true "Song id"
+// @Param suggestionID path int true "Suggestion id"
+// @Param format query string false "Response format: json (default) or text"
+// @Success 200 {object} models.SuggestionDiff
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/suggestions/{suggestionID}/diff [get]
+func (c *SongController) GetSuggestionDiff(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetSuggestionDiff"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, suggestionID, ok := suggestionPath(w, r, log)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" {
+		RenderError(w, r, log, models.NewValidationError("format", "must be json or text"))
+		return
+	}
+	diff, err := c.songService.DiffSuggestion(r.Context(), id, suggestionID)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	if format == "text" {
+		render.PlainText(w, r, textdiff.Render(diff.Lines))
+		return
+	}
+	render.JSON(w, r, diff)
+}
+
+// ApproveSuggestion godoc
+// @Summary Approve suggestion
+// @Description Apply the suggested text to the song as PUT /songs/{id} does and record a revision. Fails with 409 if the text changed after the suggestion was made or the suggestion is already reviewed
+// @Produce json
+// @Param id path int true "Song id"
+// @Param suggestionID path int true "Suggestion id"
+// @Success 200 {object} models.Suggestion
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/suggestions/{suggestionID}/approve [post]
+func (c *SongController) ApproveSuggestion(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.ApproveSuggestion"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, suggestionID, ok := suggestionPath(w, r, log)
+	if !ok {
+		return
+	}
+	suggestion, err := c.songService.ApproveSuggestion(r.Context(), id, suggestionID, requestSubject(r))
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, suggestion)
+}
+
+// RejectSuggestion godoc
+// @Summary Reject suggestion
+// @Description Reject the suggestion with a reason shown to its author
+// @Accept json
+// @Produce json
+// @Param id path int true "Song id"
+// @Param suggestionID path int true "Suggestion id"
+// @Param request body RejectRequest true "Reason"
+// @Success 200 {object} models.Suggestion
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/suggestions/{suggestionID}/reject [post]
+func (c *SongController) RejectSuggestion(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.RejectSuggestion"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, suggestionID, ok := suggestionPath(w, r, log)
+	if !ok {
+		return
+	}
+	var request RejectRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	suggestion, err := c.songService.RejectSuggestion(r.Context(), id, suggestionID, requestSubject(r), request.Reason)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, suggestion)
+}
+
+// ListRevisions godoc
+// @Summary List lyrics revisions
+// @Description Get versions of the song text recorded when suggestions were approved, newest first
+// @Produce json
+// @Param id path int true "Song id"
+// @Success 200 {array} models.Revision
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/revisions [get]
+func (c *SongController) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.ListRevisions"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	revisions, err := c.songService.ListRevisions(r.Context(), id)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, revisions)
+}
+
+// suggestionPath разбирает id песни и предложения из пути. При ошибке отвечает 400 и возвращает ok = false
+func suggestionPath(w http.ResponseWriter, r *http.Request, log *slog.Logger) (id, suggestionID int, ok bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return 0, 0, false
+	}
+	suggestionID, err = strconv.Atoi(chi.URLParam(r, "suggestionID"))
+	if err != nil {
+		log.Debug("failed to get suggestion id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid suggestion id")
+		return 0, 0, false
+	}
+	return id, suggestionID, true
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/lrc"
+)
+
+// maxLRCSize ограничивает размер загружаемого LRC-файла. Более длинные файлы отклоняются с 413
+const maxLRCSize = 1 << 20
+
+// defaultSyncedContext - количество строк до и после текущей по умолчанию
+const defaultSyncedContext = 2
+
+// PutSyncedLyrics godoc
+// @Summary Import synced lyrics in LRC format
+// @Description Replace time-synced lyrics of the song with lines from an LRC file. Timestamps must go in non-decreasing order
+// @Accept plain
+// @Produce json
+// @Param id path int true "Song id"
+// @Param request body string true "LRC file"
+// @Success 200 {object} models.SyncedLyrics
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics/synced [put]
+func (c *SongController) PutSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.PutSyncedLyrics"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLRCSize))
+	if err != nil {
+		log.Warn("failed to read body", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "failed to read request body")
+		return
+	}
+	synced, err := c.songService.ImportSyncedLyrics(r.Context(), id, string(body))
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, synced)
+}
+
+// GetSyncedLyrics godoc
+// @Summary Get synced lyrics
+// @Description Get time-synced lyrics of the song. With at=mm:ss returns the line playing at that moment and surrounding lines,
+// @Description with format=lrc returns the lyrics as an LRC file
+// @Produce json
+// @Produce plain
+// @Param id path int true "Song id"
+// @Param at query string false "Playback position in mm:ss or mm:ss.xx format"
+// @Param context query int false "Number of lines before and after the current one (default 2)"
+// @Param format query string false "Set to lrc to export LRC file"
+// @Success 200 {object} models.SyncedLyrics
+// @Success 200 {object} models.SyncedLyricsPosition
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics/synced [get]
+func (c *SongController) GetSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.GetSyncedLyrics"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+
+	if r.URL.Query().Get("format") == "lrc" {
+		text, err := c.songService.ExportSyncedLyrics(r.Context(), id)
+		if err != nil {
+			RenderError(w, r, log, err)
+			return
+		}
+		render.PlainText(w, r, text)
+		return
+	}
+
+	at := r.URL.Query().Get("at")
+	if at == "" {
+		synced, err := c.songService.GetSyncedLyrics(r.Context(), id)
+		if err != nil {
+			RenderError(w, r, log, err)
+			return
+		}
+		render.JSON(w, r, synced)
+		return
+	}
+	atMs, err := lrc.ParseTimestamp(at)
+	if err != nil {
+		RenderError(w, r, log, models.NewValidationError("at", err.Error()))
+		return
+	}
+	contextLines, err := strconv.Atoi(r.URL.Query().Get("context"))
+	if err != nil || contextLines < 0 {
+		contextLines = defaultSyncedContext
+	}
+	position, err := c.songService.GetSyncedLyricsAt(r.Context(), id, atMs, contextLines)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, position)
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// songBodyStub запоминает загруженный текст. Остальные методы SongService не используются
+type songBodyStub struct {
+	SongService
+	imported string
+}
+
+func (s *songBodyStub) ImportSyncedLyrics(_ context.Context, _ int, text string) (*models.SyncedLyrics, error) {
+	s.imported = text
+	return &models.SyncedLyrics{}, nil
+}
+
+// testUploadLimit проверяет, что тело длиннее limit отклоняется с 413 и не передается в сервис,
+// а тело длиной limit передается целиком
+func testUploadLimit(t *testing.T, path string, limit int, handler http.HandlerFunc, service *songBodyStub) {
+	t.Helper()
+	router := chi.NewRouter()
+	router.Put(path, handler)
+	send := func(size int) int {
+		url := strings.ReplaceAll(path, "{id}", "1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, url, strings.NewReader(strings.Repeat("a", size))))
+		return w.Code
+	}
+
+	if status := send(limit + 1); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", status)
+	}
+	if service.imported != "" {
+		t.Errorf("imported %d bytes of an oversized body", len(service.imported))
+	}
+	if status := send(limit); status != http.StatusOK || len(service.imported) != limit {
+		t.Errorf("status = %d, imported %d bytes, want 200 and %d bytes", status, len(service.imported), limit)
+	}
+}
+
+func TestPutSyncedLyricsRejectsOversizedFile(t *testing.T) {
+	service := &songBodyStub{}
+	controller := NewController(service, discardLog)
+	testUploadLimit(t, "/songs/{id}/lyrics/synced", maxLRCSize, controller.PutSyncedLyrics, service)
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/tenant"
+	"testEffectiveMobile/internal/utils/validator"
+)
+
+// TenantHeader - заголовок, которым администратор или ключ API без арендатора выбирает библиотеку песен
+const TenantHeader = "X-Tenant"
+
+type TenantService interface {
+	CreateTenant(ctx context.Context, tenant *models.Tenant) error
+	ListTenants(ctx context.Context) ([]models.Tenant, error)
+	GetTenantBySlug(ctx context.Context, slug string) (*models.Tenant, error)
+}
+
+type TenantController struct {
+	log           *slog.Logger
+	tenantService TenantService
+}
+
+func NewTenantController(tenantService TenantService, log *slog.Logger) *TenantController {
+	return &TenantController{
+		tenantService: tenantService,
+		log:           log,
+	}
+}
+
+// ResolveTenant определяет арендатора запроса и сохраняет его id в контексте (tenant.WithID). Арендатор
+// берется из ключа API или JWT клиента, затем из заголовка X-Tenant, затем используется defaultTenant.
+// Заголовок принимается только от анонимных запросов, администраторов и ключей API без арендатора, остальным
+// клиентам выбор чужого арендатора запрещен. Должен стоять после Authenticate
+func ResolveTenant(log *slog.Logger, tenants TenantService, policy *Policy, defaultTenant string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "controller.ResolveTenant"
+			log := log.With(
+				slog.String("op", op),
+			)
+			slug := r.Header.Get(TenantHeader)
+			principal := PrincipalFromContext(r.Context())
+			switch {
+			case principal == nil:
+			case principal.Tenant != "":
+				if slug != "" && slug != principal.Tenant {
+					log.Debug("tenant mismatch", slog.String("subject", principal.Subject), slog.String("tenant", slug))
+					RenderError(w, r, log, fmt.Errorf("client is bound to tenant %q: %w", principal.Tenant, models.ErrForbidden))
+					return
+				}
+				slug = principal.Tenant
+			case slug != "" && principal.Method != models.AuthMethodAPIKey && !policy.Allowed(principal, models.PermissionAdmin):
+				log.Debug("tenant header is not allowed", slog.String("subject", principal.Subject), slog.String("tenant", slug))
+				RenderError(w, r, log, fmt.Errorf("%s header is allowed only for admins and API keys: %w", TenantHeader,
+					models.ErrForbidden))
+				return
+			}
+			if slug == "" {
+				slug = defaultTenant
+			}
+			if slug == "" {
+				RenderBadRequest(w, r, TenantHeader+" header is required")
+				return
+			}
+			current, err := tenants.GetTenantBySlug(r.Context(), slug)
+			if err != nil {
+				RenderError(w, r, log, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.WithID(r.Context(), current.ID)))
+		})
+	}
+}
+
+// TenantRequest содержит код и название создаваемого арендатора
+type TenantRequest struct {
+	Slug string `json:"slug" validate:"required,max=64,slug"`
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// CreateTenant godoc
+// @Summary Create tenant
+// @Description Create a tenant with its own song library. Admins and API keys without a tenant select it with the X-Tenant header, other clients are bound to it by their API key or token
+// @Accept json
+// @Produce json
+// @Param request body TenantRequest true "Tenant slug and name"
+// @Param Idempotency-Key header string false "Key to safely retry the request, the stored response is replayed for repeated keys"
+// @Success 201 {object} models.Tenant
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/tenants [post]
+func (c *TenantController) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.TenantController.CreateTenant"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	var request TenantRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	created := models.Tenant{Slug: request.Slug, Name: request.Name}
+	if err := c.tenantService.CreateTenant(r.Context(), &created); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, created)
+}
+
+// ListTenants godoc
+// @Summary List tenants
+// @Description List tenants
+// @Produce json
+// @Success 200 {array} models.Tenant
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/tenants [get]
+func (c *TenantController) ListTenants(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.TenantController.ListTenants"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	tenants, err := c.tenantService.ListTenants(r.Context())
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, tenants)
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/tenant"
+	"testing"
+)
+
+// tenantServiceStub знает арендаторов default (id 1) и acme (id 2). Остальные методы TenantService не используются
+type tenantServiceStub struct {
+	TenantService
+}
+
+func (tenantServiceStub) GetTenantBySlug(_ context.Context, slug string) (*models.Tenant, error) {
+	switch slug {
+	case "default":
+		return &models.Tenant{ID: 1, Slug: slug}, nil
+	case "acme":
+		return &models.Tenant{ID: 2, Slug: slug}, nil
+	}
+	return nil, fmt.Errorf("tenant %q: %w", slug, models.ErrNotFound)
+}
+
+func TestResolveTenant(t *testing.T) {
+	policy, err := NewPolicy(discardLog, "reader=songs:read;admin=*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := ResolveTenant(discardLog, tenantServiceStub{}, policy, "default")(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(strconv.FormatUint(uint64(tenant.FromContext(r.Context())), 10)))
+		}))
+	user := func(role, tenant string) *models.Principal {
+		return &models.Principal{Subject: "user:1", Method: models.AuthMethodJWT, Roles: []string{role}, Tenant: tenant}
+	}
+	apiKey := func(role, tenant string) *models.Principal {
+		return &models.Principal{Subject: "api-key:1", Method: models.AuthMethodAPIKey, Roles: []string{role}, Tenant: tenant}
+	}
+	tests := []struct {
+		name       string
+		principal  *models.Principal
+		header     string
+		wantStatus int
+		wantTenant string
+	}{
+		{name: "anonymous", wantStatus: http.StatusOK, wantTenant: "1"},
+		{name: "anonymous with header", header: "acme", wantStatus: http.StatusOK, wantTenant: "2"},
+		{name: "bound user", principal: user(models.RoleReader, "acme"), wantStatus: http.StatusOK, wantTenant: "2"},
+		{name: "bound user with own tenant", principal: user(models.RoleReader, "acme"), header: "acme",
+			wantStatus: http.StatusOK, wantTenant: "2"},
+		{name: "bound user spoofs tenant", principal: user(models.RoleReader, "acme"), header: "default",
+			wantStatus: http.StatusForbidden},
+		{name: "unbound user", principal: user(models.RoleReader, ""), wantStatus: http.StatusOK, wantTenant: "1"},
+		{name: "unbound user spoofs tenant", principal: user(models.RoleReader, ""), header: "acme",
+			wantStatus: http.StatusForbidden},
+		{name: "bound admin spoofs tenant", principal: user(models.RoleAdmin, "acme"), header: "default",
+			wantStatus: http.StatusForbidden},
+		{name: "unbound admin selects tenant", principal: user(models.RoleAdmin, ""), header: "acme",
+			wantStatus: http.StatusOK, wantTenant: "2"},
+		{name: "bound api key spoofs tenant", principal: apiKey(models.RoleReader, "default"), header: "acme",
+			wantStatus: http.StatusForbidden},
+		{name: "unbound api key selects tenant", principal: apiKey(models.RoleReader, ""), header: "acme",
+			wantStatus: http.StatusOK, wantTenant: "2"},
+		{name: "unknown tenant", principal: apiKey(models.RoleReader, ""), header: "unknown",
+			wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/songs", nil)
+			if tt.principal != nil {
+				r = r.WithContext(WithPrincipal(r.Context(), tt.principal))
+			}
+			if tt.header != "" {
+				r.Header.Set(TenantHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantTenant != "" && w.Body.String() != tt.wantTenant {
+				t.Errorf("tenant id = %s, want %s", w.Body, tt.wantTenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/validator"
+)
+
+// TranslationRequest содержит перевод песни, выровненный по куплетам оригинала
+type TranslationRequest struct {
+	Language string   `json:"language" validate:"required,lang"`
+	Verses   []string `json:"verses"`
+}
+
+// ListTranslations godoc
+// @Summary List song translations
+// @Description Get all translations of the song
+// @Produce json
+// @Param id path int true "Song id"
+// @Success 200 {array} models.Translation
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/translations [get]
+func (c *SongController) ListTranslations(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.ListTranslations"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	var translations []models.Translation
+	translations, err = c.songService.ListTranslations(r.Context(), id)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, translations)
+}
+
+// CreateTranslation godoc
+// @Summary Create song translation
+// @Description Add a translation of the song lyrics. Verses are aligned with the original by index, so their number must match
+// @Accept json
+// @Produce json
+// @Param id path int true "Song id"
+// @Param request body TranslationRequest true "Translation"
+// @Param Idempotency-Key header string false "Key to safely retry the request, the stored response is replayed for repeated keys"
+// @Success 201 {object} models.Translation
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/translations [post]
+func (c *SongController) CreateTranslation(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.CreateTranslation"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	var request TranslationRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	translation, err := c.songService.CreateTranslation(r.Context(), id, request.Language, request.Verses)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, translation)
+}
+
+// UpdateTranslation godoc
+// @Summary Update song translation
+// @Description Replace verses of an existing translation. The language in the body is ignored
+// @Accept json
+// @Produce json
+// @Param id path int true "Song id"
+// @Param lang path string true "Language code"
+// @Param request body TranslationRequest true "Translation"
+// @Success 200 {object} models.Translation
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/translations/{lang} [put]
+func (c *SongController) UpdateTranslation(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.SongController.UpdateTranslation"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Debug("failed to get id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	var request TranslationRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	request.Language = chi.URLParam(r, "lang")
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	translation, err := c.songService.UpdateTranslation(r.Context(), id, request.Language, request.Verses)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, translation)
+}
@@ -0,0 +1,468 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/validator"
+)
+
+type UserService interface {
+	Register(ctx context.Context, email, password string) (*models.User, error)
+	Login(ctx context.Context, email, password string) (*models.AccessToken, error)
+	GetUser(ctx context.Context, id uint) (*models.User, error)
+	AddFavorite(ctx context.Context, userID uint, songID int) error
+	RemoveFavorite(ctx context.Context, userID uint, songID int) error
+	ListFavorites(ctx context.Context, userID uint, page, pageSize int) ([]models.FavoriteSong, error)
+	RecordPlay(ctx context.Context, userID uint, songID int) (*models.Play, error)
+	ListHistory(ctx context.Context, userID uint, page, pageSize int) ([]models.HistoryEntry, error)
+	RateSong(ctx context.Context, userID uint, songID, rating int) (*models.SongRating, error)
+	DeleteRating(ctx context.Context, userID uint, songID int) (*models.SongRating, error)
+}
+
+type UserController struct {
+	log         *slog.Logger
+	userService UserService
+}
+
+func NewUserController(userService UserService, log *slog.Logger) *UserController {
+	return &UserController{
+		userService: userService,
+		log:         log,
+	}
+}
+
+// RegisterRequest содержит адрес почты и пароль нового пользователя. Пароль ограничен 72 байтами bcrypt
+type RegisterRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"notrim,required,min=8,max=72"`
+}
+
+// LoginRequest содержит адрес почты и пароль пользователя
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"notrim,required"`
+}
+
+// PlayRequest указывает прослушанную песню
+type PlayRequest struct {
+	SongID int `json:"song_id"`
+}
+
+// RatingRequest содержит оценку песни от 1 до 5
+type RatingRequest struct {
+	Rating int `json:"rating"`
+}
+
+// Register godoc
+// @Summary Register user
+// @Description Create a user account with the reader role in the tenant from X-Tenant or the default tenant. The user's tokens are bound to this tenant. The password is stored as a bcrypt hash
+// @Accept json
+// @Produce json
+// @Param request body RegisterRequest true "Email and password"
+// @Param X-Tenant header string false "Tenant slug, the default tenant is used if empty"
+// @Success 201 {object} models.User
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Router /auth/register [post]
+func (c *UserController) Register(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.UserController.Register"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	var request RegisterRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	user, err := c.userService.Register(r.Context(), request.Email, request.Password)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, user)
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Check the email and password and issue a JWT for the Authorization: Bearer header.
+// @Description Returns 503 if JWT issuing is not configured
+// @Accept json
+// @Produce json
+// @Param request body LoginRequest true "Email and password"
+// @Success 200 {object} models.AccessToken
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Router /auth/login [post]
+func (c *UserController) Login(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.UserController.Login"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	var request LoginRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if err := validator.Validate(&request); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	token, err := c.userService.Login(r.Context(), request.Email, request.Password)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, token)
+}
+
+// GetMe godoc
+// @Summary Get current user
+// @Description Get the account of the user the token was issued to
+// @Produce json
+// @Success 200 {object} models.User
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security BearerAuth
+// @Router /me [get]
+func (c *UserController) GetMe(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.UserController.GetMe"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	userID, err := currentUserID(r)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	user, err := c.userService.GetUser(r.Context(), userID)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, user)
+}
+
+// GetFavorites godoc
+// @Summary Get favorite songs
+// @Description Get the current user's favorite songs with pagination, most recently added first
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {array} models.FavoriteSong
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security BearerAuth
+// @Router /me/favorites [get]
+func (c *UserController) GetFavorites(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.UserController.GetFavorites"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	userID, err := currentUserID(r)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	page, pageSize := GetPages(r.URL.Query().Get("page"), r.URL.Query().Get("page_size"))
+	favorites, err := c.userService.ListFavorites(r.Context(), userID, page, pageSize)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, favorites)
+}
+
+// AddFavorite godoc
+// @Summary Add song to favorites
+// @Description Add a song to the current user's favorites. Adding the same song again is not an error
+// @Produce json
+// @Param songID path int true "Song id"
+// @Success 200 {string} string "song added to favorites"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security BearerAuth
+// @Router /me/favorites/{songID} [put]
+func (c *UserController) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.UserController.AddFavorite"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	userID, err := currentUserID(r)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	songID, err := strconv.Atoi(chi.URLParam(r, "songID"))
+	if err != nil {
+		log.Debug("failed to get song id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	if err := c.userService.AddFavorite(r.Context(), userID, songID); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, map[string]string{"message": "song added to favorites"})
+}
+
+// RemoveFavorite godoc
+// @Summary Remove song from favorites
+// @Description Remove a song from the current user's favorites
+// @Produce json
+// @Param songID path int true "Song id"
+// @Success 200 {string} string "song removed from favorites"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security BearerAuth
+// @Router /me/favorites/{songID} [delete]
+func (c *UserController) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.UserController.RemoveFavorite"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	userID, err := currentUserID(r)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	songID, err := strconv.Atoi(chi.URLParam(r, "songID"))
+	if err != nil {
+		log.Debug("failed to get song id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	if err := c.userService.RemoveFavorite(r.Context(), userID, songID); err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, map[string]string{"message": "song removed from favorites"})
+}
+
+// GetHistory godoc
+// @Summary Get listening history
+// @Description Get the songs played by the current user with pagination, most recent first
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {array} models.HistoryEntry
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security BearerAuth
+// @Router /me/history [get]
+func (c *UserController) GetHistory(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.UserController.GetHistory"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	userID, err := currentUserID(r)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	page, pageSize := GetPages(r.URL.Query().Get("page"), r.URL.Query().Get("page_size"))
+	history, err := c.userService.ListHistory(r.Context(), userID, page, pageSize)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, history)
+}
+
+// RecordPlay godoc
+// @Summary Record song play
+// @Description Add a play of the song to the current user's listening history
+// @Accept json
+// @Produce json
+// @Param request body PlayRequest true "Played song" example({"song_id": 1})
+// @Param Idempotency-Key header string false "Key to safely retry the request, the stored response is replayed for repeated keys"
+// @Success 201 {object} models.Play
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security BearerAuth
+// @Router /me/history [post]
+func (c *UserController) RecordPlay(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.UserController.RecordPlay"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	userID, err := currentUserID(r)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	var request PlayRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if request.SongID < 1 {
+		RenderError(w, r, log, models.NewValidationError("song_id", "is required"))
+		return
+	}
+	play, err := c.userService.RecordPlay(r.Context(), userID, request.SongID)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, play)
+}
+
+// RateSong godoc
+// @Summary Rate song
+// @Description Rate a song from 1 to 5. A user has one rating per song, rating again replaces it
+// @Accept json
+// @Produce json
+// @Param songID path int true "Song id"
+// @Param request body RatingRequest true "Rating" example({"rating": 5})
+// @Success 200 {object} models.SongRating
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security BearerAuth
+// @Router /me/ratings/{songID} [put]
+func (c *UserController) RateSong(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.UserController.RateSong"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	userID, err := currentUserID(r)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	songID, err := strconv.Atoi(chi.URLParam(r, "songID"))
+	if err != nil {
+		log.Debug("failed to get song id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	var request RatingRequest
+	if err := render.DecodeJSON(r.Body, &request); err != nil {
+		log.Warn("failed to decode JSON", slog.String("err", err.Error()))
+		RenderBodyError(w, r, err, "invalid JSON body")
+		return
+	}
+	if request.Rating < 1 || request.Rating > 5 {
+		RenderError(w, r, log, models.NewValidationError("rating", "must be between 1 and 5"))
+		return
+	}
+	rating, err := c.userService.RateSong(r.Context(), userID, songID, request.Rating)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, rating)
+}
+
+// DeleteRating godoc
+// @Summary Delete song rating
+// @Description Remove the current user's rating of the song
+// @Produce json
+// @Param songID path int true "Song id"
+// @Success 200 {object} models.SongRating
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 503 {object} models.Problem
+// @Security BearerAuth
+// @Router /me/ratings/{songID} [delete]
+func (c *UserController) DeleteRating(w http.ResponseWriter, r *http.Request) {
+	const op = "controller.UserController.DeleteRating"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+	userID, err := currentUserID(r)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	songID, err := strconv.Atoi(chi.URLParam(r, "songID"))
+	if err != nil {
+		log.Debug("failed to get song id", slog.String("err", err.Error()))
+		RenderBadRequest(w, r, "invalid song id")
+		return
+	}
+	rating, err := c.userService.DeleteRating(r.Context(), userID, songID)
+	if err != nil {
+		RenderError(w, r, log, err)
+		return
+	}
+	render.JSON(w, r, rating)
+}
+
+// currentUserID возвращает пользователя, которому выдан токен запроса. Запросы без аутентификации
+// отклоняются с models.ErrUnauthorized, запросы с ключом API - с models.ErrForbidden
+func currentUserID(r *http.Request) (uint, error) {
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil {
+		return 0, fmt.Errorf("user token required: %w", models.ErrUnauthorized)
+	}
+	userID, ok := models.ParseUserSubject(principal.Subject)
+	if !ok {
+		return 0, fmt.Errorf("available only to user accounts: %w", models.ErrForbidden)
+	}
+	return userID, nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Виды аннотаций
+const (
+	AnnotationExplanation = "explanation"
+	AnnotationReference   = "reference"
+)
+
+// Annotation - пояснение к фрагменту текста песни. Фрагмент задается номером куплета и строки (с 1)
+// и диапазоном символов [start, end) в строке; start = end = 0 означает всю строку.
+// Quote хранит текст фрагмента, по нему аннотация переносится при изменении текста песни
+// @Description аннотация к фрагменту текста
+type Annotation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SongID    uint      `json:"song_id" gorm:"column:song_id"`
+	Verse     int       `json:"verse" gorm:"column:verse"`
+	Line      int       `json:"line" gorm:"column:line"`
+	Start     int       `json:"start" gorm:"column:start_char"`
+	End       int       `json:"end" gorm:"column:end_char"`
+	Quote     string    `json:"quote" gorm:"column:quote"`
+	Kind      string    `json:"kind" gorm:"column:kind"`
+	Body      string    `json:"body" gorm:"column:body"`
+	Orphaned  bool      `json:"orphaned" gorm:"column:orphaned"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (Annotation) TableName() string {
+	return "song_annotations"
+}
+
+// AnnotatedSegment - часть строки и аннотации, которые к ней относятся
+// @Description часть строки с аннотациями
+type AnnotatedSegment struct {
+	Text          string `json:"text"`
+	AnnotationIDs []uint `json:"annotation_ids,omitempty"`
+}
+
+// AnnotatedLine - строка текста с маркерами аннотаций. Marked содержит строку, в которой
+// аннотированные фрагменты отмечены в стиле сносок: [фрагмент][^id]
+// @Description строка текста с маркерами аннотаций
+type AnnotatedLine struct {
+	Number   int                `json:"number"`
+	Text     string             `json:"text"`
+	Marked   string             `json:"marked"`
+	Segments []AnnotatedSegment `json:"segments"`
+}
+
+// AnnotatedVerse - куплет с аннотированными строками
+// @Description куплет с аннотированными строками
+type AnnotatedVerse struct {
+	Number int             `json:"number"`
+	Lines  []AnnotatedLine `json:"lines"`
+}
+
+// AnnotatedLyrics - текст песни с маркерами аннотаций и сами аннотации
+// @Description текст песни с аннотациями
+type AnnotatedLyrics struct {
+	SongID      uint             `json:"song_id"`
+	Verses      []AnnotatedVerse `json:"verses"`
+	Annotations []Annotation     `json:"annotations"`
+}
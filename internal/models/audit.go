@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Действия, которые записываются в журнал аудита
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// Виды ресурсов в журнале аудита
+const (
+	AuditResourceSong         = "song"
+	AuditResourceTranslation  = "translation"
+	AuditResourceAnnotation   = "annotation"
+	AuditResourceSyncedLyrics = "synced_lyrics"
+	AuditResourceChords       = "chords"
+	AuditResourceSuggestion   = "suggestion"
+	AuditResourceAPIKey       = "api_key"
+	AuditResourceUser         = "user"
+	AuditResourceTenant       = "tenant"
+	AuditResourceFavorite     = "favorite"
+	AuditResourcePlay         = "play"
+	AuditResourceRating       = "rating"
+)
+
+// AuditSource - кто и откуда выполнил изменение
+type AuditSource struct {
+	Actor     string
+	RequestID string
+	ClientIP  string
+}
+
+// AuditEntry - запись журнала аудита. Before и After - состояние ресурса до и после изменения,
+// при создании нет Before, при удалении - After
+// @Description запись журнала аудита
+type AuditEntry struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	Actor      string          `json:"actor" gorm:"column:actor"`
+	Action     string          `json:"action" gorm:"column:action"`
+	Resource   string          `json:"resource" gorm:"column:resource"`
+	ResourceID string          `json:"resource_id" gorm:"column:resource_id"`
+	RequestID  string          `json:"request_id,omitempty" gorm:"column:request_id"`
+	ClientIP   string          `json:"client_ip,omitempty" gorm:"column:client_ip"`
+	Before     json.RawMessage `json:"before,omitempty" gorm:"column:before;serializer:json" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" gorm:"column:after;serializer:json" swaggertype:"object"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"column:created_at"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
+
+// AuditFilter - условия выборки журнала аудита. Пустые поля не ограничивают выборку,
+// From включается в интервал, To - нет
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	Resource   string
+	ResourceID string
+	From       *time.Time
+	To         *time.Time
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Способы аутентификации клиента
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+)
+
+// Роли клиентов API
+const (
+	RoleReader = "reader"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Разрешения, которые проверяются на маршрутах. PermissionAll в настройке роли дает все разрешения
+const (
+	PermissionRead   = "songs:read"
+	PermissionWrite  = "songs:write"
+	PermissionDelete = "songs:delete"
+	// PermissionSuggest позволяет предлагать правки текста, PermissionModerate - принимать и отклонять их
+	PermissionSuggest  = "songs:suggest"
+	PermissionModerate = "songs:moderate"
+	PermissionAdmin    = "admin"
+	PermissionAll      = "*"
+)
+
+// Principal - аутентифицированный клиент API, от имени которого выполняется запрос. Tenant - код арендатора,
+// к которому привязан клиент, или пустая строка, если клиент выбирает арендатора заголовком X-Tenant
+type Principal struct {
+	Subject string   `json:"subject"`
+	Name    string   `json:"name,omitempty"`
+	Method  string   `json:"method"`
+	Roles   []string `json:"roles"`
+	Tenant  string   `json:"tenant,omitempty"`
+}
+
+// APIKey - статический ключ доступа к API. В базе хранится только SHA-256 хэш ключа,
+// сам ключ показывается один раз при создании
+// @Description ключ доступа к API
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Name       string     `json:"name" gorm:"column:name"`
+	Prefix     string     `json:"prefix" gorm:"column:prefix"`
+	Role       string     `json:"role" gorm:"column:role"`
+	Tenant     *string    `json:"tenant" gorm:"column:tenant"`
+	Hash       string     `json:"-" gorm:"column:hash"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"column:revoked_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// CreatedAPIKey - только что созданный ключ вместе с его значением
+// @Description созданный ключ доступа к API
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
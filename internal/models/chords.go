@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// ChordSheet - исходный текст песни с аккордами в формате ChordPro
+type ChordSheet struct {
+	SongID    uint      `json:"song_id" gorm:"column:song_id;primaryKey"`
+	Source    string    `json:"source" gorm:"column:source"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (ChordSheet) TableName() string {
+	return "song_chord_sheets"
+}
+
+// Chords - разобранный текст песни с аккордами над строками
+// @Description текст песни с аккордами
+type Chords struct {
+	SongID    uint        `json:"song_id"`
+	Title     string      `json:"title,omitempty"`
+	Artist    string      `json:"artist,omitempty"`
+	Key       string      `json:"key,omitempty"`
+	Transpose int         `json:"transpose"`
+	Lines     []ChordLine `json:"lines"`
+}
+
+// ChordLine - строка текста с аккордами. Section - часть песни (verse, chorus, bridge), в которой находится строка,
+// Comment - строка является комментарием ({comment: ...}), а не текстом песни
+// @Description строка текста с аккордами
+type ChordLine struct {
+	Section string          `json:"section,omitempty"`
+	Comment bool            `json:"comment,omitempty"`
+	Text    string          `json:"text"`
+	Chords  []ChordPosition `json:"chords"`
+}
+
+// ChordPosition - аккорд и номер символа строки, над которым он стоит (с 0)
+// @Description аккорд над строкой
+type ChordPosition struct {
+	Chord    string `json:"chord"`
+	Position int    `json:"position"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// Ошибки предметной области. Слои сервиса и репозитория оборачивают их через fmt.Errorf("...: %w", ...),
+// а контроллер сопоставляет их с HTTP-статусами через errors.Is/errors.As
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	// ErrUnavailable - возможность сервиса отключена в конфигурации или временно недоступна
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// FieldError описывает ошибку валидации конкретного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError возвращается, если входные данные не прошли проверку
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		messages = append(messages, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Problem описывает ответ об ошибке в формате RFC 7807 (application/problem+json)
+// @Description ошибка
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
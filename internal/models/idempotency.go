@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyKey - запрос, выполненный с заголовком Idempotency-Key, и сохраненный ответ на него.
+// Status = 0 означает, что запрос еще выполняется
+type IdempotencyKey struct {
+	Key         string    `gorm:"column:key;primaryKey"`
+	Fingerprint string    `gorm:"column:fingerprint"`
+	Status      int       `gorm:"column:status"`
+	ContentType string    `gorm:"column:content_type"`
+	Body        []byte    `gorm:"column:body"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+	ExpiresAt   time.Time `gorm:"column:expires_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"strings"
+)
+
+// Типы частей текста песни
+const (
+	SectionVerse     = "verse"
+	SectionChorus    = "chorus"
+	SectionPreChorus = "pre-chorus"
+	SectionBridge    = "bridge"
+	SectionIntro     = "intro"
+	SectionOutro     = "outro"
+	SectionHook      = "hook"
+	SectionOther     = "other"
+)
+
+// LyricsSection - часть текста песни (куплет, припев, бридж и т.п.)
+// @Description часть текста песни
+type LyricsSection struct {
+	SongID   uint     `json:"-" gorm:"column:song_id;primaryKey"`
+	Position int      `json:"position" gorm:"column:position;primaryKey"`
+	Type     string   `json:"type" gorm:"column:type"`
+	Number   int      `json:"number" gorm:"column:number"`
+	Label    string   `json:"label,omitempty" gorm:"column:label"`
+	Repeat   bool     `json:"repeat" gorm:"column:repeat"`
+	Text     string   `json:"text" gorm:"column:text"`
+	Lines    []string `json:"lines" gorm:"-"`
+}
+
+func (LyricsSection) TableName() string {
+	return "song_sections"
+}
+
+// AfterFind восстанавливает строки части из сохраненного текста
+func (s *LyricsSection) AfterFind(*gorm.DB) error {
+	s.Lines = strings.Split(s.Text, "\n")
+	return nil
+}
+
+// Lyrics - структурированный текст песни
+// @Description структурированный текст песни
+type Lyrics struct {
+	SongID   uint            `json:"song_id"`
+	Sections []LyricsSection `json:"sections"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Rating - оценка песни пользователем от 1 до 5. Один пользователь ставит песне одну оценку
+type Rating struct {
+	UserID    uint      `json:"user_id" gorm:"column:user_id;primaryKey"`
+	SongID    uint      `json:"song_id" gorm:"column:song_id;primaryKey"`
+	Rating    int       `json:"rating" gorm:"column:rating"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (Rating) TableName() string {
+	return "song_ratings"
+}
+
+// SongRating - оценка пользователя и итоговый рейтинг песни после ее изменения
+// @Description оценка песни
+type SongRating struct {
+	SongID        uint     `json:"song_id"`
+	Rating        int      `json:"rating,omitempty"`
+	RatingCount   int      `json:"rating_count"`
+	RatingAverage *float64 `json:"rating_average"`
+}
+
+// Периоды, за которые строится чарт
+const (
+	ChartPeriodDay   = "day"
+	ChartPeriodWeek  = "week"
+	ChartPeriodMonth = "month"
+	ChartPeriodYear  = "year"
+	ChartPeriodAll   = "all"
+)
+
+// ChartEntry - песня в чарте и ее прослушивания и оценки за период
+// @Description место в чарте
+type ChartEntry struct {
+	Song `json:"song"`
+
+	Position int `json:"position" gorm:"-"`
+	// Plays, Ratings и AverageRating считаются только за период чарта
+	Plays         int      `json:"plays" gorm:"column:plays"`
+	Ratings       int      `json:"ratings" gorm:"column:ratings"`
+	AverageRating *float64 `json:"average_rating" gorm:"column:average_rating"`
+	Score         int      `json:"score" gorm:"column:score"`
+}
+
+// Chart - самые популярные песни за период. Since не задан для периода all
+// @Description чарт песен
+type Chart struct {
+	Period  string       `json:"period"`
+	Since   *time.Time   `json:"since"`
+	Entries []ChartEntry `json:"entries"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// SimilarSong - песня, похожая на заданную, с составляющими оценки похожести
+// @Description похожая песня
+type SimilarSong struct {
+	SongID      uint    `json:"song_id"`
+	Group       string  `json:"group"`
+	Song        string  `json:"song"`
+	Score       float64 `json:"score"`
+	LyricsScore float64 `json:"lyrics_score"`
+	SameArtist  bool    `json:"same_artist"`
+	YearScore   float64 `json:"year_score"`
+}
@@ -1,8 +1,6 @@
 package models
 
-import (
-	"gorm.io/datatypes"
-)
+import "time"
 
 type CreateSongResponse struct {
 	SongID uint `json:"song_id"`
@@ -16,23 +14,79 @@ type CreateSongResponse struct {
 // @Property releaseDate{string} дата релиза
 // @Property text{string} текст песни
 // @Property link{string} ссылка на песню
+// @Property created_at{string} время создания записи
+// @Property updated_at{string} время последнего изменения записи
+// @Property explicit{boolean} откровенное содержание
+// @Property language{string} язык текста
+// @Property play_count{integer} количество прослушиваний
+// @Property rating_average{number} средняя оценка
 type Song struct {
-	ID          uint   `json:"id" gorm:"primaryKey"`
-	Group       string `json:"group" gorm:"column:group"`
-	Song        string `json:"song" gorm:"column:song"`
-	ReleaseDate string `json:"release_date" gorm:"column:release_date"`
-	Text        string `json:"text" gorm:"column:text"`
-	Link        string `json:"link" gorm:"column:link"`
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Group       string    `json:"group" gorm:"column:group"`
+	Song        string    `json:"song" gorm:"column:song"`
+	ReleaseDate string    `json:"release_date" gorm:"column:release_date"`
+	Text        string    `json:"text" gorm:"column:text"`
+	Link        string    `json:"link" gorm:"column:link"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"column:updated_at"`
+	// TenantID - арендатор, которому принадлежит песня. Задается только при создании
+	TenantID uint `json:"-" gorm:"column:tenant_id;<-:create"`
+	// Explicit - итоговый признак откровенного содержания: ручное значение ExplicitOverride, если оно задано,
+	// иначе результат проверки текста, причины которого перечислены в ExplicitReasons. nil, пока текст
+	// песни, добавленной до появления проверки, не проверен
+	Explicit         *bool               `json:"explicit" gorm:"column:explicit"`
+	ExplicitOverride *bool               `json:"explicit_override" gorm:"column:explicit_override"`
+	ExplicitReasons  []ContentFlagReason `json:"explicit_reasons" gorm:"column:explicit_reasons;serializer:json"`
+	// Language - код языка текста, определенный автоматически с уверенностью LanguageConfidence
+	// или заданный вручную (LanguageManual). Пустой, если язык определить не удалось, и nil, пока язык
+	// песни, добавленной до появления определения, не определен
+	Language           *string `json:"language" gorm:"column:language"`
+	LanguageConfidence float64 `json:"language_confidence" gorm:"column:language_confidence"`
+	LanguageManual     bool    `json:"language_manual" gorm:"column:language_manual"`
+	// PlayCount, RatingCount и RatingAverage - счетчики, которые база обновляет при прослушиваниях и оценках
+	// пользователей. Через модель они только читаются, чтобы обновление песни их не перезаписывало
+	PlayCount     int64    `json:"play_count" gorm:"column:play_count;->"`
+	RatingCount   int      `json:"rating_count" gorm:"column:rating_count;->"`
+	RatingAverage *float64 `json:"rating_average" gorm:"column:rating_average;->"`
+}
+
+// ContentFlagReason - слово из списка нежелательных, найденное в тексте песни
+// @Description причина пометки песни как откровенной
+type ContentFlagReason struct {
+	Language string `json:"language"`
+	Word     string `json:"word"`
+	Count    int    `json:"count"`
+}
+
+// Порядок выдачи песен. По умолчанию песни упорядочены по идентификатору
+const (
+	SortPopularity = "popularity"
+	SortRating     = "rating"
+)
+
+// SongFilter - условия отбора песен. Пустые поля не участвуют в фильтрации. Sort задает порядок выдачи
+type SongFilter struct {
+	ID       int
+	Group    string
+	Name     string
+	Explicit *bool
+	Language string
+	Sort     string
 }
 
-type SongWithoutID struct {
-	Group       string         `json:"group"`
-	Song        string         `json:"song"`
-	ReleaseDate datatypes.Date `json:"releaseDate"`
-	Text        string         `json:"text"`
-	Link        string         `json:"link"`
+// LanguageFacet - язык и количество песен на нем
+// @Description количество песен на языке
+type LanguageFacet struct {
+	Language string `json:"language"`
+	Count    int    `json:"count"`
 }
 
-type Failures struct {
-	Error string
+// SongDetails - представление одной песни с вычисляемыми полями и дополнительными данными,
+// запрошенными через параметр include
+// @Description песня с вычисляемыми полями
+type SongDetails struct {
+	Song
+	VerseCount int      `json:"verse_count"`
+	WordCount  int      `json:"word_count"`
+	Verses     []string `json:"verses,omitempty"`
 }
@@ -0,0 +1,37 @@
+package models
+
+// WordFrequency - слово и количество его употреблений
+// @Description частота слова
+type WordFrequency struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// SongStats - статистика текста песни
+// @Description статистика текста песни
+type SongStats struct {
+	SongID      uint            `json:"song_id"`
+	WordCount   int             `json:"word_count"`
+	LineCount   int             `json:"line_count"`
+	VerseCount  int             `json:"verse_count"`
+	UniqueWords int             `json:"unique_words"`
+	TopWords    []WordFrequency `json:"top_words"`
+}
+
+// SongWordCount - количество употреблений слова в одной песне
+// @Description употребления слова в песне
+type SongWordCount struct {
+	SongID uint   `json:"song_id"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	Count  int    `json:"count"`
+}
+
+// WordUsage - песни, в которых встречается слово, по убыванию количества употреблений
+// @Description употребления слова в библиотеке
+type WordUsage struct {
+	Word             string          `json:"word"`
+	TotalSongs       int             `json:"total_songs"`
+	TotalOccurrences int             `json:"total_occurrences"`
+	Songs            []SongWordCount `json:"songs"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Статусы предложений правок
+const (
+	SuggestionPending  = "pending"
+	SuggestionApproved = "approved"
+	SuggestionRejected = "rejected"
+)
+
+// Suggestion - предложенная правка текста песни, которую принимает или отклоняет модератор.
+// BaseText - текст песни на момент предложения: правка принимается, только если текст с тех пор не менялся
+// @Description предложение правки текста
+type Suggestion struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	SongID     uint       `json:"song_id" gorm:"column:song_id"`
+	Text       string     `json:"text" gorm:"column:text"`
+	BaseText   string     `json:"-" gorm:"column:base_text"`
+	Comment    string     `json:"comment" gorm:"column:comment"`
+	Author     string     `json:"author" gorm:"column:author"`
+	Status     string     `json:"status" gorm:"column:status"`
+	Reason     string     `json:"reason,omitempty" gorm:"column:reason"`
+	ReviewedBy string     `json:"reviewed_by,omitempty" gorm:"column:reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at" gorm:"column:reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at"`
+}
+
+func (Suggestion) TableName() string {
+	return "song_suggestions"
+}
+
+// SuggestionFilter - условия отбора предложений. Пустые поля не участвуют в фильтрации
+type SuggestionFilter struct {
+	SongID int
+	Status string
+}
+
+// Revision - версия текста песни, сохраненная при принятии предложения
+// @Description версия текста песни
+type Revision struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	SongID       uint      `json:"song_id" gorm:"column:song_id"`
+	Text         string    `json:"text" gorm:"column:text"`
+	PreviousText string    `json:"previous_text" gorm:"column:previous_text"`
+	Author       string    `json:"author" gorm:"column:author"`
+	ApprovedBy   string    `json:"approved_by" gorm:"column:approved_by"`
+	SuggestionID *uint     `json:"suggestion_id" gorm:"column:suggestion_id"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (Revision) TableName() string {
+	return "song_revisions"
+}
+
+// Операции построчного сравнения текстов
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine - строка сравнения текстов. OldLine и NewLine - номера строки (с 1) в старом и новом тексте,
+// 0 для строк, которых в соответствующем тексте нет
+// @Description строка сравнения текстов
+type DiffLine struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// SuggestionDiff - сравнение предложенного текста с текущим текстом песни. Stale означает, что текст
+// песни изменился после предложения и принять его уже нельзя
+// @Description сравнение предложения с текущим текстом
+type SuggestionDiff struct {
+	SuggestionID uint       `json:"suggestion_id"`
+	SongID       uint       `json:"song_id"`
+	Stale        bool       `json:"stale"`
+	Added        int        `json:"added"`
+	Removed      int        `json:"removed"`
+	Lines        []DiffLine `json:"lines"`
+}
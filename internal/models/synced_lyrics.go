@@ -0,0 +1,32 @@
+package models
+
+// SyncedLine - строка текста с временной меткой для режима караоке
+// @Description строка текста с временной меткой
+type SyncedLine struct {
+	SongID   uint   `json:"-" gorm:"column:song_id;primaryKey"`
+	Position int    `json:"position" gorm:"column:position;primaryKey"`
+	TimeMs   int    `json:"time_ms" gorm:"column:time_ms"`
+	Time     string `json:"time" gorm:"-"`
+	Text     string `json:"text" gorm:"column:text"`
+}
+
+func (SyncedLine) TableName() string {
+	return "song_synced_lines"
+}
+
+// SyncedLyrics - синхронизированный текст песни
+// @Description синхронизированный текст песни
+type SyncedLyrics struct {
+	SongID uint         `json:"song_id"`
+	Lines  []SyncedLine `json:"lines"`
+}
+
+// SyncedLyricsPosition - строка, звучащая в момент At, и строки вокруг нее
+// @Description текущая и соседние строки синхронизированного текста
+type SyncedLyricsPosition struct {
+	SongID   uint         `json:"song_id"`
+	AtMs     int          `json:"at_ms"`
+	Current  *SyncedLine  `json:"current"`
+	Previous []SyncedLine `json:"previous"`
+	Next     []SyncedLine `json:"next"`
+}
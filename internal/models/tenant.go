@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Tenant - арендатор: бренд-партнер со своей библиотекой песен. Slug - код арендатора в заголовке X-Tenant,
+// ключах API и JWT
+// @Description арендатор
+type Tenant struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Slug      string    `json:"slug" gorm:"column:slug"`
+	Name      string    `json:"name" gorm:"column:name"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (Tenant) TableName() string {
+	return "tenants"
+}
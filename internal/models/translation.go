@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Translation - перевод текста песни на другой язык. Куплеты перевода выровнены с куплетами оригинала по индексу
+// @Description перевод текста песни
+type Translation struct {
+	SongID    uint      `json:"song_id" gorm:"column:song_id;primaryKey"`
+	Language  string    `json:"language" gorm:"column:language;primaryKey"`
+	Verses    []string  `json:"verses" gorm:"column:verses;serializer:json"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (Translation) TableName() string {
+	return "song_translations"
+}
+
+// VersePair - куплет оригинала и соответствующий ему куплет перевода
+// @Description куплет оригинала и перевода
+type VersePair struct {
+	Index       int    `json:"index"`
+	Original    string `json:"original"`
+	Translation string `json:"translation"`
+}
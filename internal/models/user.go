@@ -0,0 +1,92 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userSubjectPrefix отличает субъект токена пользователя от субъектов ключей API
+const userSubjectPrefix = "user:"
+
+// User - учетная запись пользователя. Пароль хранится только в виде bcrypt-хэша. Пользователь привязан
+// к арендатору, в котором зарегистрировался, Tenant - код этого арендатора
+// @Description пользователь
+type User struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Email        string    `json:"email" gorm:"column:email"`
+	Role         string    `json:"role" gorm:"column:role"`
+	TenantID     uint      `json:"-" gorm:"column:tenant_id;<-:create"`
+	Tenant       string    `json:"tenant,omitempty" gorm:"column:tenant;->;-:migration"`
+	PasswordHash string    `json:"-" gorm:"column:password_hash"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+// UserSubject возвращает субъект токена, выданного пользователю
+func UserSubject(id uint) string {
+	return userSubjectPrefix + strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseUserSubject возвращает идентификатор пользователя из субъекта токена. ok равен false,
+// если субъект принадлежит не пользователю, например ключу API
+func ParseUserSubject(subject string) (id uint, ok bool) {
+	value, found := strings.CutPrefix(subject, userSubjectPrefix)
+	if !found {
+		return 0, false
+	}
+	parsed, err := strconv.ParseUint(value, 10, 0)
+	if err != nil || parsed == 0 {
+		return 0, false
+	}
+	return uint(parsed), true
+}
+
+// AccessToken - токен, выданный при входе пользователя
+// @Description токен доступа
+type AccessToken struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Favorite - песня в избранном пользователя
+type Favorite struct {
+	UserID    uint      `json:"user_id" gorm:"column:user_id;primaryKey"`
+	SongID    uint      `json:"song_id" gorm:"column:song_id;primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (Favorite) TableName() string {
+	return "user_favorites"
+}
+
+// Play - прослушивание песни пользователем
+// @Description прослушивание песни
+type Play struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	UserID   uint      `json:"-" gorm:"column:user_id"`
+	SongID   uint      `json:"song_id" gorm:"column:song_id"`
+	PlayedAt time.Time `json:"played_at" gorm:"column:played_at"`
+}
+
+func (Play) TableName() string {
+	return "song_plays"
+}
+
+// FavoriteSong - песня из избранного и время ее добавления
+// @Description песня в избранном
+type FavoriteSong struct {
+	Song
+	AddedAt time.Time `json:"added_at" gorm:"column:added_at"`
+}
+
+// HistoryEntry - прослушанная песня и время прослушивания
+// @Description запись истории прослушиваний
+type HistoryEntry struct {
+	Song
+	PlayedAt time.Time `json:"played_at" gorm:"column:played_at"`
+}
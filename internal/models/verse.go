@@ -0,0 +1,40 @@
+package models
+
+// Режимы пагинации текста песни
+const (
+	PaginationVerses = "verses"
+	PaginationLines  = "lines"
+)
+
+// Verse - один куплет песни
+// @Description куплет песни
+type Verse struct {
+	SongID      uint     `json:"song_id"`
+	Number      int      `json:"number"`
+	TotalVerses int      `json:"total_verses"`
+	Text        string   `json:"text"`
+	Lines       []string `json:"lines"`
+}
+
+// VerseLine - строка текста песни с указанием куплета, к которому она относится
+// @Description строка текста песни
+type VerseLine struct {
+	Verse int    `json:"verse"`
+	Line  int    `json:"line"`
+	Text  string `json:"text"`
+}
+
+// VersePage - страница текста песни с данными для навигации. В зависимости от режима заполнено
+// одно из полей Verses, Lines или Translations; за пределами текста оно содержит пустой массив.
+// Клиенту элементы страницы отдаются массивом, а данные для навигации - в заголовках ответа
+type VersePage struct {
+	Mode         string
+	Page         int
+	PageSize     int
+	TotalPages   int
+	TotalVerses  int
+	TotalLines   int
+	Verses       *[]string
+	Lines        *[]VerseLine
+	Translations *[]VersePair
+}
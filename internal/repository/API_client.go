@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"testEffectiveMobile/internal/models"
 	"testEffectiveMobile/internal/service"
 	"time"
@@ -13,25 +15,34 @@ import (
 var ClientTimeout time.Duration = 10
 
 type APIClientImpl struct {
-	log *slog.Logger
-	URL string
+	log    *slog.Logger
+	URL    string
+	client *http.Client
 }
 
-func NewAPIClient(url string) service.APIClient {
+func NewAPIClient(log *slog.Logger, url string) service.APIClient {
 	return &APIClientImpl{
-		URL: url,
+		log:    log,
+		URL:    url,
+		client: &http.Client{Timeout: ClientTimeout * time.Second},
 	}
 }
 
-func (a *APIClientImpl) SongEnrichment(name, group string) (*models.Song, error) {
+func (a *APIClientImpl) SongEnrichment(ctx context.Context, name, group string) (*models.Song, error) {
 	const op = "repository.APIClientImpl.SongEnrichment"
 	log := a.log.With(
 		slog.String("op", op),
 	)
 
-	url := fmt.Sprintf("%s/info?group=%s&song=%s", a.URL, group, name)
-	client := &http.Client{Timeout: ClientTimeout * time.Second}
-	resp, err := client.Get(url)
+	query := url.Values{}
+	query.Set("group", group)
+	query.Set("song", name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL+"/info?"+query.Encode(), nil)
+	if err != nil {
+		log.Warn("failed to build request", slog.String("err", err.Error()))
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
 	if err != nil {
 		log.Warn("Can't connect to API", slog.String("err", err.Error()))
 		return nil, err
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/service"
+)
+
+type annotationRepositoryImpl struct {
+	log *slog.Logger
+	DB  *gorm.DB
+}
+
+func (a *annotationRepositoryImpl) CreateAnnotation(ctx context.Context, annotation *models.Annotation) error {
+	const op = "repository.annotationRepositoryImpl.CreateAnnotation"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", annotation.SongID),
+	)
+	err := withTenant(ctx, a.DB, func(tx *gorm.DB) error {
+		if err := requireTenantSong(ctx, tx, annotation.SongID); err != nil {
+			return err
+		}
+		return tx.Create(annotation).Error
+	})
+	if errors.Is(err, models.ErrNotFound) {
+		log.Debug("song not found")
+		return err
+	}
+	if err != nil {
+		log.Warn("failed to create annotation", slog.String("err", err.Error()))
+		return err
+	}
+	log.Info("annotation successfully created", slog.Any("annotation_id", annotation.ID))
+	return nil
+}
+
+func (a *annotationRepositoryImpl) GetAnnotation(ctx context.Context, songID, id int) (*models.Annotation, error) {
+	const op = "repository.annotationRepositoryImpl.GetAnnotation"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+		slog.Any("annotation_id", id),
+	)
+	var annotation models.Annotation
+	err := withTenant(ctx, a.DB, func(tx *gorm.DB) error {
+		return tx.Where("id = ? AND song_id = ? AND song_id IN (?)", id, songID, tenantSongIDs(ctx, tx)).
+			Take(&annotation).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Debug("annotation not found")
+		return nil, fmt.Errorf("annotation %d of song %d: %w", id, songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to get annotation", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &annotation, nil
+}
+
+func (a *annotationRepositoryImpl) ListAnnotations(ctx context.Context, songID int) ([]models.Annotation, error) {
+	const op = "repository.annotationRepositoryImpl.ListAnnotations"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+	)
+	var annotations []models.Annotation
+	err := withTenant(ctx, a.DB, func(tx *gorm.DB) error {
+		return tx.Where("song_id = ? AND song_id IN (?)", songID, tenantSongIDs(ctx, tx)).
+			Order("verse, line, start_char, id").Find(&annotations).Error
+	})
+	if err != nil {
+		log.Warn("failed to get annotations", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return annotations, nil
+}
+
+// SaveAnnotation сохраняет все поля аннотации, включая нулевые (например, сброс признака orphaned)
+func (a *annotationRepositoryImpl) SaveAnnotation(ctx context.Context, annotation *models.Annotation) error {
+	const op = "repository.annotationRepositoryImpl.SaveAnnotation"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", annotation.SongID),
+		slog.Any("annotation_id", annotation.ID),
+	)
+	var updated int64
+	err := withTenant(ctx, a.DB, func(tx *gorm.DB) error {
+		tx = tx.Model(annotation).
+			Where("song_id = ? AND song_id IN (?)", annotation.SongID, tenantSongIDs(ctx, tx)).
+			Select("verse", "line", "start_char", "end_char", "quote", "kind", "body", "orphaned", "updated_at").
+			Updates(annotation)
+		updated = tx.RowsAffected
+		return tx.Error
+	})
+	if err != nil {
+		log.Warn("failed to save annotation", slog.String("err", err.Error()))
+		return err
+	}
+	if updated == 0 {
+		log.Debug("annotation not found")
+		return fmt.Errorf("annotation %d of song %d: %w", annotation.ID, annotation.SongID, models.ErrNotFound)
+	}
+	return nil
+}
+
+func (a *annotationRepositoryImpl) DeleteAnnotation(ctx context.Context, songID, id int) error {
+	const op = "repository.annotationRepositoryImpl.DeleteAnnotation"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+		slog.Any("annotation_id", id),
+	)
+	var deleted int64
+	err := withTenant(ctx, a.DB, func(tx *gorm.DB) error {
+		tx = tx.Where("id = ? AND song_id = ? AND song_id IN (?)", id, songID, tenantSongIDs(ctx, tx)).
+			Delete(&models.Annotation{})
+		deleted = tx.RowsAffected
+		return tx.Error
+	})
+	if err != nil {
+		log.Warn("failed to delete annotation", slog.String("err", err.Error()))
+		return err
+	}
+	if deleted == 0 {
+		log.Debug("annotation not found")
+		return fmt.Errorf("annotation %d of song %d: %w", id, songID, models.ErrNotFound)
+	}
+	log.Info("annotation successfully deleted")
+	return nil
+}
+
+func NewAnnotationRepository(log *slog.Logger, DB *gorm.DB) service.AnnotationRepository {
+	return &annotationRepositoryImpl{
+		log: log,
+		DB:  DB,
+	}
+}
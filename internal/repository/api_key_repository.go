@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/service"
+	"time"
+)
+
+type apiKeyRepositoryImpl struct {
+	log *slog.Logger
+	DB  *gorm.DB
+}
+
+func (a *apiKeyRepositoryImpl) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	const op = "repository.apiKeyRepositoryImpl.CreateAPIKey"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+	err := a.DB.WithContext(ctx).Create(key).Error
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		log.Debug("tenant not found")
+		return models.NewValidationError("tenant", "unknown tenant")
+	}
+	if err != nil {
+		log.Warn("failed to create api key", slog.String("err", err.Error()))
+		return err
+	}
+	log.Info("api key successfully created", slog.Any("api_key_id", key.ID))
+	return nil
+}
+
+func (a *apiKeyRepositoryImpl) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	const op = "repository.apiKeyRepositoryImpl.ListAPIKeys"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+	var keys []models.APIKey
+	if err := a.DB.WithContext(ctx).Order("id").Find(&keys).Error; err != nil {
+		log.Warn("failed to get api keys", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetActiveAPIKeyByHash ищет неотозванный ключ по хэшу
+func (a *apiKeyRepositoryImpl) GetActiveAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	const op = "repository.apiKeyRepositoryImpl.GetActiveAPIKeyByHash"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+	var key models.APIKey
+	err := a.DB.WithContext(ctx).Where("hash = ? AND revoked_at IS NULL", hash).Take(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("api key: %w", models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to get api key", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey отзывает активный ключ и возвращает его состояние после отзыва
+func (a *apiKeyRepositoryImpl) RevokeAPIKey(ctx context.Context, id int) (*models.APIKey, error) {
+	const op = "repository.apiKeyRepositoryImpl.RevokeAPIKey"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Any("api_key_id", id),
+	)
+	var key models.APIKey
+	tx := a.DB.WithContext(ctx).Model(&key).Clauses(clause.Returning{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if tx.Error != nil {
+		log.Warn("failed to revoke api key", slog.String("err", tx.Error.Error()))
+		return nil, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		log.Debug("api key not found")
+		return nil, fmt.Errorf("api key %d: %w", id, models.ErrNotFound)
+	}
+	log.Info("api key revoked")
+	return &key, nil
+}
+
+func (a *apiKeyRepositoryImpl) TouchAPIKey(ctx context.Context, id uint, usedAt time.Time) error {
+	return a.DB.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+func NewAPIKeyRepository(log *slog.Logger, DB *gorm.DB) service.APIKeyRepository {
+	return &apiKeyRepositoryImpl{
+		log: log,
+		DB:  DB,
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/service"
+)
+
+type auditRepositoryImpl struct {
+	log *slog.Logger
+	DB  *gorm.DB
+}
+
+func (a *auditRepositoryImpl) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	const op = "repository.auditRepositoryImpl.CreateAuditEntry"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("resource", entry.Resource),
+		slog.String("resource_id", entry.ResourceID),
+	)
+	if err := a.DB.WithContext(ctx).Create(entry).Error; err != nil {
+		log.Warn("failed to write audit entry", slog.String("err", err.Error()))
+		return err
+	}
+	log.Info("audit entry recorded",
+		slog.String("actor", entry.Actor),
+		slog.String("action", entry.Action),
+		slog.String("request_id", entry.RequestID),
+	)
+	return nil
+}
+
+// ListAuditEntries возвращает записи журнала по фильтру, начиная с последних
+func (a *auditRepositoryImpl) ListAuditEntries(ctx context.Context, filter models.AuditFilter, offset, limit int) ([]models.AuditEntry, error) {
+	const op = "repository.auditRepositoryImpl.ListAuditEntries"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+	query := a.DB.WithContext(ctx).Model(&models.AuditEntry{})
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Resource != "" {
+		query = query.Where("resource = ?", filter.Resource)
+	}
+	if filter.ResourceID != "" {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	var entries []models.AuditEntry
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		log.Warn("failed to get audit entries", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return entries, nil
+}
+
+func NewAuditRepository(log *slog.Logger, DB *gorm.DB) service.AuditRepository {
+	return &auditRepositoryImpl{
+		log: log,
+		DB:  DB,
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/idempotency"
+	"time"
+)
+
+type idempotencyStoreImpl struct {
+	log *slog.Logger
+	DB  *gorm.DB
+}
+
+func (s *idempotencyStoreImpl) Begin(ctx context.Context, record *models.IdempotencyKey) (*models.IdempotencyKey, bool, error) {
+	const op = "repository.idempotencyStoreImpl.Begin"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+	var existing *models.IdempotencyKey
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil || result.RowsAffected == 1 {
+			return result.Error
+		}
+		existing = &models.IdempotencyKey{}
+		return tx.Where("key = ?", record.Key).Take(existing).Error
+	})
+	if err != nil {
+		log.Warn("failed to save idempotency key", slog.String("err", err.Error()))
+		return nil, false, err
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+	return record, true, nil
+}
+
+func (s *idempotencyStoreImpl) Complete(ctx context.Context, record *models.IdempotencyKey) error {
+	const op = "repository.idempotencyStoreImpl.Complete"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+	err := s.DB.WithContext(ctx).Model(&models.IdempotencyKey{}).Where("key = ?", record.Key).
+		Updates(map[string]any{"status": record.Status, "content_type": record.ContentType, "body": record.Body}).Error
+	if err != nil {
+		log.Warn("failed to save idempotent response", slog.String("err", err.Error()))
+	}
+	return err
+}
+
+func (s *idempotencyStoreImpl) Delete(ctx context.Context, key string) error {
+	const op = "repository.idempotencyStoreImpl.Delete"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+	err := s.DB.WithContext(ctx).Where("key = ?", key).Delete(&models.IdempotencyKey{}).Error
+	if err != nil {
+		log.Warn("failed to delete idempotency key", slog.String("err", err.Error()))
+	}
+	return err
+}
+
+func NewIdempotencyStore(log *slog.Logger, DB *gorm.DB) idempotency.Store {
+	return &idempotencyStoreImpl{
+		log: log,
+		DB:  DB,
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"log/slog"
+	"sync"
+	"testEffectiveMobile/internal/utils/ratelimit"
+	"time"
+)
+
+// rateLimitStoreImpl хранит счетчики запросов в Postgres, чтобы лимиты были общими для всех экземпляров сервиса
+type rateLimitStoreImpl struct {
+	log *slog.Logger
+	DB  *gorm.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func (s *rateLimitStoreImpl) Increment(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	const op = "repository.rateLimitStoreImpl.Increment"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("key", key),
+	)
+	s.sweep(ctx, log, windowStart)
+	var count int
+	err := s.DB.WithContext(ctx).Raw(`INSERT INTO rate_limits (key, window_start, count) VALUES (?, ?, 1)
+		ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limits.count + 1
+		RETURNING count`, key, windowStart).Scan(&count).Error
+	if err != nil {
+		log.Warn("failed to increment rate limit counter", slog.String("err", err.Error()))
+		return 0, err
+	}
+	return count, nil
+}
+
+// sweep удаляет счетчики прошедших окон всех клиентов не чаще одного раза за окно в каждом экземпляре сервиса.
+// Счетчики не влияют на текущее окно, поэтому ошибка удаления только записывается в журнал
+func (s *rateLimitStoreImpl) sweep(ctx context.Context, log *slog.Logger, windowStart time.Time) {
+	s.mu.Lock()
+	if !s.lastSweep.Before(windowStart) {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = windowStart
+	s.mu.Unlock()
+	if err := s.DB.WithContext(ctx).Exec("DELETE FROM rate_limits WHERE window_start < ?", windowStart).Error; err != nil {
+		log.Warn("failed to delete expired rate limit counters", slog.String("err", err.Error()))
+	}
+}
+
+func NewRateLimitStore(log *slog.Logger, DB *gorm.DB) ratelimit.Store {
+	return &rateLimitStoreImpl{
+		log: log,
+		DB:  DB,
+	}
+}
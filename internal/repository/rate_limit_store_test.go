@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRateLimitStoreSweepsExpiredWindows(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+	counted := func(count int) *sqlmock.Rows { return sqlmock.NewRows([]string{"count"}).AddRow(count) }
+
+	// Счетчики прошедших окон удаляются для всех ключей один раз за окно, а не только для ключа запроса
+	mock.ExpectExec(`DELETE FROM rate_limits WHERE window_start < \$1$`).WithArgs(first).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO rate_limits").WithArgs("a", first).WillReturnRows(counted(1))
+	mock.ExpectQuery("INSERT INTO rate_limits").WithArgs("b", first).WillReturnRows(counted(1))
+	mock.ExpectExec(`DELETE FROM rate_limits WHERE window_start < \$1$`).WithArgs(second).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("INSERT INTO rate_limits").WithArgs("c", second).WillReturnRows(counted(1))
+
+	store := NewRateLimitStore(discardLog, db)
+	ctx := context.Background()
+	for _, increment := range []struct {
+		key         string
+		windowStart time.Time
+	}{{"a", first}, {"b", first}, {"c", second}} {
+		if _, err := store.Increment(ctx, increment.key, increment.windowStart); err != nil {
+			t.Fatalf("Increment(%q) error = %v", increment.key, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/tenant"
+	"testEffectiveMobile/internal/utils/storage/storagetest"
+)
+
+// restrictedRole создает роль без SUPERUSER и BYPASSRLS с правами на таблицы схемы теста, как у роли,
+// которой сервис должен подключаться в продакшене. Если пользователь тестовой базы не может создавать роли,
+// тест пропускается
+func restrictedRole(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	role := fmt.Sprintf("test_rls_%d", time.Now().UnixNano())
+	if err := db.Exec(fmt.Sprintf("CREATE ROLE %s NOLOGIN NOSUPERUSER NOBYPASSRLS", role)).Error; err != nil {
+		t.Skipf("cannot create a role for the row-level security test: %s", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(fmt.Sprintf("DROP OWNED BY %s", role))
+		db.Exec(fmt.Sprintf("DROP ROLE %s", role))
+	})
+	var schema string
+	if err := db.Raw("SELECT current_schema()").Scan(&schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range []string{
+		// Начиная с PostgreSQL 16 создатель роли без SUPERUSER не может переключиться на нее без явного членства
+		fmt.Sprintf("GRANT %s TO CURRENT_USER", role),
+		fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s", schema, role),
+		fmt.Sprintf("GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA %s TO %s", schema, role),
+		fmt.Sprintf("GRANT USAGE ON ALL SEQUENCES IN SCHEMA %s TO %s", schema, role),
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("%s: %s", statement, err)
+		}
+	}
+	return role
+}
+
+// asRole выполняет fn в транзакции от имени role с app.tenant_id = tenantID. Пустой tenantID оставляет
+// параметр незаданным
+func asRole(db *gorm.DB, role, tenantID string, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL ROLE %s", role)).Error; err != nil {
+			return err
+		}
+		if tenantID != "" {
+			if err := tx.Exec("SELECT set_config('app.tenant_id', ?, true)", tenantID).Error; err != nil {
+				return err
+			}
+		}
+		return fn(tx)
+	})
+}
+
+func TestRowLevelSecurityWithoutBypass(t *testing.T) {
+	db := storagetest.Open(t)
+	var acmeID uint
+	if err := db.Raw("INSERT INTO tenants (slug, name) VALUES ('acme', 'Acme') RETURNING id").Scan(&acmeID).Error; err != nil {
+		t.Fatal(err)
+	}
+	defaultID := defaultTenantID(t, db)
+	songs, translations := NewRepository(discardLog, db), NewTranslationRepository(discardLog, db)
+	songIDs := make(map[uint]uint)
+	for _, tenantID := range []uint{defaultID, acmeID} {
+		ctx := tenant.WithID(context.Background(), tenantID)
+		id, err := songs.CreateSong(ctx, &models.Song{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := translations.CreateTranslation(ctx, &models.Translation{SongID: id, Language: "ru", Verses: []string{"one"}}); err != nil {
+			t.Fatal(err)
+		}
+		songIDs[tenantID] = id
+	}
+	role := restrictedRole(t, db)
+
+	t.Run("role has no bypass", func(t *testing.T) {
+		var bypass bool
+		err := db.Raw("SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = ?", role).Scan(&bypass).Error
+		if err != nil || bypass {
+			t.Fatalf("role %s bypasses row-level security: %t, %v", role, bypass, err)
+		}
+	})
+
+	t.Run("unfiltered queries see only the tenant", func(t *testing.T) {
+		var songRows, translationRows []uint
+		err := asRole(db, role, fmt.Sprint(defaultID), func(tx *gorm.DB) error {
+			if err := tx.Raw("SELECT id FROM songs").Scan(&songRows).Error; err != nil {
+				return err
+			}
+			return tx.Raw("SELECT song_id FROM song_translations").Scan(&translationRows).Error
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := songIDs[defaultID]
+		if len(songRows) != 1 || songRows[0] != want || len(translationRows) != 1 || translationRows[0] != want {
+			t.Errorf("songs = %v, translations = %v, want only song %d", songRows, translationRows, want)
+		}
+	})
+
+	t.Run("no tenant sees nothing", func(t *testing.T) {
+		var count int64
+		err := asRole(db, role, "", func(tx *gorm.DB) error {
+			return tx.Raw("SELECT count(*) FROM songs").Scan(&count).Error
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("songs without app.tenant_id = %d, want 0", count)
+		}
+	})
+
+	t.Run("writes to another tenant fail", func(t *testing.T) {
+		insert := func(tenantID uint, song string) error {
+			return asRole(db, role, fmt.Sprint(defaultID), func(tx *gorm.DB) error {
+				return tx.Exec(`INSERT INTO songs (tenant_id, "group", song, release_date, text, link)
+					VALUES (?, 'Queen', ?, '1975-10-31', 'Is this the real life', 'https://example.com')`, tenantID, song).Error
+			})
+		}
+		// Та же вставка в свой арендатор проходит, поэтому ошибка ниже вызвана политикой, а не самим запросом
+		if err := insert(defaultID, "Bohemian Rhapsody"); err != nil {
+			t.Fatalf("insert into own tenant: %s", err)
+		}
+		if err := insert(acmeID, "Killer Queen"); err == nil {
+			t.Error("insert into another tenant succeeded")
+		}
+		var updated int64
+		err := asRole(db, role, fmt.Sprint(defaultID), func(tx *gorm.DB) error {
+			tx = tx.Exec("UPDATE songs SET text = 'changed' WHERE id = ?", songIDs[acmeID])
+			updated = tx.RowsAffected
+			return tx.Error
+		})
+		if err != nil || updated != 0 {
+			t.Errorf("update of another tenant's song: %d rows, %v", updated, err)
+		}
+	})
+}
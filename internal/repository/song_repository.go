@@ -1,12 +1,16 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"log/slog"
+	"strings"
 	"testEffectiveMobile/internal/models"
 	"testEffectiveMobile/internal/service"
+	"testEffectiveMobile/internal/tenant"
 	"time"
 )
 
@@ -15,13 +19,21 @@ type songRepositoryImpl struct {
 	DB  *gorm.DB
 }
 
-func (s *songRepositoryImpl) CreateSong(song *models.Song) (uint, error) {
+func (s *songRepositoryImpl) CreateSong(ctx context.Context, song *models.Song) (uint, error) {
 	const op = "repository.songRepositoryImpl.CreateSong"
 	log := s.log.With(
 		slog.String("op", op),
 	)
 
-	if err := s.DB.Model(&models.Song{}).Create(song).Error; err != nil {
+	song.TenantID = tenant.FromContext(ctx)
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tx.Model(&models.Song{}).Create(song).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			log.Debug("song already exists")
+			return 0, fmt.Errorf("song %q by %q already exists: %w", song.Song, song.Group, models.ErrConflict)
+		}
 		log.Warn(fmt.Sprintf("failed to create song: %s", err.Error()))
 		return 0, err
 	}
@@ -29,89 +41,463 @@ func (s *songRepositoryImpl) CreateSong(song *models.Song) (uint, error) {
 	return song.ID, nil
 }
 
-// FilterSongs обращается к базе данных для получения записей с фильтрацией по group и name и пагинацией
-func (s *songRepositoryImpl) FilterSongs(group, name string, offset, limit, id int) ([]models.Song, error) {
+// FilterSongs обращается к базе данных для получения записей с фильтрацией и пагинацией
+func (s *songRepositoryImpl) FilterSongs(ctx context.Context, filter models.SongFilter, offset, limit int) ([]models.Song, error) {
 	const op = "repository.songRepositoryImpl.FilterSongs"
 	log := s.log.With(
 		slog.String("op", op),
 	)
 	var songs []models.Song
-	query := s.DB.Model(&models.Song{})
-	if group != "" {
-		query = query.Where("\"group\" ILIKE ?", "%"+group+"%")
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		query := applySongFilter(tenantSongs(ctx, tx), filter)
+		return query.Order(songOrder(filter.Sort)).Limit(limit).Offset(offset).Find(&songs).Error
+	})
+	if err != nil {
+		log.Warn(fmt.Sprintf("failed to get songs: %s", err.Error()))
+		return nil, err
+	}
+	log.Info("songs successfully received")
+	return songs, nil
+}
+
+// LanguageFacets считает количество песен по языкам с учетом всех условий фильтра, кроме языка.
+// Песни, язык которых еще не определен, не учитываются
+func (s *songRepositoryImpl) LanguageFacets(ctx context.Context, filter models.SongFilter) ([]models.LanguageFacet, error) {
+	const op = "repository.songRepositoryImpl.LanguageFacets"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+	var facets []models.LanguageFacet
+	filter.Language = ""
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return applySongFilter(tenantSongs(ctx, tx), filter).
+			Where("language IS NOT NULL").
+			Select("language, COUNT(*) AS count").
+			Group("language").
+			Order("count DESC, language").
+			Scan(&facets).Error
+	})
+	if err != nil {
+		log.Warn("failed to get language facets", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return facets, nil
+}
+
+// applySongFilter добавляет к запросу условия фильтра песен
+func applySongFilter(query *gorm.DB, filter models.SongFilter) *gorm.DB {
+	if filter.Group != "" {
+		query = query.Where("\"group\" ILIKE ?", "%"+filter.Group+"%")
+	}
+	if filter.Name != "" {
+		query = query.Where("song ILIKE ?", "%"+filter.Name+"%")
 	}
-	if name != "" {
-		query = query.Where("song ILIKE ?", "%"+name+"%")
+	if filter.ID > 0 {
+		query = query.Where("id = ?", filter.ID)
 	}
-	if id > 0 {
-		query = query.Where("id = ?", id)
+	if filter.Explicit != nil {
+		query = query.Where("explicit = ?", *filter.Explicit)
+	}
+	if filter.Language != "" {
+		query = query.Where("language = ?", filter.Language)
+	}
+	return query
+}
+
+// songOrder возвращает условие сортировки для порядка выдачи sort. Песни без оценок при сортировке
+// по рейтингу идут последними
+func songOrder(sort string) string {
+	switch sort {
+	case models.SortPopularity:
+		return "play_count DESC, id"
+	case models.SortRating:
+		return "rating_average DESC NULLS LAST, rating_count DESC, id"
+	default:
+		return "id"
+	}
+}
+
+func (s *songRepositoryImpl) GetSongByID(ctx context.Context, id int) (*models.Song, error) {
+	const op = "repository.songRepositoryImpl.GetSongByID"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", id),
+	)
+	var song models.Song
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tenantSongs(ctx, tx).Where("id = ?", id).Take(&song).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Debug("song not found")
+		return nil, fmt.Errorf("song %d: %w", id, models.ErrNotFound)
 	}
-	err := query.Limit(limit).Offset(offset).Find(&songs).Error
 	if err != nil {
-		log.Warn(fmt.Sprintf("failed to get songs: %s", err.Error()))
+		log.Warn("failed to get song", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &song, nil
+}
+
+// GetSongForUpdate читает песню и блокирует ее строку до конца транзакции, чтобы текст не изменился
+// между проверкой и обновлением. Имеет смысл только внутри UnitOfWork
+func (s *songRepositoryImpl) GetSongForUpdate(ctx context.Context, id int) (*models.Song, error) {
+	const op = "repository.songRepositoryImpl.GetSongForUpdate"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", id),
+	)
+	var song models.Song
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tenantSongs(ctx, tx).Where("id = ?", id).Clauses(clause.Locking{Strength: "UPDATE"}).Take(&song).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Debug("song not found")
+		return nil, fmt.Errorf("song %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to lock song", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &song, nil
+}
+
+// SearchSongsByText возвращает песни, текст которых содержит подстроку substr без учета регистра
+func (s *songRepositoryImpl) SearchSongsByText(ctx context.Context, substr string) ([]models.Song, error) {
+	const op = "repository.songRepositoryImpl.SearchSongsByText"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+	var songs []models.Song
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(substr) + "%"
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tenantSongs(ctx, tx).Where("text ILIKE ?", pattern).Order("id").Find(&songs).Error
+	})
+	if err != nil {
+		log.Warn("failed to search songs", slog.String("err", err.Error()))
 		return nil, err
 	}
-	log.Info("songs successfully received")
 	return songs, nil
 }
 
-func (s *songRepositoryImpl) GetVerseByID(id int) (string, error) {
+func (s *songRepositoryImpl) GetVerseByID(ctx context.Context, id int) (string, error) {
 	const op = "repository.songRepositoryImpl.GetVerseByID"
 	log := s.log.With(
 		slog.String("op", op),
 		slog.Any("song_id", id),
 	)
-	var UnsplittedVerse string
-	query := s.DB.Model(&models.Song{}).Select("text")
-	err := query.Where("id = ?", id).Scan(&UnsplittedVerse).Error
-	if err != nil || UnsplittedVerse == "" {
+	var song models.Song
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tenantSongs(ctx, tx).Select("text").Where("id = ?", id).Take(&song).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Debug("song not found")
+		return "", fmt.Errorf("song %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
 		log.Warn("failed to get song verse", slog.String("err", err.Error()))
+		return "", err
 	}
-	return UnsplittedVerse, nil
+	return song.Text, nil
 }
 
-func (s *songRepositoryImpl) DeleteSong(id int) error {
+func (s *songRepositoryImpl) DeleteSong(ctx context.Context, id int) error {
 	const op = "repository.songRepositoryImpl.DeleteSong"
 	log := s.log.With(
 		slog.String("op", op),
 		slog.Any("song_id", id),
 	)
-	tx := s.DB.Model(&models.Song{}).Where("id = ?", id).Delete(&models.Song{})
-	if tx.Error != nil {
-		log.Warn("failed to delete song", slog.String("err", tx.Error.Error()))
+	var deleted int64
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		tx = tenantSongs(ctx, tx).Where("id = ?", id).Delete(&models.Song{})
+		deleted = tx.RowsAffected
 		return tx.Error
+	})
+	if err != nil {
+		log.Warn("failed to delete song", slog.String("err", err.Error()))
+		return err
 	}
-	if tx.RowsAffected == 0 {
+	if deleted == 0 {
 		log.Debug("song not found")
-		return fmt.Errorf("song not found")
+		return fmt.Errorf("song %d: %w", id, models.ErrNotFound)
 	}
 	log.Info("song successfully deleted")
 	return nil
 }
 
-func (s *songRepositoryImpl) UpdateSong(song *models.Song) error {
+func (s *songRepositoryImpl) UpdateSong(ctx context.Context, song *models.Song) error {
 	const op = "repository.songRepositoryImpl.UpdateSong"
 	log := s.log.With(
 		slog.String("op", op),
 		slog.Any("song_id", song.ID),
 	)
-	if _, err := time.Parse(time.DateOnly, song.ReleaseDate); song.ReleaseDate != "" && err != nil {
-		log.Debug("failed to parse date", slog.String("err", err.Error()))
-		return errors.New("bad date format")
-	}
-	tx := s.DB.Model(&models.Song{}).Where("id = ?", song.ID).Updates(song)
-	if tx.Error != nil {
-		log.Warn("failed to update song", slog.String("err", tx.Error.Error()))
+	var updated int64
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		tx = tenantSongs(ctx, tx).Where("id = ?", song.ID).Updates(song)
+		updated = tx.RowsAffected
 		return tx.Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		log.Debug("song already exists")
+		return fmt.Errorf("song %q by %q already exists: %w", song.Song, song.Group, models.ErrConflict)
+	}
+	if err != nil {
+		log.Warn("failed to update song", slog.String("err", err.Error()))
+		return err
 	}
-	if tx.RowsAffected == 0 {
+	if updated == 0 {
 		log.Debug("song not found")
-		return fmt.Errorf("song not found")
+		return fmt.Errorf("song %d: %w", song.ID, models.ErrNotFound)
 	}
 	log.Info("song successfully updated")
 	return nil
 }
 
+// UpdateContentFlags сохраняет признак откровенного содержания, ручное значение и причины
+func (s *songRepositoryImpl) UpdateContentFlags(ctx context.Context, song *models.Song) error {
+	const op = "repository.songRepositoryImpl.UpdateContentFlags"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", song.ID),
+	)
+	var updated int64
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		tx = tenantSongs(ctx, tx).Where("id = ?", song.ID).
+			Select("explicit", "explicit_override", "explicit_reasons").
+			Updates(song)
+		updated = tx.RowsAffected
+		return tx.Error
+	})
+	if err != nil {
+		log.Warn("failed to update content flags", slog.String("err", err.Error()))
+		return err
+	}
+	if updated == 0 {
+		log.Debug("song not found")
+		return fmt.Errorf("song %d: %w", song.ID, models.ErrNotFound)
+	}
+	return nil
+}
+
+// ListUncheckedSongs возвращает до limit песен, текст которых еще не проверен на откровенное содержание
+// или язык которых еще не определен
+func (s *songRepositoryImpl) ListUncheckedSongs(ctx context.Context, limit int) ([]models.Song, error) {
+	const op = "repository.songRepositoryImpl.ListUncheckedSongs"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+	var songs []models.Song
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tenantSongs(ctx, tx).Where("explicit IS NULL OR language IS NULL").Order("id").Limit(limit).Find(&songs).Error
+	})
+	if err != nil {
+		log.Warn("failed to get unchecked songs", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return songs, nil
+}
+
+// UpdateLanguage сохраняет язык текста песни, уверенность и признак ручной установки
+func (s *songRepositoryImpl) UpdateLanguage(ctx context.Context, song *models.Song) error {
+	const op = "repository.songRepositoryImpl.UpdateLanguage"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", song.ID),
+	)
+	var updated int64
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		tx = tenantSongs(ctx, tx).Where("id = ?", song.ID).
+			Select("language", "language_confidence", "language_manual").
+			Updates(song)
+		updated = tx.RowsAffected
+		return tx.Error
+	})
+	if err != nil {
+		log.Warn("failed to update language", slog.String("err", err.Error()))
+		return err
+	}
+	if updated == 0 {
+		log.Debug("song not found")
+		return fmt.Errorf("song %d: %w", song.ID, models.ErrNotFound)
+	}
+	return nil
+}
+
+// ReplaceSections заменяет сохраненные части текста песни
+func (s *songRepositoryImpl) ReplaceSections(ctx context.Context, songID uint, sections []models.LyricsSection) error {
+	const op = "repository.songRepositoryImpl.ReplaceSections"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+	)
+	for i := range sections {
+		sections[i].SongID = songID
+	}
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		err := tx.Where("song_id = ? AND song_id IN (?)", songID, tenantSongIDs(ctx, tx)).
+			Delete(&models.LyricsSection{}).Error
+		if err != nil || len(sections) == 0 {
+			return err
+		}
+		return tx.Create(&sections).Error
+	})
+	if err != nil {
+		log.Warn("failed to replace song sections", slog.String("err", err.Error()))
+		return err
+	}
+	log.Debug("song sections successfully replaced", slog.Int("count", len(sections)))
+	return nil
+}
+
+func (s *songRepositoryImpl) GetSections(ctx context.Context, songID int) ([]models.LyricsSection, error) {
+	const op = "repository.songRepositoryImpl.GetSections"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+	)
+	var sections []models.LyricsSection
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tx.Where("song_id = ? AND song_id IN (?)", songID, tenantSongIDs(ctx, tx)).
+			Order("position").Find(&sections).Error
+	})
+	if err != nil {
+		log.Warn("failed to get song sections", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return sections, nil
+}
+
+// ReplaceSyncedLines заменяет синхронизированный текст песни
+func (s *songRepositoryImpl) ReplaceSyncedLines(ctx context.Context, songID uint, lines []models.SyncedLine) error {
+	const op = "repository.songRepositoryImpl.ReplaceSyncedLines"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+	)
+	for i := range lines {
+		lines[i].SongID = songID
+	}
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		err := tx.Where("song_id = ? AND song_id IN (?)", songID, tenantSongIDs(ctx, tx)).
+			Delete(&models.SyncedLine{}).Error
+		if err != nil || len(lines) == 0 {
+			return err
+		}
+		return tx.Create(&lines).Error
+	})
+	if err != nil {
+		log.Warn("failed to replace synced lines", slog.String("err", err.Error()))
+		return err
+	}
+	log.Info("synced lyrics successfully saved", slog.Int("count", len(lines)))
+	return nil
+}
+
+func (s *songRepositoryImpl) GetSyncedLines(ctx context.Context, songID int) ([]models.SyncedLine, error) {
+	const op = "repository.songRepositoryImpl.GetSyncedLines"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+	)
+	var lines []models.SyncedLine
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tx.Where("song_id = ? AND song_id IN (?)", songID, tenantSongIDs(ctx, tx)).
+			Order("position").Find(&lines).Error
+	})
+	if err != nil {
+		log.Warn("failed to get synced lines", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return lines, nil
+}
+
+// SaveChordSheet создает или заменяет текст песни с аккордами
+func (s *songRepositoryImpl) SaveChordSheet(ctx context.Context, sheet *models.ChordSheet) error {
+	const op = "repository.songRepositoryImpl.SaveChordSheet"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", sheet.SongID),
+	)
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "song_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"source", "updated_at"}),
+		}).Create(sheet).Error
+	})
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		log.Debug("song not found")
+		return fmt.Errorf("song %d: %w", sheet.SongID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to save chord sheet", slog.String("err", err.Error()))
+		return err
+	}
+	log.Info("chord sheet successfully saved")
+	return nil
+}
+
+func (s *songRepositoryImpl) GetChordSheet(ctx context.Context, songID int) (*models.ChordSheet, error) {
+	const op = "repository.songRepositoryImpl.GetChordSheet"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+	)
+	var sheet models.ChordSheet
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tx.Where("song_id = ? AND song_id IN (?)", songID, tenantSongIDs(ctx, tx)).Take(&sheet).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Debug("chord sheet not found")
+		return nil, fmt.Errorf("chords for song %d: %w", songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to get chord sheet", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &sheet, nil
+}
+
+// chartRatingWeight - сколько прослушиваний в очках чарта стоит каждый балл оценки выше или ниже средней (3)
+const chartRatingWeight = 2
+
+// Chart возвращает limit песен с наибольшим количеством очков за прослушивания и оценки начиная с since.
+// Очки - количество прослушиваний плюс chartRatingWeight * (оценка - 3) за каждую оценку. since, равный nil,
+// означает весь период. Песни без прослушиваний и оценок за период в чарт не попадают
+func (s *songRepositoryImpl) Chart(ctx context.Context, since *time.Time, limit int) ([]models.ChartEntry, error) {
+	const op = "repository.songRepositoryImpl.Chart"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+	var from time.Time
+	if since != nil {
+		from = *since
+	}
+	var entries []models.ChartEntry
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tx.Raw(`
+		WITH plays AS (
+			SELECT song_id, COUNT(*) AS plays FROM song_plays WHERE played_at >= ? GROUP BY song_id
+		), ratings AS (
+			SELECT song_id, COUNT(*) AS ratings, ROUND(AVG(rating), 2) AS average_rating, SUM(rating - 3) AS balance
+			FROM song_ratings WHERE updated_at >= ? GROUP BY song_id
+		)
+		SELECT songs.*, COALESCE(plays.plays, 0) AS plays, COALESCE(ratings.ratings, 0) AS ratings,
+			ratings.average_rating, COALESCE(plays.plays, 0) + ? * COALESCE(ratings.balance, 0) AS score
+		FROM songs
+		LEFT JOIN plays ON plays.song_id = songs.id
+		LEFT JOIN ratings ON ratings.song_id = songs.id
+		WHERE songs.tenant_id = ? AND (plays.song_id IS NOT NULL OR ratings.song_id IS NOT NULL)
+		ORDER BY score DESC, plays DESC, songs.id
+		LIMIT ?`,
+			from, from, chartRatingWeight, tenant.FromContext(ctx), limit,
+		).Scan(&entries).Error
+	})
+	if err != nil {
+		log.Warn("failed to get chart", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return entries, nil
+}
+
 func NewRepository(log *slog.Logger, DB *gorm.DB) service.SongRepository {
 	return &songRepositoryImpl{
 		log: log,
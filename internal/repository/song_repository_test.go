@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/service"
+	"testEffectiveMobile/internal/tenant"
+	"testEffectiveMobile/internal/utils/storage/storagetest"
+)
+
+func TestGetSongForUpdateLocksRow(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("set_config").WithArgs("7").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "songs" WHERE .*id = \$\d+.* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text"}).AddRow(3, "Paranoia is in bloom"))
+	mock.ExpectCommit()
+
+	ctx := tenant.WithID(context.Background(), 7)
+	song, err := NewRepository(discardLog, db).GetSongForUpdate(ctx, 3)
+	if err != nil {
+		t.Fatalf("GetSongForUpdate() error = %v", err)
+	}
+	if song.ID != 3 || song.Text != "Paranoia is in bloom" {
+		t.Errorf("song = %+v", song)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetSongForUpdateWaitsForLockHolder(t *testing.T) {
+	db := storagetest.Open(t)
+	ctx := tenant.WithID(context.Background(), defaultTenantID(t, db))
+	songs := NewRepository(discardLog, db)
+	id, err := songs.CreateSong(ctx, &models.Song{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uow := NewUnitOfWork(discardLog, db)
+
+	locked, release := make(chan struct{}), make(chan struct{})
+	holder := make(chan error, 1)
+	go func() {
+		holder <- uow.Do(ctx, func(repos service.Repositories) error {
+			_, err := repos.Songs.GetSongForUpdate(ctx, int(id))
+			close(locked)
+			if err != nil {
+				return err
+			}
+			<-release
+			return repos.Songs.UpdateSong(ctx, &models.Song{ID: id, Text: "The PR transmissions will resume"})
+		})
+	}()
+	<-locked
+
+	waiter := make(chan string, 1)
+	go func() {
+		_ = uow.Do(ctx, func(repos service.Repositories) error {
+			song, err := repos.Songs.GetSongForUpdate(ctx, int(id))
+			if err != nil {
+				waiter <- err.Error()
+				return err
+			}
+			waiter <- song.Text
+			return nil
+		})
+	}()
+	select {
+	case text := <-waiter:
+		t.Fatalf("second lock taken while the first transaction holds it, text %q", text)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-holder; err != nil {
+		t.Fatal(err)
+	}
+	// После снятия блокировки второй транзакции виден текст, сохраненный первой
+	if text := <-waiter; text != "The PR transmissions will resume" {
+		t.Errorf("text after lock = %q, want the committed update", text)
+	}
+}
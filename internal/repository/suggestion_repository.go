@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/service"
+)
+
+type suggestionRepositoryImpl struct {
+	log *slog.Logger
+	DB  *gorm.DB
+}
+
+func (s *suggestionRepositoryImpl) CreateSuggestion(ctx context.Context, suggestion *models.Suggestion) error {
+	const op = "repository.suggestionRepositoryImpl.CreateSuggestion"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", suggestion.SongID),
+	)
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		if err := requireTenantSong(ctx, tx, suggestion.SongID); err != nil {
+			return err
+		}
+		return tx.Create(suggestion).Error
+	})
+	if errors.Is(err, models.ErrNotFound) {
+		log.Debug("song not found")
+		return err
+	}
+	if err != nil {
+		log.Warn("failed to create suggestion", slog.String("err", err.Error()))
+		return err
+	}
+	log.Info("suggestion successfully created", slog.Any("suggestion_id", suggestion.ID))
+	return nil
+}
+
+func (s *suggestionRepositoryImpl) GetSuggestion(ctx context.Context, songID, id int) (*models.Suggestion, error) {
+	const op = "repository.suggestionRepositoryImpl.GetSuggestion"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+		slog.Any("suggestion_id", id),
+	)
+	var suggestion models.Suggestion
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tx.Where("id = ? AND song_id = ? AND song_id IN (?)", id, songID, tenantSongIDs(ctx, tx)).
+			Take(&suggestion).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Debug("suggestion not found")
+		return nil, fmt.Errorf("suggestion %d of song %d: %w", id, songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to get suggestion", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &suggestion, nil
+}
+
+// ListSuggestions возвращает предложения по фильтру, начиная с самых старых
+func (s *suggestionRepositoryImpl) ListSuggestions(ctx context.Context, filter models.SuggestionFilter, offset, limit int) ([]models.Suggestion, error) {
+	const op = "repository.suggestionRepositoryImpl.ListSuggestions"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+	var suggestions []models.Suggestion
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		query := tx.Model(&models.Suggestion{}).Where("song_id IN (?)", tenantSongIDs(ctx, tx))
+		if filter.SongID > 0 {
+			query = query.Where("song_id = ?", filter.SongID)
+		}
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+		return query.Order("id").Limit(limit).Offset(offset).Find(&suggestions).Error
+	})
+	if err != nil {
+		log.Warn("failed to get suggestions", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return suggestions, nil
+}
+
+// ReviewSuggestion сохраняет решение модератора. Решение принимается только для ожидающего предложения,
+// иначе возвращается ошибка с models.ErrConflict
+func (s *suggestionRepositoryImpl) ReviewSuggestion(ctx context.Context, suggestion *models.Suggestion) error {
+	const op = "repository.suggestionRepositoryImpl.ReviewSuggestion"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("suggestion_id", suggestion.ID),
+		slog.String("status", suggestion.Status),
+	)
+	var updated int64
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		tx = tx.Model(&models.Suggestion{}).
+			Where("id = ? AND status = ? AND song_id IN (?)", suggestion.ID, models.SuggestionPending, tenantSongIDs(ctx, tx)).
+			Updates(map[string]any{
+				"status":      suggestion.Status,
+				"reason":      suggestion.Reason,
+				"reviewed_by": suggestion.ReviewedBy,
+				"reviewed_at": suggestion.ReviewedAt,
+			})
+		updated = tx.RowsAffected
+		return tx.Error
+	})
+	if err != nil {
+		log.Warn("failed to review suggestion", slog.String("err", err.Error()))
+		return err
+	}
+	if updated == 0 {
+		log.Debug("suggestion is already reviewed")
+		return fmt.Errorf("suggestion %d is already reviewed: %w", suggestion.ID, models.ErrConflict)
+	}
+	log.Info("suggestion reviewed")
+	return nil
+}
+
+func (s *suggestionRepositoryImpl) CreateRevision(ctx context.Context, revision *models.Revision) error {
+	const op = "repository.suggestionRepositoryImpl.CreateRevision"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", revision.SongID),
+	)
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		if err := requireTenantSong(ctx, tx, revision.SongID); err != nil {
+			return err
+		}
+		return tx.Create(revision).Error
+	})
+	if err != nil {
+		log.Warn("failed to create revision", slog.String("err", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// ListRevisions возвращает версии текста песни, начиная с последней
+func (s *suggestionRepositoryImpl) ListRevisions(ctx context.Context, songID int) ([]models.Revision, error) {
+	const op = "repository.suggestionRepositoryImpl.ListRevisions"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+	)
+	var revisions []models.Revision
+	err := withTenant(ctx, s.DB, func(tx *gorm.DB) error {
+		return tx.Where("song_id = ? AND song_id IN (?)", songID, tenantSongIDs(ctx, tx)).
+			Order("id DESC").Find(&revisions).Error
+	})
+	if err != nil {
+		log.Warn("failed to get revisions", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func NewSuggestionRepository(log *slog.Logger, DB *gorm.DB) service.SuggestionRepository {
+	return &suggestionRepositoryImpl{
+		log: log,
+		DB:  DB,
+	}
+}
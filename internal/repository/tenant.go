@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"strconv"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/tenant"
+)
+
+// withTenant выполняет fn в транзакции, в которой параметр app.tenant_id равен арендатору из ctx, чтобы запросы
+// проходили политики row-level security. Если db уже транзакция (UnitOfWork или внешний withTenant),
+// арендатор в ней задан, и fn выполняется в ней же
+func withTenant(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	db = db.WithContext(ctx)
+	if committer, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok && committer != nil {
+		return fn(db)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := setTenant(ctx, tx); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// setTenant задает арендатора из ctx до конца транзакции tx
+func setTenant(ctx context.Context, tx *gorm.DB) error {
+	id := strconv.FormatUint(uint64(tenant.FromContext(ctx)), 10)
+	return tx.Exec("SELECT set_config('app.tenant_id', ?, true)", id).Error
+}
+
+// tenantSongs возвращает запрос к песням арендатора из ctx. Условие дублирует политику row-level security,
+// чтобы изоляция не зависела только от прав пользователя базы данных
+func tenantSongs(ctx context.Context, tx *gorm.DB) *gorm.DB {
+	return tx.Model(&models.Song{}).Where("songs.tenant_id = ?", tenant.FromContext(ctx))
+}
+
+// tenantSongIDs возвращает подзапрос id песен арендатора из ctx для таблиц, связанных с песнями
+func tenantSongIDs(ctx context.Context, tx *gorm.DB) *gorm.DB {
+	return tx.Session(&gorm.Session{NewDB: true}).Model(&models.Song{}).Select("id").
+		Where("tenant_id = ?", tenant.FromContext(ctx))
+}
+
+// requireTenantSong проверяет, что песня songID принадлежит арендатору из ctx. Внешние ключи проверяются
+// без учета row-level security, поэтому без проверки можно было бы сослаться на песню другого арендатора
+func requireTenantSong(ctx context.Context, tx *gorm.DB, songID uint) error {
+	var id uint
+	err := tenantSongs(ctx, tx).Select("id").Where("id = ?", songID).Take(&id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("song %d: %w", songID, models.ErrNotFound)
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/service"
+)
+
+type tenantRepositoryImpl struct {
+	log *slog.Logger
+	DB  *gorm.DB
+}
+
+func (t *tenantRepositoryImpl) CreateTenant(ctx context.Context, tenant *models.Tenant) error {
+	const op = "repository.tenantRepositoryImpl.CreateTenant"
+	log := t.log.With(
+		slog.String("op", op),
+		slog.String("tenant", tenant.Slug),
+	)
+	err := t.DB.WithContext(ctx).Create(tenant).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		log.Debug("tenant already exists")
+		return fmt.Errorf("tenant %q already exists: %w", tenant.Slug, models.ErrConflict)
+	}
+	if err != nil {
+		log.Warn("failed to create tenant", slog.String("err", err.Error()))
+		return err
+	}
+	log.Info("tenant successfully created", slog.Any("tenant_id", tenant.ID))
+	return nil
+}
+
+func (t *tenantRepositoryImpl) GetTenantBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
+	const op = "repository.tenantRepositoryImpl.GetTenantBySlug"
+	log := t.log.With(
+		slog.String("op", op),
+		slog.String("tenant", slug),
+	)
+	var tenant models.Tenant
+	err := t.DB.WithContext(ctx).Where("slug = ?", slug).Take(&tenant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Debug("tenant not found")
+		return nil, fmt.Errorf("tenant %q: %w", slug, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to get tenant", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (t *tenantRepositoryImpl) ListTenants(ctx context.Context) ([]models.Tenant, error) {
+	const op = "repository.tenantRepositoryImpl.ListTenants"
+	log := t.log.With(
+		slog.String("op", op),
+	)
+	var tenants []models.Tenant
+	if err := t.DB.WithContext(ctx).Order("id").Find(&tenants).Error; err != nil {
+		log.Warn("failed to get tenants", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return tenants, nil
+}
+
+func NewTenantRepository(log *slog.Logger, DB *gorm.DB) service.TenantRepository {
+	return &tenantRepositoryImpl{
+		log: log,
+		DB:  DB,
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/service"
+)
+
+type translationRepositoryImpl struct {
+	log *slog.Logger
+	DB  *gorm.DB
+}
+
+func (t *translationRepositoryImpl) CreateTranslation(ctx context.Context, translation *models.Translation) error {
+	const op = "repository.translationRepositoryImpl.CreateTranslation"
+	log := t.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", translation.SongID),
+		slog.String("language", translation.Language),
+	)
+	err := withTenant(ctx, t.DB, func(tx *gorm.DB) error {
+		if err := requireTenantSong(ctx, tx, translation.SongID); err != nil {
+			return err
+		}
+		return tx.Create(translation).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		log.Debug("translation already exists")
+		return fmt.Errorf("translation of song %d to %q already exists: %w", translation.SongID, translation.Language, models.ErrConflict)
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		log.Debug("song not found")
+		return err
+	}
+	if err != nil {
+		log.Warn("failed to create translation", slog.String("err", err.Error()))
+		return err
+	}
+	log.Info("translation successfully created")
+	return nil
+}
+
+func (t *translationRepositoryImpl) UpdateTranslation(ctx context.Context, translation *models.Translation) error {
+	const op = "repository.translationRepositoryImpl.UpdateTranslation"
+	log := t.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", translation.SongID),
+		slog.String("language", translation.Language),
+	)
+	var updated int64
+	err := withTenant(ctx, t.DB, func(tx *gorm.DB) error {
+		tx = tx.Model(&models.Translation{}).
+			Where("song_id = ? AND language = ? AND song_id IN (?)", translation.SongID, translation.Language, tenantSongIDs(ctx, tx)).
+			Updates(translation)
+		updated = tx.RowsAffected
+		return tx.Error
+	})
+	if err != nil {
+		log.Warn("failed to update translation", slog.String("err", err.Error()))
+		return err
+	}
+	if updated == 0 {
+		log.Debug("translation not found")
+		return fmt.Errorf("translation of song %d to %q: %w", translation.SongID, translation.Language, models.ErrNotFound)
+	}
+	log.Info("translation successfully updated")
+	return nil
+}
+
+func (t *translationRepositoryImpl) GetTranslation(ctx context.Context, songID int, language string) (*models.Translation, error) {
+	const op = "repository.translationRepositoryImpl.GetTranslation"
+	log := t.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+		slog.String("language", language),
+	)
+	var translation models.Translation
+	err := withTenant(ctx, t.DB, func(tx *gorm.DB) error {
+		return tx.Where("song_id = ? AND language = ? AND song_id IN (?)", songID, language, tenantSongIDs(ctx, tx)).
+			Take(&translation).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Debug("translation not found")
+		return nil, fmt.Errorf("translation of song %d to %q: %w", songID, language, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to get translation", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &translation, nil
+}
+
+func (t *translationRepositoryImpl) ListTranslations(ctx context.Context, songID int) ([]models.Translation, error) {
+	const op = "repository.translationRepositoryImpl.ListTranslations"
+	log := t.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+	)
+	var translations []models.Translation
+	err := withTenant(ctx, t.DB, func(tx *gorm.DB) error {
+		return tx.Where("song_id = ? AND song_id IN (?)", songID, tenantSongIDs(ctx, tx)).
+			Order("language").Find(&translations).Error
+	})
+	if err != nil {
+		log.Warn("failed to get translations", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return translations, nil
+}
+
+func NewTranslationRepository(log *slog.Logger, DB *gorm.DB) service.TranslationRepository {
+	return &translationRepositoryImpl{
+		log: log,
+		DB:  DB,
+	}
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"log/slog"
+	"testEffectiveMobile/internal/service"
+)
+
+type unitOfWorkImpl struct {
+	log *slog.Logger
+	DB  *gorm.DB
+}
+
+// Do выполняет fn в одной транзакции gorm с арендатором из ctx. Репозитории, переданные в fn, работают поверх
+// этой транзакции; если fn возвращает ошибку или паникует, транзакция откатывается
+func (u *unitOfWorkImpl) Do(ctx context.Context, fn func(repos service.Repositories) error) error {
+	const op = "repository.unitOfWorkImpl.Do"
+	log := u.log.With(
+		slog.String("op", op),
+	)
+	err := u.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := setTenant(ctx, tx); err != nil {
+			return err
+		}
+		return fn(service.Repositories{
+			Songs:        NewRepository(u.log, tx),
+			Translations: NewTranslationRepository(u.log, tx),
+			Annotations:  NewAnnotationRepository(u.log, tx),
+			Suggestions:  NewSuggestionRepository(u.log, tx),
+			Users:        NewUserRepository(u.log, tx),
+			Audit:        NewAuditRepository(u.log, tx),
+		})
+	})
+	if err != nil {
+		log.Debug("transaction rolled back", slog.String("err", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func NewUnitOfWork(log *slog.Logger, DB *gorm.DB) service.UnitOfWork {
+	return &unitOfWorkImpl{
+		log: log,
+		DB:  DB,
+	}
+}
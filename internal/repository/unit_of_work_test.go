@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/service"
+	"testEffectiveMobile/internal/tenant"
+	"testEffectiveMobile/internal/utils/storage/storagetest"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// createTwoSongs создает в арендаторе из ctx песню и вторым шагом ту же песню еще раз, поэтому второй шаг
+// завершается ошибкой
+func createTwoSongs(ctx context.Context) func(repos service.Repositories) error {
+	return func(repos service.Repositories) error {
+		song := models.Song{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"}
+		if _, err := repos.Songs.CreateSong(ctx, &song); err != nil {
+			return err
+		}
+		again := models.Song{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"}
+		_, err := repos.Songs.CreateSong(ctx, &again)
+		return err
+	}
+}
+
+func TestUnitOfWorkRollsBackOnError(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("insert failed")
+	mock.ExpectBegin()
+	mock.ExpectExec("set_config").WithArgs("7").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "songs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "songs"`).WillReturnError(failure)
+	mock.ExpectRollback()
+
+	ctx := tenant.WithID(context.Background(), 7)
+	err = NewUnitOfWork(discardLog, db).Do(ctx, createTwoSongs(ctx))
+	if !errors.Is(err, failure) {
+		t.Fatalf("Do() error = %v, want %v", err, failure)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnitOfWorkLeavesNoRowsOnError(t *testing.T) {
+	db := storagetest.Open(t)
+	ctx := tenant.WithID(context.Background(), defaultTenantID(t, db))
+
+	err := NewUnitOfWork(discardLog, db).Do(ctx, createTwoSongs(ctx))
+	if !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("Do() error = %v, want %v", err, models.ErrConflict)
+	}
+	if count := countSongs(t, ctx, db); count != 0 {
+		t.Fatalf("songs after rollback = %d, want 0", count)
+	}
+
+	// Песня, созданная успешной единицей работы, видна тем же подсчетом
+	err = NewUnitOfWork(discardLog, db).Do(ctx, func(repos service.Repositories) error {
+		_, err := repos.Songs.CreateSong(ctx, &models.Song{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := countSongs(t, ctx, db); count != 1 {
+		t.Fatalf("songs after commit = %d, want 1", count)
+	}
+}
+
+// countSongs считает песни без условия по арендатору, но с app.tenant_id из ctx, иначе row-level security
+// скрыла бы все строки
+func countSongs(t *testing.T, ctx context.Context, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	err := withTenant(ctx, db, func(tx *gorm.DB) error {
+		return tx.Model(&models.Song{}).Count(&count).Error
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func defaultTenantID(t *testing.T, db *gorm.DB) uint {
+	t.Helper()
+	var id uint
+	if err := db.Table("tenants").Select("id").Where("slug = ?", "default").Take(&id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
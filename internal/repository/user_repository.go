@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/service"
+	"testEffectiveMobile/internal/tenant"
+)
+
+type userRepositoryImpl struct {
+	log *slog.Logger
+	DB  *gorm.DB
+}
+
+func (u *userRepositoryImpl) CreateUser(ctx context.Context, user *models.User) error {
+	const op = "repository.userRepositoryImpl.CreateUser"
+	log := u.log.With(
+		slog.String("op", op),
+	)
+	err := u.DB.WithContext(ctx).Create(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		log.Debug("user already exists")
+		return fmt.Errorf("user with email %q already exists: %w", user.Email, models.ErrConflict)
+	}
+	if err != nil {
+		log.Warn("failed to create user", slog.String("err", err.Error()))
+		return err
+	}
+	log.Info("user successfully created", slog.Any("user_id", user.ID))
+	return nil
+}
+
+func (u *userRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	const op = "repository.userRepositoryImpl.GetUserByEmail"
+	log := u.log.With(
+		slog.String("op", op),
+	)
+	var user models.User
+	err := usersWithTenant(u.DB.WithContext(ctx)).Where("users.email = ?", email).Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("user %q: %w", email, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to get user", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (u *userRepositoryImpl) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	const op = "repository.userRepositoryImpl.GetUserByID"
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Any("user_id", id),
+	)
+	var user models.User
+	err := usersWithTenant(u.DB.WithContext(ctx)).Where("users.id = ?", id).Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("user %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to get user", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &user, nil
+}
+
+// AddFavorite добавляет песню в избранное. Если песня уже там, запись не меняется
+func (u *userRepositoryImpl) AddFavorite(ctx context.Context, favorite *models.Favorite) error {
+	const op = "repository.userRepositoryImpl.AddFavorite"
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Any("user_id", favorite.UserID),
+		slog.Any("song_id", favorite.SongID),
+	)
+	err := withTenant(ctx, u.DB, func(tx *gorm.DB) error {
+		if err := lockSong(ctx, tx, favorite.SongID); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(favorite).Error
+	})
+	if errors.Is(err, models.ErrNotFound) {
+		log.Debug("song not found")
+		return err
+	}
+	if err != nil {
+		log.Warn("failed to add favorite", slog.String("err", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (u *userRepositoryImpl) RemoveFavorite(ctx context.Context, userID, songID uint) error {
+	const op = "repository.userRepositoryImpl.RemoveFavorite"
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Any("user_id", userID),
+		slog.Any("song_id", songID),
+	)
+	var deleted int64
+	err := withTenant(ctx, u.DB, func(tx *gorm.DB) error {
+		tx = tx.Where("user_id = ? AND song_id = ? AND song_id IN (?)", userID, songID, tenantSongIDs(ctx, tx)).
+			Delete(&models.Favorite{})
+		deleted = tx.RowsAffected
+		return tx.Error
+	})
+	if err != nil {
+		log.Warn("failed to remove favorite", slog.String("err", err.Error()))
+		return err
+	}
+	if deleted == 0 {
+		log.Debug("favorite not found")
+		return fmt.Errorf("song %d in favorites: %w", songID, models.ErrNotFound)
+	}
+	return nil
+}
+
+func (u *userRepositoryImpl) ListFavorites(ctx context.Context, userID uint, offset, limit int) ([]models.FavoriteSong, error) {
+	const op = "repository.userRepositoryImpl.ListFavorites"
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Any("user_id", userID),
+	)
+	var favorites []models.FavoriteSong
+	err := withTenant(ctx, u.DB, func(tx *gorm.DB) error {
+		return tx.Table("user_favorites").
+			Select("songs.*, user_favorites.created_at AS added_at").
+			Joins("JOIN songs ON songs.id = user_favorites.song_id").
+			Where("user_favorites.user_id = ? AND songs.tenant_id = ?", userID, tenant.FromContext(ctx)).
+			Order("user_favorites.created_at DESC, songs.id").
+			Limit(limit).Offset(offset).
+			Find(&favorites).Error
+	})
+	if err != nil {
+		log.Warn("failed to get favorites", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return favorites, nil
+}
+
+// AddPlay записывает прослушивание и увеличивает счетчик прослушиваний песни
+func (u *userRepositoryImpl) AddPlay(ctx context.Context, play *models.Play) error {
+	const op = "repository.userRepositoryImpl.AddPlay"
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Any("user_id", play.UserID),
+		slog.Any("song_id", play.SongID),
+	)
+	err := withTenant(ctx, u.DB, func(tx *gorm.DB) error {
+		updated := tenantSongs(ctx, tx).Where("id = ?", play.SongID).
+			UpdateColumn("play_count", gorm.Expr("play_count + 1"))
+		if updated.Error != nil {
+			return updated.Error
+		}
+		if updated.RowsAffected == 0 {
+			return fmt.Errorf("song %d: %w", play.SongID, models.ErrNotFound)
+		}
+		return tx.Create(play).Error
+	})
+	if errors.Is(err, models.ErrNotFound) {
+		log.Debug("song not found")
+		return err
+	}
+	if err != nil {
+		log.Warn("failed to record play", slog.String("err", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (u *userRepositoryImpl) ListHistory(ctx context.Context, userID uint, offset, limit int) ([]models.HistoryEntry, error) {
+	const op = "repository.userRepositoryImpl.ListHistory"
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Any("user_id", userID),
+	)
+	var history []models.HistoryEntry
+	err := withTenant(ctx, u.DB, func(tx *gorm.DB) error {
+		return tx.Table("song_plays").
+			Select("songs.*, song_plays.played_at").
+			Joins("JOIN songs ON songs.id = song_plays.song_id").
+			Where("song_plays.user_id = ? AND songs.tenant_id = ?", userID, tenant.FromContext(ctx)).
+			Order("song_plays.played_at DESC, song_plays.id DESC").
+			Limit(limit).Offset(offset).
+			Find(&history).Error
+	})
+	if err != nil {
+		log.Warn("failed to get listening history", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetRating возвращает оценку песни пользователем
+func (u *userRepositoryImpl) GetRating(ctx context.Context, userID, songID uint) (*models.Rating, error) {
+	const op = "repository.userRepositoryImpl.GetRating"
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Any("user_id", userID),
+		slog.Any("song_id", songID),
+	)
+	var rating models.Rating
+	err := withTenant(ctx, u.DB, func(tx *gorm.DB) error {
+		return tx.Where("user_id = ? AND song_id = ? AND song_id IN (?)", userID, songID, tenantSongIDs(ctx, tx)).
+			Take(&rating).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("rating of song %d: %w", songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Warn("failed to get rating", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return &rating, nil
+}
+
+// SetRating сохраняет оценку пользователя и обновляет количество и сумму оценок песни. Строка песни
+// блокируется, чтобы одновременные оценки не потеряли изменения счетчиков
+func (u *userRepositoryImpl) SetRating(ctx context.Context, rating *models.Rating) (*models.SongRating, error) {
+	const op = "repository.userRepositoryImpl.SetRating"
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Any("user_id", rating.UserID),
+		slog.Any("song_id", rating.SongID),
+	)
+	var result *models.SongRating
+	err := withTenant(ctx, u.DB, func(tx *gorm.DB) error {
+		if err := lockSong(ctx, tx, rating.SongID); err != nil {
+			return err
+		}
+		var previous models.Rating
+		err := tx.Where("user_id = ? AND song_id = ?", rating.UserID, rating.SongID).Take(&previous).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(rating).Error; err != nil {
+				return err
+			}
+			err = updateRatingTotals(tx, rating.SongID, 1, rating.Rating)
+		case err != nil:
+			return err
+		default:
+			if err := tx.Model(&previous).Update("rating", rating.Rating).Error; err != nil {
+				return err
+			}
+			err = updateRatingTotals(tx, rating.SongID, 0, rating.Rating-previous.Rating)
+		}
+		if err != nil {
+			return err
+		}
+		result, err = songRating(tx, rating.SongID)
+		if result != nil {
+			result.Rating = rating.Rating
+		}
+		return err
+	})
+	if errors.Is(err, models.ErrNotFound) {
+		log.Debug("song not found")
+		return nil, err
+	}
+	if err != nil {
+		log.Warn("failed to save rating", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteRating удаляет оценку пользователя и вычитает ее из количества и суммы оценок песни
+func (u *userRepositoryImpl) DeleteRating(ctx context.Context, userID, songID uint) (*models.SongRating, error) {
+	const op = "repository.userRepositoryImpl.DeleteRating"
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Any("user_id", userID),
+		slog.Any("song_id", songID),
+	)
+	var result *models.SongRating
+	err := withTenant(ctx, u.DB, func(tx *gorm.DB) error {
+		if err := lockSong(ctx, tx, songID); err != nil {
+			return err
+		}
+		var previous models.Rating
+		err := tx.Where("user_id = ? AND song_id = ?", userID, songID).Take(&previous).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("rating of song %d: %w", songID, models.ErrNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND song_id = ?", userID, songID).Delete(&models.Rating{}).Error; err != nil {
+			return err
+		}
+		if err := updateRatingTotals(tx, songID, -1, -previous.Rating); err != nil {
+			return err
+		}
+		result, err = songRating(tx, songID)
+		return err
+	})
+	if errors.Is(err, models.ErrNotFound) {
+		log.Debug("rating not found")
+		return nil, err
+	}
+	if err != nil {
+		log.Warn("failed to delete rating", slog.String("err", err.Error()))
+		return nil, err
+	}
+	return result, nil
+}
+
+// usersWithTenant возвращает запрос к пользователям вместе с кодом их арендатора
+func usersWithTenant(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.User{}).Select("users.*, tenants.slug AS tenant").
+		Joins("JOIN tenants ON tenants.id = users.tenant_id")
+}
+
+// lockSong блокирует строку песни арендатора из ctx до конца транзакции
+func lockSong(ctx context.Context, tx *gorm.DB, songID uint) error {
+	var id uint
+	err := tenantSongs(ctx, tx).Select("id").Where("id = ?", songID).
+		Clauses(clause.Locking{Strength: "UPDATE"}).Take(&id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("song %d: %w", songID, models.ErrNotFound)
+	}
+	return err
+}
+
+// updateRatingTotals изменяет количество и сумму оценок песни на count и sum
+func updateRatingTotals(tx *gorm.DB, songID uint, count, sum int) error {
+	return tx.Table("songs").Where("id = ?", songID).UpdateColumns(map[string]any{
+		"rating_count": gorm.Expr("rating_count + ?", count),
+		"rating_sum":   gorm.Expr("rating_sum + ?", sum),
+	}).Error
+}
+
+// songRating читает итоговый рейтинг песни
+func songRating(tx *gorm.DB, songID uint) (*models.SongRating, error) {
+	var song models.Song
+	if err := tx.Select("id", "rating_count", "rating_average").Take(&song, songID).Error; err != nil {
+		return nil, err
+	}
+	return &models.SongRating{SongID: song.ID, RatingCount: song.RatingCount, RatingAverage: song.RatingAverage}, nil
+}
+
+func NewUserRepository(log *slog.Logger, DB *gorm.DB) service.UserRepository {
+	return &userRepositoryImpl{
+		log: log,
+		DB:  DB,
+	}
+}
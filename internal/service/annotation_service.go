@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/lyrics"
+	"unicode/utf8"
+)
+
+type AnnotationRepository interface {
+	CreateAnnotation(ctx context.Context, annotation *models.Annotation) error
+	GetAnnotation(ctx context.Context, songID, id int) (*models.Annotation, error)
+	ListAnnotations(ctx context.Context, songID int) ([]models.Annotation, error)
+	SaveAnnotation(ctx context.Context, annotation *models.Annotation) error
+	DeleteAnnotation(ctx context.Context, songID, id int) error
+}
+
+// CreateAnnotation привязывает аннотацию к фрагменту текущего текста песни
+func (s *SongService) CreateAnnotation(ctx context.Context, songID int, annotation *models.Annotation) error {
+	song, err := s.songRepository.GetSongByID(ctx, songID)
+	if err != nil {
+		return err
+	}
+	if err := anchor(lyrics.Verses(song.Text), annotation); err != nil {
+		return err
+	}
+	annotation.SongID = song.ID
+	return s.uow.Do(ctx, func(repos Repositories) error {
+		if err := repos.Annotations.CreateAnnotation(ctx, annotation); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditCreate, models.AuditResourceAnnotation, annotation.ID, nil, annotation)
+	})
+}
+
+// UpdateAnnotation изменяет текст и положение аннотации
+func (s *SongService) UpdateAnnotation(ctx context.Context, songID int, annotation *models.Annotation) error {
+	return s.uow.Do(ctx, func(repos Repositories) error {
+		existing, err := repos.Annotations.GetAnnotation(ctx, songID, int(annotation.ID))
+		if err != nil {
+			return err
+		}
+		song, err := repos.Songs.GetSongByID(ctx, songID)
+		if err != nil {
+			return err
+		}
+		if err := anchor(lyrics.Verses(song.Text), annotation); err != nil {
+			return err
+		}
+		annotation.SongID = song.ID
+		annotation.CreatedAt = existing.CreatedAt
+		annotation.Orphaned = false
+		if err := repos.Annotations.SaveAnnotation(ctx, annotation); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditUpdate, models.AuditResourceAnnotation, annotation.ID, existing, annotation)
+	})
+}
+
+func (s *SongService) GetAnnotation(ctx context.Context, songID, id int) (*models.Annotation, error) {
+	return s.annotationRepository.GetAnnotation(ctx, songID, id)
+}
+
+func (s *SongService) ListAnnotations(ctx context.Context, songID int) ([]models.Annotation, error) {
+	if _, err := s.songRepository.GetSongByID(ctx, songID); err != nil {
+		return nil, err
+	}
+	annotations, err := s.annotationRepository.ListAnnotations(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	if annotations == nil {
+		annotations = []models.Annotation{}
+	}
+	return annotations, nil
+}
+
+func (s *SongService) DeleteAnnotation(ctx context.Context, songID, id int) error {
+	return s.uow.Do(ctx, func(repos Repositories) error {
+		annotation, err := repos.Annotations.GetAnnotation(ctx, songID, id)
+		if err != nil {
+			return err
+		}
+		if err := repos.Annotations.DeleteAnnotation(ctx, songID, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditDelete, models.AuditResourceAnnotation, id, annotation, nil)
+	})
+}
+
+// GetAnnotatedLyrics возвращает куплеты песни, в строках которых отмечены аннотированные фрагменты
+func (s *SongService) GetAnnotatedLyrics(ctx context.Context, songID int) (*models.AnnotatedLyrics, error) {
+	song, err := s.songRepository.GetSongByID(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := s.annotationRepository.ListAnnotations(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	if annotations == nil {
+		annotations = []models.Annotation{}
+	}
+	result := &models.AnnotatedLyrics{SongID: song.ID, Verses: []models.AnnotatedVerse{}, Annotations: annotations}
+	for i, verse := range lyrics.Verses(song.Text) {
+		annotatedVerse := models.AnnotatedVerse{Number: i + 1}
+		for j, line := range strings.Split(verse, "\n") {
+			var onLine []models.Annotation
+			for _, annotation := range annotations {
+				if !annotation.Orphaned && annotation.Verse == i+1 && annotation.Line == j+1 {
+					onLine = append(onLine, annotation)
+				}
+			}
+			annotatedVerse.Lines = append(annotatedVerse.Lines, markLine(j+1, line, onLine))
+		}
+		result.Verses = append(result.Verses, annotatedVerse)
+	}
+	return result, nil
+}
+
+// reanchorAnnotations переносит аннотации песни на новый текст. Аннотация остается на месте, если ее фрагмент
+// не изменился, иначе переносится на ближайшее вхождение цитаты. Если цитата не найдена, аннотация помечается
+// как orphaned и не отображается в тексте, пока ее не привяжут заново
+func (s *SongService) reanchorAnnotations(ctx context.Context, repos Repositories, songID uint, text string) error {
+	const op = "service.SongService.reanchorAnnotations"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", songID),
+	)
+	annotations, err := repos.Annotations.ListAnnotations(ctx, int(songID))
+	if err != nil {
+		return err
+	}
+	lines := flattenLines(lyrics.Verses(text))
+	for i := range annotations {
+		if !reanchor(lines, &annotations[i]) {
+			continue
+		}
+		if annotations[i].Orphaned {
+			log.Debug("annotation orphaned", slog.Any("annotation_id", annotations[i].ID))
+		}
+		if err := repos.Annotations.SaveAnnotation(ctx, &annotations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textLine - строка текста песни с координатами
+type textLine struct {
+	verse, line int
+	text        string
+}
+
+func flattenLines(verses []string) []textLine {
+	var lines []textLine
+	for i, verse := range verses {
+		for j, line := range strings.Split(verse, "\n") {
+			lines = append(lines, textLine{verse: i + 1, line: j + 1, text: line})
+		}
+	}
+	return lines
+}
+
+// anchor проверяет координаты аннотации и заполняет цитату. start = end = 0 означает всю строку
+func anchor(verses []string, annotation *models.Annotation) error {
+	if annotation.Verse < 1 || annotation.Verse > len(verses) {
+		return models.NewValidationError("verse", fmt.Sprintf("must be between 1 and %d", len(verses)))
+	}
+	lines := strings.Split(verses[annotation.Verse-1], "\n")
+	if annotation.Line < 1 || annotation.Line > len(lines) {
+		return models.NewValidationError("line", fmt.Sprintf("must be between 1 and %d", len(lines)))
+	}
+	runes := []rune(lines[annotation.Line-1])
+	if annotation.Start == 0 && annotation.End == 0 {
+		annotation.End = len(runes)
+	}
+	if annotation.Start < 0 || annotation.Start >= len(runes) {
+		return models.NewValidationError("start", fmt.Sprintf("must be between 0 and %d", len(runes)-1))
+	}
+	if annotation.End <= annotation.Start || annotation.End > len(runes) {
+		return models.NewValidationError("end", fmt.Sprintf("must be between %d and %d", annotation.Start+1, len(runes)))
+	}
+	annotation.Quote = string(runes[annotation.Start:annotation.End])
+	return nil
+}
+
+// reanchor ищет цитату аннотации в новом тексте и возвращает true, если аннотацию нужно сохранить
+func reanchor(lines []textLine, annotation *models.Annotation) bool {
+	oldIndex := len(lines) - 1
+	for i, line := range lines {
+		if line.verse == annotation.Verse && line.line == annotation.Line {
+			oldIndex = i
+			runes := []rune(line.text)
+			if annotation.End <= len(runes) && string(runes[annotation.Start:annotation.End]) == annotation.Quote {
+				// Фрагмент на прежнем месте
+				wasOrphaned := annotation.Orphaned
+				annotation.Orphaned = false
+				return wasOrphaned
+			}
+			break
+		}
+	}
+
+	bestIndex, bestStart, bestScore := -1, 0, 0
+	for i, line := range lines {
+		for offset := 0; ; {
+			found := strings.Index(line.text[offset:], annotation.Quote)
+			if found < 0 || annotation.Quote == "" {
+				break
+			}
+			start := utf8.RuneCountInString(line.text[:offset+found])
+			// Сначала важна близость строки, затем близость позиции в строке
+			score := abs(i-oldIndex)*1000 + abs(start-annotation.Start)
+			if bestIndex < 0 || score < bestScore {
+				bestIndex, bestStart, bestScore = i, start, score
+			}
+			offset += found + 1
+		}
+	}
+	if bestIndex < 0 {
+		wasOrphaned := annotation.Orphaned
+		annotation.Orphaned = true
+		return !wasOrphaned
+	}
+	annotation.Verse = lines[bestIndex].verse
+	annotation.Line = lines[bestIndex].line
+	annotation.End = bestStart + utf8.RuneCountInString(annotation.Quote)
+	annotation.Start = bestStart
+	annotation.Orphaned = false
+	return true
+}
+
+// markLine делит строку на фрагменты по границам аннотаций
+func markLine(number int, line string, annotations []models.Annotation) models.AnnotatedLine {
+	runes := []rune(line)
+	bounds := []int{0, len(runes)}
+	for _, annotation := range annotations {
+		bounds = append(bounds, min(annotation.Start, len(runes)), min(annotation.End, len(runes)))
+	}
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+
+	result := models.AnnotatedLine{Number: number, Text: line, Segments: []models.AnnotatedSegment{}}
+	var marked strings.Builder
+	for i := 0; i+1 < len(bounds); i++ {
+		segment := models.AnnotatedSegment{Text: string(runes[bounds[i]:bounds[i+1]])}
+		for _, annotation := range annotations {
+			if annotation.Start <= bounds[i] && bounds[i+1] <= annotation.End {
+				segment.AnnotationIDs = append(segment.AnnotationIDs, annotation.ID)
+			}
+		}
+		result.Segments = append(result.Segments, segment)
+		if len(segment.AnnotationIDs) == 0 {
+			marked.WriteString(segment.Text)
+			continue
+		}
+		marked.WriteString("[" + segment.Text + "]")
+		for _, id := range segment.AnnotationIDs {
+			fmt.Fprintf(&marked, "[^%d]", id)
+		}
+	}
+	result.Marked = marked.String()
+	return result
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
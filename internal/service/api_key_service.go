@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testEffectiveMobile/internal/controller"
+	"testEffectiveMobile/internal/models"
+	"time"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	GetActiveAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id uint, usedAt time.Time) error
+}
+
+const (
+	// apiKeyPrefix отличает ключи сервиса от других секретов, например при поиске утечек
+	apiKeyPrefix = "sk_"
+	// apiKeyBytes - количество случайных байт ключа
+	apiKeyBytes = 32
+	// apiKeyDisplayLength - длина начала ключа, которое хранится открыто, чтобы ключ можно было узнать в списке
+	apiKeyDisplayLength = 10
+	// adminSubject - субъект ключа администратора из конфигурации
+	adminSubject = "admin"
+)
+
+type APIKeyService struct {
+	log              *slog.Logger
+	apiKeyRepository APIKeyRepository
+	auditRepository  AuditRepository
+	// adminKey - ключ из конфигурации для первоначальной настройки, сравнивается напрямую
+	adminKey string
+}
+
+func NewAPIKeyService(repository APIKeyRepository, audit AuditRepository, log *slog.Logger, adminKey string) controller.APIKeyService {
+	return &APIKeyService{
+		log:              log,
+		apiKeyRepository: repository,
+		auditRepository:  audit,
+		adminKey:         adminKey,
+	}
+}
+
+// CreateAPIKey создает случайный ключ с ролью role (по умолчанию reader) и сохраняет его хэш. Ключ с непустым
+// tenant дает доступ только к библиотеке этого арендатора. Значение ключа возвращается только здесь
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, name, role, tenant string) (*models.CreatedAPIKey, error) {
+	if role == "" {
+		role = models.RoleReader
+	}
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate api key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	apiKey := models.APIKey{
+		Name:   name,
+		Prefix: key[:apiKeyDisplayLength],
+		Role:   role,
+		Hash:   hashAPIKey(key),
+	}
+	if tenant != "" {
+		apiKey.Tenant = &tenant
+	}
+	if err := s.apiKeyRepository.CreateAPIKey(ctx, &apiKey); err != nil {
+		return nil, err
+	}
+	recordCommittedAudit(ctx, s.log, s.auditRepository, models.AuditCreate, models.AuditResourceAPIKey, apiKey.ID, nil, apiKey)
+	return &models.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.apiKeyRepository.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ. В журнале аудита отзыв записывается как удаление ключа
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	key, err := s.apiKeyRepository.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	before := *key
+	before.RevokedAt = nil
+	recordCommittedAudit(ctx, s.log, s.auditRepository, models.AuditDelete, models.AuditResourceAPIKey, key.ID, before, key)
+	return nil
+}
+
+// VerifyAPIKey возвращает клиента, которому выдан ключ. Для неизвестного или отозванного ключа
+// возвращает ошибку с models.ErrUnauthorized
+func (s *APIKeyService) VerifyAPIKey(ctx context.Context, key string) (*models.Principal, error) {
+	const op = "service.APIKeyService.VerifyAPIKey"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+	if s.adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.adminKey)) == 1 {
+		return &models.Principal{Subject: adminSubject, Method: models.AuthMethodAPIKey, Roles: []string{models.RoleAdmin}}, nil
+	}
+	apiKey, err := s.apiKeyRepository.GetActiveAPIKeyByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, fmt.Errorf("invalid api key: %w", models.ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Время последнего использования справочное, поэтому ошибка его сохранения не отклоняет запрос
+	if err := s.apiKeyRepository.TouchAPIKey(ctx, apiKey.ID, time.Now()); err != nil {
+		log.Warn("failed to update api key usage time", slog.String("err", err.Error()))
+	}
+	return &models.Principal{
+		Subject: fmt.Sprintf("api-key:%d", apiKey.ID),
+		Name:    apiKey.Name,
+		Method:  models.AuthMethodAPIKey,
+		Roles:   []string{apiKey.Role},
+		Tenant:  tenantSlug(apiKey.Tenant),
+	}, nil
+}
+
+// tenantSlug возвращает код арендатора ключа или пустую строку, если ключ не привязан к арендатору
+func tenantSlug(tenant *string) string {
+	if tenant == nil {
+		return ""
+	}
+	return *tenant
+}
+
+// hashAPIKey возвращает SHA-256 хэш ключа. Ключи случайные и длинные, поэтому медленное хэширование
+// с солью, как для паролей, не требуется, а поиск по хэшу остается точным
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
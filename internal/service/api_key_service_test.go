@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testing"
+	"time"
+)
+
+// memoryAPIKeys хранит ключи в памяти и, как репозиторий, не находит отозванные ключи по хэшу
+type memoryAPIKeys struct {
+	keys []models.APIKey
+}
+
+func (r *memoryAPIKeys) CreateAPIKey(_ context.Context, key *models.APIKey) error {
+	key.ID = uint(len(r.keys) + 1)
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *memoryAPIKeys) ListAPIKeys(_ context.Context) ([]models.APIKey, error) {
+	return r.keys, nil
+}
+
+func (r *memoryAPIKeys) GetActiveAPIKeyByHash(_ context.Context, hash string) (*models.APIKey, error) {
+	for _, key := range r.keys {
+		if key.Hash == hash && key.RevokedAt == nil {
+			return &key, nil
+		}
+	}
+	return nil, models.ErrNotFound
+}
+
+func (r *memoryAPIKeys) RevokeAPIKey(_ context.Context, id int) (*models.APIKey, error) {
+	if id < 1 || id > len(r.keys) {
+		return nil, models.ErrNotFound
+	}
+	now := time.Now()
+	r.keys[id-1].RevokedAt = &now
+	key := r.keys[id-1]
+	return &key, nil
+}
+
+func (r *memoryAPIKeys) TouchAPIKey(_ context.Context, id uint, usedAt time.Time) error {
+	r.keys[id-1].LastUsedAt = &usedAt
+	return nil
+}
+
+func TestVerifyAPIKey(t *testing.T) {
+	keys := &memoryAPIKeys{}
+	service := NewAPIKeyService(keys, &memoryAudit{}, slog.New(slog.NewTextHandler(io.Discard, nil)), "admin-secret")
+	ctx := context.Background()
+
+	created, err := service.CreateAPIKey(ctx, "ci", models.RoleEditor, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.keys[0].Hash == created.Key || keys.keys[0].Hash != hashAPIKey(created.Key) {
+		t.Errorf("stored hash = %q, want SHA-256 of the key", keys.keys[0].Hash)
+	}
+	principal, err := service.VerifyAPIKey(ctx, created.Key)
+	if err != nil {
+		t.Fatalf("VerifyAPIKey() error = %v", err)
+	}
+	if principal.Subject != "api-key:1" || principal.Tenant != "acme" || principal.Roles[0] != models.RoleEditor {
+		t.Errorf("principal = %+v", principal)
+	}
+	if keys.keys[0].LastUsedAt == nil {
+		t.Error("last usage time is not updated")
+	}
+
+	if err := service.RevokeAPIKey(ctx, int(created.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.VerifyAPIKey(ctx, created.Key); !errors.Is(err, models.ErrUnauthorized) {
+		t.Errorf("revoked key: VerifyAPIKey() error = %v, want %v", err, models.ErrUnauthorized)
+	}
+}
+
+func TestVerifyAdminKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		adminKey string
+		key      string
+		admin    bool
+	}{
+		{name: "admin key", adminKey: "admin-secret", key: "admin-secret", admin: true},
+		{name: "prefix of admin key", adminKey: "admin-secret", key: "admin-secre"},
+		{name: "admin key with suffix", adminKey: "admin-secret", key: "admin-secret2"},
+		{name: "different case", adminKey: "admin-secret", key: "ADMIN-SECRET"},
+		// Без ключа администратора в конфигурации пустой ключ не должен давать доступ
+		{name: "empty admin key", adminKey: "", key: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewAPIKeyService(&memoryAPIKeys{}, &memoryAudit{}, slog.New(slog.NewTextHandler(io.Discard, nil)),
+				tt.adminKey)
+			principal, err := service.VerifyAPIKey(context.Background(), tt.key)
+			if !tt.admin {
+				if !errors.Is(err, models.ErrUnauthorized) {
+					t.Errorf("VerifyAPIKey() = %+v, %v, want %v", principal, err, models.ErrUnauthorized)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyAPIKey() error = %v", err)
+			}
+			if principal.Subject != adminSubject || principal.Tenant != "" || len(principal.Roles) != 1 ||
+				principal.Roles[0] != models.RoleAdmin {
+				t.Errorf("principal = %+v", principal)
+			}
+		})
+	}
+}
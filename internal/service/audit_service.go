@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testEffectiveMobile/internal/controller"
+	"testEffectiveMobile/internal/models"
+)
+
+type AuditRepository interface {
+	CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+	ListAuditEntries(ctx context.Context, filter models.AuditFilter, offset, limit int) ([]models.AuditEntry, error)
+}
+
+type AuditService struct {
+	log             *slog.Logger
+	auditRepository AuditRepository
+}
+
+func NewAuditService(repository AuditRepository, log *slog.Logger) controller.AuditService {
+	return &AuditService{
+		log:             log,
+		auditRepository: repository,
+	}
+}
+
+// ListAuditEntries возвращает страницу журнала аудита, начиная с последних записей
+func (s *AuditService) ListAuditEntries(ctx context.Context, filter models.AuditFilter, page, pageSize int) ([]models.AuditEntry, error) {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, models.NewValidationError("to", "must be later than from")
+	}
+	entries, err := s.auditRepository.ListAuditEntries(ctx, filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+	return entries, nil
+}
+
+// recordAudit записывает изменение ресурса в журнал аудита. before и after сохраняются в том виде,
+// в котором ресурс отдается API, поэтому поля со скрытыми данными (хэши паролей и ключей) в журнал не попадают.
+// nil означает, что ресурса до или после изменения нет
+func recordAudit(ctx context.Context, repository AuditRepository, action, resource string, resourceID any, before, after any) error {
+	source := controller.AuditSourceFromContext(ctx)
+	entry := models.AuditEntry{
+		Actor:      source.Actor,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: fmt.Sprint(resourceID),
+		RequestID:  source.RequestID,
+		ClientIP:   source.ClientIP,
+	}
+	var err error
+	if entry.Before, err = auditSnapshot(before); err != nil {
+		return err
+	}
+	if entry.After, err = auditSnapshot(after); err != nil {
+		return err
+	}
+	return repository.CreateAuditEntry(ctx, &entry)
+}
+
+// recordCommittedAudit записывает в журнал изменение, которое уже сохранено вне транзакции. Отменить его
+// нельзя, поэтому ошибка записи в журнал не возвращается клиенту, а логируется
+func recordCommittedAudit(ctx context.Context, log *slog.Logger, repository AuditRepository, action, resource string, resourceID any, before, after any) {
+	if err := recordAudit(ctx, repository, action, resource, resourceID, before, after); err != nil {
+		log.Error("failed to write audit entry",
+			slog.String("action", action),
+			slog.String("resource", resource),
+			slog.Any("resource_id", resourceID),
+			slog.String("err", err.Error()),
+		)
+	}
+}
+
+func auditSnapshot(state any) (json.RawMessage, error) {
+	snapshot, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("audit snapshot: %w", err)
+	}
+	if string(snapshot) == "null" {
+		return nil, nil
+	}
+	return snapshot, nil
+}
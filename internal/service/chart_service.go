@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"testEffectiveMobile/internal/models"
+	"time"
+)
+
+// chartPeriods сопоставляет периоду чарта его длительность. Период all не ограничен
+var chartPeriods = map[string]time.Duration{
+	models.ChartPeriodDay:   24 * time.Hour,
+	models.ChartPeriodWeek:  7 * 24 * time.Hour,
+	models.ChartPeriodMonth: 30 * 24 * time.Hour,
+	models.ChartPeriodYear:  365 * 24 * time.Hour,
+}
+
+// Chart возвращает limit самых популярных песен за период по прослушиваниям и оценкам пользователей
+func (s *SongService) Chart(ctx context.Context, period string, limit int) (*models.Chart, error) {
+	chart := models.Chart{Period: period}
+	if duration, ok := chartPeriods[period]; ok {
+		since := time.Now().Add(-duration).UTC().Truncate(time.Second)
+		chart.Since = &since
+	}
+	entries, err := s.songRepository.Chart(ctx, chart.Since, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].Position = i + 1
+	}
+	chart.Entries = entries
+	if chart.Entries == nil {
+		chart.Entries = []models.ChartEntry{}
+	}
+	return &chart, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/chordpro"
+)
+
+// ImportChords проверяет текст с аккордами в формате ChordPro и сохраняет его для песни
+func (s *SongService) ImportChords(ctx context.Context, id int, source string) (*models.Chords, error) {
+	sheet, err := chordpro.Parse(source)
+	if err != nil {
+		var parseErr *chordpro.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, models.NewValidationError("chordpro", parseErr.Error())
+		}
+		return nil, err
+	}
+	if !hasChords(sheet) {
+		return nil, models.NewValidationError("chordpro", "must contain at least one chord")
+	}
+	err = s.uow.Do(ctx, func(repos Repositories) error {
+		song, err := repos.Songs.GetSongByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		action := models.AuditUpdate
+		before, err := repos.Songs.GetChordSheet(ctx, id)
+		if errors.Is(err, models.ErrNotFound) {
+			action = models.AuditCreate
+		} else if err != nil {
+			return err
+		}
+		after := &models.ChordSheet{SongID: song.ID, Source: source}
+		if err := repos.Songs.SaveChordSheet(ctx, after); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, action, models.AuditResourceChords, song.ID, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sheet.SongID = uint(id)
+	return sheet, nil
+}
+
+// GetChords возвращает текст песни с аккордами, транспонированными на transpose полутонов
+func (s *SongService) GetChords(ctx context.Context, id, transpose int) (*models.Chords, error) {
+	stored, err := s.songRepository.GetChordSheet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := chordpro.Parse(stored.Source)
+	if err != nil {
+		// Сохраненный текст проверяется при загрузке, поэтому ошибка здесь означает поврежденные данные
+		return nil, err
+	}
+	sheet.SongID = stored.SongID
+	chordpro.Transpose(sheet, transpose)
+	return sheet, nil
+}
+
+// RenderChords возвращает текст песни с аккордами над строками в виде обычного текста
+func (s *SongService) RenderChords(ctx context.Context, id, transpose int) (string, error) {
+	sheet, err := s.GetChords(ctx, id, transpose)
+	if err != nil {
+		return "", err
+	}
+	return chordpro.Render(sheet), nil
+}
+
+func hasChords(sheet *models.Chords) bool {
+	for _, line := range sheet.Lines {
+		if len(line.Chords) > 0 {
+			return true
+		}
+	}
+	return false
+}
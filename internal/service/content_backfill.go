@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/tenant"
+)
+
+// contentBackfillBatchSize - количество песен, проверяемых за один проход
+const contentBackfillBatchSize = 200
+
+// ContentBackfill проверяет на откровенное содержание и определяет язык текстов песен, добавленных до
+// появления этих проверок. Новые и измененные песни проверяются сразу, поэтому после одного прохода
+// по всем арендаторам непроверенных песен не остается
+type ContentBackfill struct {
+	log        *slog.Logger
+	songs      SongRepository
+	tenants    TenantRepository
+	classifier ContentClassifier
+	detector   LanguageDetector
+}
+
+func NewContentBackfill(songs SongRepository, tenants TenantRepository, log *slog.Logger, classifier ContentClassifier,
+	detector LanguageDetector) *ContentBackfill {
+	return &ContentBackfill{
+		log:        log,
+		songs:      songs,
+		tenants:    tenants,
+		classifier: classifier,
+		detector:   detector,
+	}
+}
+
+// Run проверяет непроверенные песни всех арендаторов
+func (b *ContentBackfill) Run(ctx context.Context) error {
+	const op = "service.ContentBackfill.Run"
+	log := b.log.With(
+		slog.String("op", op),
+	)
+	tenants, err := b.tenants.ListTenants(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range tenants {
+		count, err := b.backfillTenant(tenant.WithID(ctx, t.ID))
+		if err != nil {
+			log.Warn("content backfill failed", slog.String("tenant", t.Slug), slog.String("err", err.Error()))
+			return err
+		}
+		if count > 0 {
+			log.Info("content backfill finished", slog.String("tenant", t.Slug), slog.Int("songs", count))
+		}
+	}
+	return nil
+}
+
+// backfillTenant проверяет непроверенные песни арендатора из ctx и возвращает их количество
+func (b *ContentBackfill) backfillTenant(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		songs, err := b.songs.ListUncheckedSongs(ctx, contentBackfillBatchSize)
+		if err != nil {
+			return count, err
+		}
+		for i := range songs {
+			if err := b.backfillSong(ctx, &songs[i]); err != nil {
+				return count, err
+			}
+		}
+		count += len(songs)
+		if len(songs) < contentBackfillBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// backfillSong выполняет проверки, которые еще не выполнялись для песни. Песню могли удалить после выборки,
+// тогда она пропускается
+func (b *ContentBackfill) backfillSong(ctx context.Context, song *models.Song) error {
+	if song.Explicit == nil {
+		classifyContent(b.classifier, song)
+		if err := b.songs.UpdateContentFlags(ctx, song); err != nil {
+			return skipNotFound(err)
+		}
+	}
+	if song.Language == nil {
+		detectLanguage(b.detector, song)
+		if err := b.songs.UpdateLanguage(ctx, song); err != nil {
+			return skipNotFound(err)
+		}
+	}
+	return nil
+}
+
+func skipNotFound(err error) error {
+	if errors.Is(err, models.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/tenant"
+	"testing"
+)
+
+// backfillSongs хранит песни арендаторов в памяти. Остальные методы SongRepository не используются
+type backfillSongs struct {
+	SongRepository
+	songs map[uint][]models.Song
+}
+
+func (r *backfillSongs) ListUncheckedSongs(ctx context.Context, limit int) ([]models.Song, error) {
+	var result []models.Song
+	for _, song := range r.songs[tenant.FromContext(ctx)] {
+		if (song.Explicit == nil || song.Language == nil) && len(result) < limit {
+			result = append(result, song)
+		}
+	}
+	return result, nil
+}
+
+func (r *backfillSongs) UpdateContentFlags(ctx context.Context, song *models.Song) error {
+	songs := r.songs[tenant.FromContext(ctx)]
+	for i := range songs {
+		if songs[i].ID == song.ID {
+			songs[i].Explicit = song.Explicit
+			songs[i].ExplicitReasons = song.ExplicitReasons
+		}
+	}
+	return nil
+}
+
+func (r *backfillSongs) UpdateLanguage(ctx context.Context, song *models.Song) error {
+	songs := r.songs[tenant.FromContext(ctx)]
+	for i := range songs {
+		if songs[i].ID == song.ID {
+			songs[i].Language = song.Language
+			songs[i].LanguageConfidence = song.LanguageConfidence
+		}
+	}
+	return nil
+}
+
+type backfillTenants struct {
+	TenantRepository
+	tenants []models.Tenant
+}
+
+func (r *backfillTenants) ListTenants(context.Context) ([]models.Tenant, error) {
+	return r.tenants, nil
+}
+
+// wordClassifier помечает откровенными тексты, совпадающие со словом
+type wordClassifier string
+
+func (c wordClassifier) Classify(text string, _ ...string) (bool, []models.ContentFlagReason) {
+	if text == string(c) {
+		return true, []models.ContentFlagReason{{Language: "en", Word: text, Count: 1}}
+	}
+	return false, nil
+}
+
+// englishDetector определяет английский язык у любого текста
+type englishDetector struct{}
+
+func (englishDetector) Detect(string) (string, float64) {
+	return "en", 0.9
+}
+
+func TestContentBackfillChecksUncheckedSongs(t *testing.T) {
+	checked := true
+	override := false
+	detected := "ru"
+	songs := &backfillSongs{songs: map[uint][]models.Song{
+		1: {
+			{ID: 1, Text: "damn"},
+			{ID: 2, Text: "hello", Language: &detected},
+			{ID: 3, Text: "damn", ExplicitOverride: &override},
+		},
+		2: {
+			{ID: 4, Text: "damn"},
+			{ID: 5, Text: "hello", Explicit: &checked},
+		},
+	}}
+	tenants := &backfillTenants{tenants: []models.Tenant{{ID: 1, Slug: "a"}, {ID: 2, Slug: "b"}}}
+	backfill := NewContentBackfill(songs, tenants, slog.New(slog.NewTextHandler(io.Discard, nil)), wordClassifier("damn"),
+		englishDetector{})
+
+	if err := backfill.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := map[uint]bool{1: true, 2: false, 3: false, 4: true, 5: true}
+	wantLanguage := map[uint]string{1: "en", 2: "ru", 3: "en", 4: "en", 5: "en"}
+	for _, tenantSongs := range songs.songs {
+		for _, song := range tenantSongs {
+			if song.Explicit == nil || song.Language == nil {
+				t.Errorf("song %d is still unchecked", song.ID)
+				continue
+			}
+			if *song.Language != wantLanguage[song.ID] {
+				t.Errorf("song %d language = %q, want %q", song.ID, *song.Language, wantLanguage[song.ID])
+			}
+			if *song.Explicit != want[song.ID] {
+				t.Errorf("song %d explicit = %t, want %t", song.ID, *song.Explicit, want[song.ID])
+			}
+		}
+	}
+}
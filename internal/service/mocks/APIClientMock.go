@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"testEffectiveMobile/internal/models"
 	"time"
 )
@@ -12,7 +13,7 @@ func NewAPIClientMock() *APIClientMock {
 	return &APIClientMock{}
 }
 
-func (a *APIClientMock) SongEnrichment(name, group string) (*models.Song, error) {
+func (a *APIClientMock) SongEnrichment(ctx context.Context, name, group string) (*models.Song, error) {
 	return &models.Song{
 		Group:       group,
 		Song:        name,
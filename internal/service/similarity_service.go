@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/tenant"
+	"testEffectiveMobile/internal/utils/similarity"
+	"testEffectiveMobile/internal/utils/textstats"
+	"time"
+)
+
+const (
+	// similarityBatchSize - количество песен, загружаемых за один запрос при построении индекса
+	similarityBatchSize = 500
+	// similarityIndexTTL - время, через которое индекс арендатора строится заново. Изменения песен через этот
+	// экземпляр сервиса попадают в индекс сразу, а изменения через другие экземпляры - после перестроения
+	similarityIndexTTL = 10 * time.Minute
+)
+
+// GetSimilarSongs возвращает до limit песен, похожих на заданную по тексту, исполнителю и году релиза
+func (s *SongService) GetSimilarSongs(ctx context.Context, id, limit int) ([]models.SimilarSong, error) {
+	index, err := s.similarityIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !index.Contains(uint(id)) {
+		return nil, fmt.Errorf("song %d: %w", id, models.ErrNotFound)
+	}
+	similar := make([]models.SimilarSong, 0, limit)
+	for _, result := range index.Similar(uint(id), limit) {
+		similar = append(similar, models.SimilarSong{
+			SongID:      result.ID,
+			Group:       result.Group,
+			Song:        result.Song,
+			Score:       result.Score,
+			LyricsScore: result.LyricsScore,
+			SameArtist:  result.SameArtist,
+			YearScore:   result.YearScore,
+		})
+	}
+	return similar, nil
+}
+
+// tenantSimilarity - индекс похожести песен арендатора, построенный в builtAt, index равен nil, пока индекс
+// не построен. mu удерживается на время построения индекса, поэтому обновления песен арендатора ждут его
+// завершения и не теряются, а другие арендаторы не ждут
+type tenantSimilarity struct {
+	mu      sync.Mutex
+	index   *similarity.Index
+	builtAt time.Time
+}
+
+// tenantSimilarity возвращает индекс похожести арендатора, создавая пустую запись при первом обращении.
+// similarityMu удерживается только на время поиска записи
+func (s *SongService) tenantSimilarity(ctx context.Context) *tenantSimilarity {
+	tenantID := tenant.FromContext(ctx)
+	s.similarityMu.Lock()
+	defer s.similarityMu.Unlock()
+	entry, ok := s.similarity[tenantID]
+	if !ok {
+		entry = &tenantSimilarity{}
+		s.similarity[tenantID] = entry
+	}
+	return entry
+}
+
+// similarityIndex возвращает индекс похожести песен арендатора из ctx и строит его при первом обращении
+// и по истечении similarityIndexTTL. Пока индекс строится, обновления песен этого арендатора ждут его завершения
+func (s *SongService) similarityIndex(ctx context.Context) (*similarity.Index, error) {
+	const op = "service.SongService.similarityIndex"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("tenant_id", tenant.FromContext(ctx)),
+	)
+	entry := s.tenantSimilarity(ctx)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.index != nil && time.Since(entry.builtAt) < similarityIndexTTL {
+		return entry.index, nil
+	}
+	index := similarity.NewIndex()
+	count := 0
+	for offset := 0; ; offset += similarityBatchSize {
+		songs, err := s.songRepository.FilterSongs(ctx, models.SongFilter{}, offset, similarityBatchSize)
+		if err != nil {
+			log.Warn("failed to build similarity index", slog.String("err", err.Error()))
+			return nil, err
+		}
+		for i := range songs {
+			index.Upsert(similarityDocument(&songs[i]))
+		}
+		count += len(songs)
+		if len(songs) < similarityBatchSize {
+			break
+		}
+	}
+	entry.index, entry.builtAt = index, time.Now()
+	log.Info("similarity index built", slog.Int("songs", count))
+	return index, nil
+}
+
+// updateSimilarity обновляет песню в индексе арендатора из ctx, если он уже построен
+func (s *SongService) updateSimilarity(ctx context.Context, song *models.Song) {
+	entry := s.tenantSimilarity(ctx)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.index != nil {
+		entry.index.Upsert(similarityDocument(song))
+	}
+}
+
+func (s *SongService) removeSimilarity(ctx context.Context, id uint) {
+	entry := s.tenantSimilarity(ctx)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.index != nil {
+		entry.index.Remove(id)
+	}
+}
+
+func similarityDocument(song *models.Song) similarity.Document {
+	var words []string
+	for _, word := range textstats.Words(song.Text) {
+		if !textstats.IsStopWord(word) {
+			words = append(words, word)
+		}
+	}
+	return similarity.Document{
+		ID:    song.ID,
+		Group: song.Group,
+		Song:  song.Song,
+		Year:  releaseYear(song.ReleaseDate),
+		Words: words,
+	}
+}
+
+// releaseYear извлекает год из даты релиза в формате YYYY-MM-DD или DD.MM.YYYY, 0 - если дата не распознана
+func releaseYear(date string) int {
+	for _, layout := range []string{time.DateOnly, "02.01.2006"} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.Year()
+		}
+	}
+	return 0
+}
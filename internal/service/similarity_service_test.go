@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testing"
+	"time"
+)
+
+// librarySongs отдает песни библиотеки постранично и считает построения индекса. Остальные методы
+// SongRepository не используются
+type librarySongs struct {
+	SongRepository
+	songs []models.Song
+	loads int
+}
+
+func (r *librarySongs) FilterSongs(_ context.Context, _ models.SongFilter, offset, limit int) ([]models.Song, error) {
+	if offset == 0 {
+		r.loads++
+	}
+	if offset >= len(r.songs) {
+		return nil, nil
+	}
+	return r.songs[offset:min(offset+limit, len(r.songs))], nil
+}
+
+func TestSimilarityIndexIsRebuiltAfterTTL(t *testing.T) {
+	songs := &librarySongs{songs: []models.Song{
+		{ID: 1, Group: "Muse", Song: "Uprising", Text: "paranoia is in bloom the transmissions will resume"},
+		{ID: 2, Group: "Muse", Song: "Resistance", Text: "is our secret safe tonight and are we out of sight"},
+	}}
+	service := NewService(Repositories{Songs: songs}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil,
+		nil).(*SongService)
+	ctx := context.Background()
+
+	if _, err := service.GetSimilarSongs(ctx, 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	// Песня, добавленная другим экземпляром сервиса, не видна, пока индекс не устарел
+	songs.songs = append(songs.songs, models.Song{ID: 3, Group: "Queen", Song: "Bohemian Rhapsody",
+		Text: "is this the real life is this just fantasy"})
+	if _, err := service.GetSimilarSongs(ctx, 3, 5); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("GetSimilarSongs() error = %v, want %v before the index expires", err, models.ErrNotFound)
+	}
+	if songs.loads != 1 {
+		t.Fatalf("index built %d times, want 1", songs.loads)
+	}
+
+	service.tenantSimilarity(ctx).builtAt = time.Now().Add(-similarityIndexTTL)
+	if _, err := service.GetSimilarSongs(ctx, 3, 5); err != nil {
+		t.Fatalf("GetSimilarSongs() after the index expired error = %v", err)
+	}
+	if songs.loads != 2 {
+		t.Errorf("index built %d times, want 2", songs.loads)
+	}
+}
@@ -1,86 +1,425 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"sync"
 	"testEffectiveMobile/internal/controller"
 	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/cache"
+	"testEffectiveMobile/internal/utils/lyrics"
+	"testEffectiveMobile/internal/utils/textstats"
+	"time"
 )
 
 type SongRepository interface {
-	FilterSongs(group, name string, offset, limit, id int) ([]models.Song, error)
-	CreateSong(song *models.Song) (uint, error)
-	GetVerseByID(id int) (string, error)
-	DeleteSong(id int) error
-	UpdateSong(song *models.Song) error
+	FilterSongs(ctx context.Context, filter models.SongFilter, offset, limit int) ([]models.Song, error)
+	CreateSong(ctx context.Context, song *models.Song) (uint, error)
+	GetSongByID(ctx context.Context, id int) (*models.Song, error)
+	GetSongForUpdate(ctx context.Context, id int) (*models.Song, error)
+	SearchSongsByText(ctx context.Context, substr string) ([]models.Song, error)
+	GetVerseByID(ctx context.Context, id int) (string, error)
+	DeleteSong(ctx context.Context, id int) error
+	UpdateSong(ctx context.Context, song *models.Song) error
+	UpdateContentFlags(ctx context.Context, song *models.Song) error
+	ListUncheckedSongs(ctx context.Context, limit int) ([]models.Song, error)
+	UpdateLanguage(ctx context.Context, song *models.Song) error
+	LanguageFacets(ctx context.Context, filter models.SongFilter) ([]models.LanguageFacet, error)
+	ReplaceSections(ctx context.Context, songID uint, sections []models.LyricsSection) error
+	GetSections(ctx context.Context, songID int) ([]models.LyricsSection, error)
+	ReplaceSyncedLines(ctx context.Context, songID uint, lines []models.SyncedLine) error
+	GetSyncedLines(ctx context.Context, songID int) ([]models.SyncedLine, error)
+	SaveChordSheet(ctx context.Context, sheet *models.ChordSheet) error
+	GetChordSheet(ctx context.Context, songID int) (*models.ChordSheet, error)
+	Chart(ctx context.Context, since *time.Time, limit int) ([]models.ChartEntry, error)
 }
 type APIClient interface {
-	SongEnrichment(name, group string) (*models.Song, error)
+	SongEnrichment(ctx context.Context, name, group string) (*models.Song, error)
+}
+
+// ContentClassifier проверяет текст песни на откровенное содержание
+type ContentClassifier interface {
+	Classify(text string, languages ...string) (bool, []models.ContentFlagReason)
+}
+
+// LanguageDetector определяет язык текста песни и уверенность от 0 до 1
+type LanguageDetector interface {
+	Detect(text string) (string, float64)
+}
+
+// Repositories объединяет репозитории, работающие в рамках одной транзакции
+type Repositories struct {
+	Songs        SongRepository
+	Translations TranslationRepository
+	Annotations  AnnotationRepository
+	Suggestions  SuggestionRepository
+	Users        UserRepository
+	Audit        AuditRepository
+}
+
+// UnitOfWork выполняет несколько обращений к репозиториям атомарно
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos Repositories) error) error
 }
 
 type SongService struct {
-	log            *slog.Logger
-	songRepository SongRepository
-	APIClient      APIClient
+	log                   *slog.Logger
+	songRepository        SongRepository
+	translationRepository TranslationRepository
+	annotationRepository  AnnotationRepository
+	suggestionRepository  SuggestionRepository
+	uow                   UnitOfWork
+	APIClient             APIClient
+	classifier            ContentClassifier
+	detector              LanguageDetector
+	// statsCache хранит статистику текста по id песни, wordUsageCache - результаты поиска слова по библиотеке
+	// арендатора. Записи сбрасываются при изменении текста песен, устаревают и вытесняются при переполнении
+	statsCache     *cache.Cache[tenantKey[uint], *songStats]
+	wordUsageCache *cache.Cache[tenantKey[string], *models.WordUsage]
+	// similarity - индексы похожести по арендаторам. Индекс арендатора строится лениво при первом запросе
+	// похожих песен и перестраивается по истечении similarityIndexTTL, similarityMu защищает только саму карту
+	similarity   map[uint]*tenantSimilarity
+	similarityMu sync.Mutex
 }
 
-func NewService(songRepository SongRepository, log *slog.Logger, client APIClient) controller.SongService {
+func NewService(repos Repositories, uow UnitOfWork, log *slog.Logger, client APIClient, classifier ContentClassifier, detector LanguageDetector) controller.SongService {
 	return &SongService{
-		songRepository: songRepository,
-		log:            log,
-		APIClient:      client,
+		songRepository:        repos.Songs,
+		translationRepository: repos.Translations,
+		annotationRepository:  repos.Annotations,
+		suggestionRepository:  repos.Suggestions,
+		uow:                   uow,
+		log:                   log,
+		APIClient:             client,
+		classifier:            classifier,
+		detector:              detector,
+		statsCache:            cache.NewLRU[tenantKey[uint], *songStats](statsCacheTTL, statsCacheSize),
+		wordUsageCache:        cache.NewLRU[tenantKey[string], *models.WordUsage](wordUsageCacheTTL, wordUsageCacheSize),
+		similarity:            make(map[uint]*tenantSimilarity),
 	}
 }
 
 // FilterSongs считает смещение и передает запрос на уровень репозитория
-func (s *SongService) FilterSongs(group, name string, page, pageSize, id int) ([]models.Song, error) {
+func (s *SongService) FilterSongs(ctx context.Context, filter models.SongFilter, page, pageSize int) ([]models.Song, error) {
 	offset := (page - 1) * pageSize
-	return s.songRepository.FilterSongs(group, name, offset, pageSize, id)
-}
-
-func (s *SongService) GetVersesWithPagination(id, page, pageSize int) ([]string, error) {
-	const op = "service.SongService.GetVerseWithPagination"
-	log := s.log.With(
-		slog.String("op", op),
-		slog.Any("song_id", id),
-		slog.Any("page", page),
-		slog.Any("page_size", pageSize),
-	)
-	verse, err := s.songRepository.GetVerseByID(id)
+	return s.songRepository.FilterSongs(ctx, filter, offset, pageSize)
+}
+
+// GetVersesWithPagination возвращает страницу куплетов песни. За пределами текста возвращается пустая страница
+func (s *SongService) GetVersesWithPagination(ctx context.Context, id, page, pageSize int) (*models.VersePage, error) {
+	text, err := s.songRepository.GetVerseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	verses := lyrics.Verses(text)
+	result := newVersePage(models.PaginationVerses, verses, len(verses), page, pageSize)
+	start, end := pageBounds(len(verses), page, pageSize)
+	pageVerses := append([]string{}, verses[start:end]...)
+	result.Verses = &pageVerses
+	return result, nil
+}
+
+// GetLinesWithPagination возвращает страницу строк песни с номерами куплетов
+func (s *SongService) GetLinesWithPagination(ctx context.Context, id, page, pageSize int) (*models.VersePage, error) {
+	text, err := s.songRepository.GetVerseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	verses := lyrics.Verses(text)
+	var lines []models.VerseLine
+	for i, verse := range verses {
+		for j, line := range strings.Split(verse, "\n") {
+			lines = append(lines, models.VerseLine{Verse: i + 1, Line: j + 1, Text: line})
+		}
+	}
+	result := newVersePage(models.PaginationLines, verses, len(lines), page, pageSize)
+	start, end := pageBounds(len(lines), page, pageSize)
+	pageLines := append([]models.VerseLine{}, lines[start:end]...)
+	result.Lines = &pageLines
+	return result, nil
+}
+
+// GetVerse возвращает куплет песни с номером number (нумерация с 1)
+func (s *SongService) GetVerse(ctx context.Context, id, number int) (*models.Verse, error) {
+	text, err := s.songRepository.GetVerseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	verses := lyrics.Verses(text)
+	if number < 1 || number > len(verses) {
+		return nil, fmt.Errorf("verse %d of song %d: %w", number, id, models.ErrNotFound)
+	}
+	return &models.Verse{
+		SongID:      uint(id),
+		Number:      number,
+		TotalVerses: len(verses),
+		Text:        verses[number-1],
+		Lines:       strings.Split(verses[number-1], "\n"),
+	}, nil
+}
+
+// newVersePage заполняет данные для навигации. total - количество элементов в выбранном режиме
+func newVersePage(mode string, verses []string, total, page, pageSize int) *models.VersePage {
+	totalLines := 0
+	for _, verse := range verses {
+		totalLines += strings.Count(verse, "\n") + 1
+	}
+	return &models.VersePage{
+		Mode:        mode,
+		Page:        page,
+		PageSize:    pageSize,
+		TotalPages:  (total + pageSize - 1) / pageSize,
+		TotalVerses: len(verses),
+		TotalLines:  totalLines,
+	}
+}
+
+// pageBounds возвращает границы страницы в срезе длины total
+func pageBounds(total, page, pageSize int) (int, int) {
+	start := min((page-1)*pageSize, total)
+	return start, min(start+pageSize, total)
+}
+
+// Возможные значения параметра include для GetSong
+const (
+	IncludeVerses = "verses"
+)
+
+// GetSong возвращает песню с вычисляемыми полями. include перечисляет дополнительные данные для ответа
+func (s *SongService) GetSong(ctx context.Context, id int, include []string) (*models.SongDetails, error) {
+	for _, item := range include {
+		switch item {
+		case IncludeVerses:
+		default:
+			return nil, models.NewValidationError("include", fmt.Sprintf("unknown expansion %q", item))
+		}
+	}
+	song, err := s.songRepository.GetSongByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	verses := strings.Split(verse, "\n\n")
-	totalVerses := len(verses)
-	start := (page - 1) * pageSize
-	if start >= totalVerses {
-		log.Debug("no more verses available")
-		return nil, errors.New("no more verses available")
+	verses := lyrics.Verses(song.Text)
+	details := &models.SongDetails{
+		Song:       *song,
+		VerseCount: len(verses),
+		WordCount:  len(textstats.Words(song.Text)),
 	}
-	end := start + pageSize
-	if end > totalVerses {
-		end = totalVerses
+	if slices.Contains(include, IncludeVerses) {
+		details.Verses = verses
 	}
-	return verses[start:end], nil
+	return details, nil
+}
+
+// GetLyrics возвращает структурированный текст песни. Для песен, части которых еще не сохранены,
+// текст разбирается на лету
+func (s *SongService) GetLyrics(ctx context.Context, id int) (*models.Lyrics, error) {
+	song, err := s.songRepository.GetSongByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := s.songRepository.GetSections(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		sections = lyrics.Parse(song.Text)
+	}
+	if sections == nil {
+		sections = []models.LyricsSection{}
+	}
+	return &models.Lyrics{SongID: song.ID, Sections: sections}, nil
 }
 
 // CreateSong создает запись о песне
-func (s *SongService) CreateSong(group, name string) (uint, error) {
-	song, err := s.APIClient.SongEnrichment(name, group)
+func (s *SongService) CreateSong(ctx context.Context, group, name string) (uint, error) {
+	song, err := s.APIClient.SongEnrichment(ctx, name, group)
 	if err != nil {
-		return 0, err
+		if ctx.Err() != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("song enrichment: %w: %s", models.ErrUpstreamUnavailable, err.Error())
 	}
-	id, err := s.songRepository.CreateSong(song)
+	song.Text = lyrics.Normalize(song.Text)
+	classifyContent(s.classifier, song)
+	detectLanguage(s.detector, song)
+	//Запрос к внешнему API выполняется вне транзакции, чтобы не держать соединение с БД на время ожидания
+	var id uint
+	err = s.uow.Do(ctx, func(repos Repositories) error {
+		id, err = repos.Songs.CreateSong(ctx, song)
+		if err != nil {
+			return err
+		}
+		if err := repos.Songs.ReplaceSections(ctx, id, lyrics.Parse(song.Text)); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditCreate, models.AuditResourceSong, id, nil, song)
+	})
 	if err != nil {
 		return 0, err
 	}
+	s.invalidateStats(ctx, id)
+	s.updateSimilarity(ctx, song)
 	return id, nil
 }
 
-func (s *SongService) UpdateSong(song *models.Song) error {
-	return s.songRepository.UpdateSong(song)
+func (s *SongService) UpdateSong(ctx context.Context, song *models.Song) error {
+	if _, err := time.Parse(time.DateOnly, song.ReleaseDate); song.ReleaseDate != "" && err != nil {
+		return models.NewValidationError("release_date", "must be a date in YYYY-MM-DD format")
+	}
+	song.Text = lyrics.Normalize(song.Text)
+	err := s.uow.Do(ctx, func(repos Repositories) error {
+		return s.updateSong(ctx, repos, song)
+	})
+	if err != nil {
+		return err
+	}
+	s.songUpdated(ctx, song)
+	return nil
+}
+
+// updateSong сохраняет измененные поля песни в транзакции repos. При изменении текста заново разбирает
+// его на части, определяет откровенное содержание и язык и переносит аннотации
+func (s *SongService) updateSong(ctx context.Context, repos Repositories, song *models.Song) error {
+	before, err := repos.Songs.GetSongByID(ctx, int(song.ID))
+	if err != nil {
+		return err
+	}
+	if err := repos.Songs.UpdateSong(ctx, song); err != nil {
+		return err
+	}
+	current, err := repos.Songs.GetSongByID(ctx, int(song.ID))
+	if err != nil {
+		return err
+	}
+	if song.Text != "" {
+		if err := repos.Songs.ReplaceSections(ctx, song.ID, lyrics.Parse(song.Text)); err != nil {
+			return err
+		}
+		classifyContent(s.classifier, current)
+		if err := repos.Songs.UpdateContentFlags(ctx, current); err != nil {
+			return err
+		}
+		detectLanguage(s.detector, current)
+		if err := repos.Songs.UpdateLanguage(ctx, current); err != nil {
+			return err
+		}
+		if err := s.reanchorAnnotations(ctx, repos, song.ID, song.Text); err != nil {
+			return err
+		}
+	}
+	return recordAudit(ctx, repos.Audit, models.AuditUpdate, models.AuditResourceSong, song.ID, before, current)
+}
+
+// songUpdated сбрасывает кэши после фиксации изменений песни
+func (s *SongService) songUpdated(ctx context.Context, song *models.Song) {
+	if song.Text != "" {
+		s.invalidateStats(ctx, song.ID)
+	}
+	// В song только измененные поля, поэтому для индекса песня перечитывается целиком
+	if updated, err := s.songRepository.GetSongByID(ctx, int(song.ID)); err == nil {
+		s.updateSimilarity(ctx, updated)
+	}
 }
-func (s *SongService) DeleteSong(id int) error {
-	return s.songRepository.DeleteSong(id)
+
+// SetExplicitOverride задает признак откровенного содержания вручную. override = nil возвращает
+// автоматическое определение по тексту
+func (s *SongService) SetExplicitOverride(ctx context.Context, id int, override *bool) (*models.Song, error) {
+	var song *models.Song
+	err := s.uow.Do(ctx, func(repos Repositories) error {
+		var err error
+		song, err = repos.Songs.GetSongByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		before := *song
+		song.ExplicitOverride = override
+		classifyContent(s.classifier, song)
+		if err := repos.Songs.UpdateContentFlags(ctx, song); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditUpdate, models.AuditResourceSong, song.ID, before, song)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return song, nil
+}
+
+// classifyContent проверяет текст песни и заполняет признак откровенного содержания с учетом ручного значения
+func classifyContent(classifier ContentClassifier, song *models.Song) {
+	explicit, reasons := classifier.Classify(song.Text)
+	song.ExplicitReasons = reasons
+	if song.ExplicitOverride != nil {
+		explicit = *song.ExplicitOverride
+	}
+	song.Explicit = &explicit
+}
+
+// SetLanguage задает язык песни вручную. language = nil возвращает автоматическое определение по тексту
+func (s *SongService) SetLanguage(ctx context.Context, id int, language *string) (*models.Song, error) {
+	var song *models.Song
+	err := s.uow.Do(ctx, func(repos Repositories) error {
+		var err error
+		song, err = repos.Songs.GetSongByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		before := *song
+		song.LanguageManual = language != nil
+		if language != nil {
+			song.Language, song.LanguageConfidence = language, 1
+		}
+		detectLanguage(s.detector, song)
+		if err := repos.Songs.UpdateLanguage(ctx, song); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditUpdate, models.AuditResourceSong, song.ID, before, song)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return song, nil
+}
+
+// LanguageFacets возвращает количество песен по языкам для фильтра
+func (s *SongService) LanguageFacets(ctx context.Context, filter models.SongFilter) ([]models.LanguageFacet, error) {
+	facets, err := s.songRepository.LanguageFacets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if facets == nil {
+		facets = []models.LanguageFacet{}
+	}
+	return facets, nil
+}
+
+// detectLanguage определяет язык текста песни, если он не задан вручную
+func detectLanguage(detector LanguageDetector, song *models.Song) {
+	if song.LanguageManual {
+		return
+	}
+	language, confidence := detector.Detect(song.Text)
+	song.Language, song.LanguageConfidence = &language, confidence
+}
+
+func (s *SongService) DeleteSong(ctx context.Context, id int) error {
+	err := s.uow.Do(ctx, func(repos Repositories) error {
+		song, err := repos.Songs.GetSongByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := repos.Songs.DeleteSong(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditDelete, models.AuditResourceSong, id, song, nil)
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidateStats(ctx, uint(id))
+	s.removeSimilarity(ctx, uint(id))
+	return nil
 }
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/tenant"
+	"testEffectiveMobile/internal/utils/lyrics"
+	"testEffectiveMobile/internal/utils/textstats"
+	"time"
+)
+
+// Ограничения кэшей статистики. Слово для поиска задает клиент, поэтому без ограничения размера кэш поиска
+// рос бы с каждым новым словом; время жизни ограничивает устаревание результатов, которые изменили
+// другие экземпляры сервиса
+const (
+	statsCacheTTL      = 30 * time.Minute
+	statsCacheSize     = 10000
+	wordUsageCacheTTL  = 10 * time.Minute
+	wordUsageCacheSize = 1000
+)
+
+// tenantKey - ключ кэша в пределах арендатора
+type tenantKey[K comparable] struct {
+	tenant uint
+	key    K
+}
+
+// songStats - статистика песни вместе с полным частотным словарем, из которого берутся top слов
+type songStats struct {
+	stats       models.SongStats
+	frequencies []textstats.Frequency
+}
+
+// GetSongStats возвращает статистику текста песни и top самых частых слов без учета служебных
+func (s *SongService) GetSongStats(ctx context.Context, id, top int) (*models.SongStats, error) {
+	key := tenantKey[uint]{tenant: tenant.FromContext(ctx), key: uint(id)}
+	cached, ok := s.statsCache.Get(key)
+	if !ok {
+		song, err := s.songRepository.GetSongByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		cached = computeStats(song)
+		s.statsCache.Set(key, cached)
+	}
+	stats := cached.stats
+	stats.TopWords = make([]models.WordFrequency, 0, top)
+	for _, f := range cached.frequencies[:min(top, len(cached.frequencies))] {
+		stats.TopWords = append(stats.TopWords, models.WordFrequency{Word: f.Word, Count: f.Count})
+	}
+	return &stats, nil
+}
+
+// GetWordUsage возвращает песни, в тексте которых встречается слово, и количество употреблений в каждой
+func (s *SongService) GetWordUsage(ctx context.Context, word string) (*models.WordUsage, error) {
+	words := textstats.Words(word)
+	if len(words) != 1 {
+		return nil, models.NewValidationError("word", "must be a single word")
+	}
+	word = words[0]
+	key := tenantKey[string]{tenant: tenant.FromContext(ctx), key: word}
+	if usage, ok := s.wordUsageCache.Get(key); ok {
+		return usage, nil
+	}
+
+	// Поиск подстроки в БД отсекает большинство песен, точные совпадения слова считаются здесь
+	songs, err := s.songRepository.SearchSongsByText(ctx, word)
+	if err != nil {
+		return nil, err
+	}
+	usage := &models.WordUsage{Word: word, Songs: []models.SongWordCount{}}
+	for _, song := range songs {
+		count := textstats.Count(song.Text, word)
+		if count == 0 {
+			continue
+		}
+		usage.Songs = append(usage.Songs, models.SongWordCount{SongID: song.ID, Group: song.Group, Song: song.Song, Count: count})
+		usage.TotalOccurrences += count
+	}
+	usage.TotalSongs = len(usage.Songs)
+	sort.SliceStable(usage.Songs, func(i, j int) bool { return usage.Songs[i].Count > usage.Songs[j].Count })
+	s.wordUsageCache.Set(key, usage)
+	return usage, nil
+}
+
+// invalidateStats сбрасывает кэшированную статистику после изменения текста песни арендатора из ctx
+func (s *SongService) invalidateStats(ctx context.Context, id uint) {
+	s.statsCache.Delete(tenantKey[uint]{tenant: tenant.FromContext(ctx), key: id})
+	s.wordUsageCache.Clear()
+}
+
+func computeStats(song *models.Song) *songStats {
+	verses := lyrics.Verses(song.Text)
+	words := textstats.Words(song.Text)
+	unique := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		unique[word] = struct{}{}
+	}
+	lines := 0
+	for _, verse := range verses {
+		lines += strings.Count(verse, "\n") + 1
+	}
+	return &songStats{
+		stats: models.SongStats{
+			SongID:      song.ID,
+			WordCount:   len(words),
+			LineCount:   lines,
+			VerseCount:  len(verses),
+			UniqueWords: len(unique),
+		},
+		frequencies: textstats.Frequencies(words),
+	}
+}
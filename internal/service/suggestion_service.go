@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/lyrics"
+	"testEffectiveMobile/internal/utils/textdiff"
+	"time"
+)
+
+type SuggestionRepository interface {
+	CreateSuggestion(ctx context.Context, suggestion *models.Suggestion) error
+	GetSuggestion(ctx context.Context, songID, id int) (*models.Suggestion, error)
+	ListSuggestions(ctx context.Context, filter models.SuggestionFilter, offset, limit int) ([]models.Suggestion, error)
+	ReviewSuggestion(ctx context.Context, suggestion *models.Suggestion) error
+	CreateRevision(ctx context.Context, revision *models.Revision) error
+	ListRevisions(ctx context.Context, songID int) ([]models.Revision, error)
+}
+
+// CreateSuggestion сохраняет предложенный текст песни вместе с текущим, чтобы при принятии
+// можно было проверить, что текст за это время не изменился
+func (s *SongService) CreateSuggestion(ctx context.Context, songID int, suggestion *models.Suggestion) error {
+	song, err := s.songRepository.GetSongByID(ctx, songID)
+	if err != nil {
+		return err
+	}
+	suggestion.Text = lyrics.Normalize(suggestion.Text)
+	if suggestion.Text == "" {
+		return models.NewValidationError("text", "is required")
+	}
+	if suggestion.Text == song.Text {
+		return models.NewValidationError("text", "must differ from the current text")
+	}
+	suggestion.SongID = song.ID
+	suggestion.BaseText = song.Text
+	suggestion.Status = models.SuggestionPending
+	return s.uow.Do(ctx, func(repos Repositories) error {
+		if err := repos.Suggestions.CreateSuggestion(ctx, suggestion); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditCreate, models.AuditResourceSuggestion, suggestion.ID, nil, suggestion)
+	})
+}
+
+func (s *SongService) GetSuggestion(ctx context.Context, songID, id int) (*models.Suggestion, error) {
+	return s.suggestionRepository.GetSuggestion(ctx, songID, id)
+}
+
+// ListSuggestions возвращает страницу предложений, начиная с самых старых
+func (s *SongService) ListSuggestions(ctx context.Context, filter models.SuggestionFilter, page, pageSize int) ([]models.Suggestion, error) {
+	suggestions, err := s.suggestionRepository.ListSuggestions(ctx, filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if suggestions == nil {
+		suggestions = []models.Suggestion{}
+	}
+	return suggestions, nil
+}
+
+// DiffSuggestion сравнивает предложенный текст с текущим текстом песни
+func (s *SongService) DiffSuggestion(ctx context.Context, songID, id int) (*models.SuggestionDiff, error) {
+	suggestion, err := s.suggestionRepository.GetSuggestion(ctx, songID, id)
+	if err != nil {
+		return nil, err
+	}
+	song, err := s.songRepository.GetSongByID(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	lines := textdiff.Lines(song.Text, suggestion.Text)
+	added, removed := textdiff.Count(lines)
+	return &models.SuggestionDiff{
+		SuggestionID: suggestion.ID,
+		SongID:       song.ID,
+		Stale:        suggestion.Status == models.SuggestionPending && song.Text != suggestion.BaseText,
+		Added:        added,
+		Removed:      removed,
+		Lines:        lines,
+	}, nil
+}
+
+// ApproveSuggestion применяет предложенный текст так же, как UpdateSong, и сохраняет версию текста.
+// Если текст песни изменился после предложения или предложение уже рассмотрено, возвращает ошибку
+// с models.ErrConflict
+func (s *SongService) ApproveSuggestion(ctx context.Context, songID, id int, moderator string) (*models.Suggestion, error) {
+	var suggestion *models.Suggestion
+	update := &models.Song{ID: uint(songID)}
+	err := s.uow.Do(ctx, func(repos Repositories) error {
+		// Строка песни блокируется до чтения предложения, поэтому одновременные изменения текста и одобрения
+		// других предложений ждут конца транзакции, а сравнение с BaseText остается верным до обновления
+		song, err := repos.Songs.GetSongForUpdate(ctx, songID)
+		if err != nil {
+			return err
+		}
+		suggestion, err = repos.Suggestions.GetSuggestion(ctx, songID, id)
+		if err != nil {
+			return err
+		}
+		if suggestion.Status == models.SuggestionPending && song.Text != suggestion.BaseText {
+			return fmt.Errorf("song %d text changed after suggestion %d was made: %w", songID, id, models.ErrConflict)
+		}
+		before := *suggestion
+		now := time.Now()
+		suggestion.Status = models.SuggestionApproved
+		suggestion.ReviewedBy = moderator
+		suggestion.ReviewedAt = &now
+		if err := repos.Suggestions.ReviewSuggestion(ctx, suggestion); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, repos.Audit, models.AuditUpdate, models.AuditResourceSuggestion, suggestion.ID, before, suggestion); err != nil {
+			return err
+		}
+		update.Text = suggestion.Text
+		if err := s.updateSong(ctx, repos, update); err != nil {
+			return err
+		}
+		return repos.Suggestions.CreateRevision(ctx, &models.Revision{
+			SongID:       song.ID,
+			Text:         suggestion.Text,
+			PreviousText: song.Text,
+			Author:       suggestion.Author,
+			ApprovedBy:   moderator,
+			SuggestionID: &suggestion.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.songUpdated(ctx, update)
+	return suggestion, nil
+}
+
+// RejectSuggestion отклоняет предложение с указанием причины
+func (s *SongService) RejectSuggestion(ctx context.Context, songID, id int, moderator, reason string) (*models.Suggestion, error) {
+	var suggestion *models.Suggestion
+	err := s.uow.Do(ctx, func(repos Repositories) error {
+		var err error
+		suggestion, err = repos.Suggestions.GetSuggestion(ctx, songID, id)
+		if err != nil {
+			return err
+		}
+		before := *suggestion
+		now := time.Now()
+		suggestion.Status = models.SuggestionRejected
+		suggestion.Reason = reason
+		suggestion.ReviewedBy = moderator
+		suggestion.ReviewedAt = &now
+		if err := repos.Suggestions.ReviewSuggestion(ctx, suggestion); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditUpdate, models.AuditResourceSuggestion, suggestion.ID, before, suggestion)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return suggestion, nil
+}
+
+// ListRevisions возвращает версии текста песни, начиная с последней
+func (s *SongService) ListRevisions(ctx context.Context, songID int) ([]models.Revision, error) {
+	if _, err := s.songRepository.GetSongByID(ctx, songID); err != nil {
+		return nil, err
+	}
+	revisions, err := s.suggestionRepository.ListRevisions(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	if revisions == nil {
+		revisions = []models.Revision{}
+	}
+	return revisions, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/lrc"
+)
+
+// ImportSyncedLyrics разбирает LRC-файл и сохраняет его строки как синхронизированный текст песни
+func (s *SongService) ImportSyncedLyrics(ctx context.Context, id int, lrcText string) (*models.SyncedLyrics, error) {
+	lines, err := lrc.Parse(lrcText)
+	if err != nil {
+		var parseErr *lrc.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, models.NewValidationError("lrc", parseErr.Error())
+		}
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, models.NewValidationError("lrc", "must contain at least one timed line")
+	}
+	err = s.uow.Do(ctx, func(repos Repositories) error {
+		song, err := repos.Songs.GetSongByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		previous, err := repos.Songs.GetSyncedLines(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := repos.Songs.ReplaceSyncedLines(ctx, song.ID, lines); err != nil {
+			return err
+		}
+		action := models.AuditCreate
+		var before *models.SyncedLyrics
+		if len(previous) > 0 {
+			action, before = models.AuditUpdate, &models.SyncedLyrics{SongID: song.ID, Lines: previous}
+		}
+		return recordAudit(ctx, repos.Audit, action, models.AuditResourceSyncedLyrics, song.ID, before,
+			&models.SyncedLyrics{SongID: song.ID, Lines: lines})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.SyncedLyrics{SongID: uint(id), Lines: lines}, nil
+}
+
+// GetSyncedLyrics возвращает синхронизированный текст песни
+func (s *SongService) GetSyncedLyrics(ctx context.Context, id int) (*models.SyncedLyrics, error) {
+	song, err := s.songRepository.GetSongByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.getSyncedLines(ctx, song)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SyncedLyrics{SongID: song.ID, Lines: lines}, nil
+}
+
+// ExportSyncedLyrics возвращает синхронизированный текст песни в формате LRC
+func (s *SongService) ExportSyncedLyrics(ctx context.Context, id int) (string, error) {
+	song, err := s.songRepository.GetSongByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	lines, err := s.getSyncedLines(ctx, song)
+	if err != nil {
+		return "", err
+	}
+	return lrc.Export(song.Group, song.Song, lines), nil
+}
+
+// GetSyncedLyricsAt возвращает строку, звучащую в момент atMs, и до contextLines строк до и после нее.
+// До начала первой строки текущей строки нет, а следующими считаются первые строки песни
+func (s *SongService) GetSyncedLyricsAt(ctx context.Context, id, atMs, contextLines int) (*models.SyncedLyricsPosition, error) {
+	song, err := s.songRepository.GetSongByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.getSyncedLines(ctx, song)
+	if err != nil {
+		return nil, err
+	}
+	// Индекс первой строки, которая начинается позже atMs
+	next := sort.Search(len(lines), func(i int) bool { return lines[i].TimeMs > atMs })
+	position := &models.SyncedLyricsPosition{
+		SongID:   song.ID,
+		AtMs:     atMs,
+		Previous: []models.SyncedLine{},
+		Next:     lines[next:min(next+contextLines, len(lines))],
+	}
+	if next > 0 {
+		position.Current = &lines[next-1]
+		position.Previous = lines[max(next-1-contextLines, 0) : next-1]
+	}
+	return position, nil
+}
+
+func (s *SongService) getSyncedLines(ctx context.Context, song *models.Song) ([]models.SyncedLine, error) {
+	lines, err := s.songRepository.GetSyncedLines(ctx, int(song.ID))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("synced lyrics for song %d: %w", song.ID, models.ErrNotFound)
+	}
+	for i := range lines {
+		lines[i].Time = lrc.Format(lines[i].TimeMs)
+	}
+	return lines, nil
+}
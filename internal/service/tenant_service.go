@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testEffectiveMobile/internal/controller"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/cache"
+)
+
+type TenantRepository interface {
+	CreateTenant(ctx context.Context, tenant *models.Tenant) error
+	GetTenantBySlug(ctx context.Context, slug string) (*models.Tenant, error)
+	ListTenants(ctx context.Context) ([]models.Tenant, error)
+}
+
+type TenantService struct {
+	log              *slog.Logger
+	tenantRepository TenantRepository
+	auditRepository  AuditRepository
+	// tenants хранит найденных арендаторов по коду: арендатор определяется в каждом запросе, а удалить
+	// или переименовать его нельзя
+	tenants *cache.Cache[string, *models.Tenant]
+}
+
+func NewTenantService(repository TenantRepository, audit AuditRepository, log *slog.Logger) controller.TenantService {
+	return &TenantService{
+		log:              log,
+		tenantRepository: repository,
+		auditRepository:  audit,
+		tenants:          cache.New[string, *models.Tenant](0),
+	}
+}
+
+func (s *TenantService) CreateTenant(ctx context.Context, tenant *models.Tenant) error {
+	if err := s.tenantRepository.CreateTenant(ctx, tenant); err != nil {
+		return err
+	}
+	s.tenants.Set(tenant.Slug, tenant)
+	recordCommittedAudit(ctx, s.log, s.auditRepository, models.AuditCreate, models.AuditResourceTenant, tenant.ID, nil, tenant)
+	return nil
+}
+
+func (s *TenantService) ListTenants(ctx context.Context) ([]models.Tenant, error) {
+	tenants, err := s.tenantRepository.ListTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenants == nil {
+		tenants = []models.Tenant{}
+	}
+	return tenants, nil
+}
+
+// GetTenantBySlug возвращает арендатора по коду. Для неизвестного кода возвращает ошибку с models.ErrNotFound
+func (s *TenantService) GetTenantBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
+	if tenant, ok := s.tenants.Get(slug); ok {
+		return tenant, nil
+	}
+	tenant, err := s.tenantRepository.GetTenantBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	s.tenants.Set(slug, tenant)
+	return tenant, nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/lyrics"
+)
+
+type TranslationRepository interface {
+	CreateTranslation(ctx context.Context, translation *models.Translation) error
+	UpdateTranslation(ctx context.Context, translation *models.Translation) error
+	GetTranslation(ctx context.Context, songID int, language string) (*models.Translation, error)
+	ListTranslations(ctx context.Context, songID int) ([]models.Translation, error)
+}
+
+// CreateTranslation добавляет перевод песни. Количество куплетов перевода должно совпадать с оригиналом
+func (s *SongService) CreateTranslation(ctx context.Context, songID int, language string, verses []string) (*models.Translation, error) {
+	translation, err := s.buildTranslation(ctx, songID, language, verses)
+	if err != nil {
+		return nil, err
+	}
+	err = s.uow.Do(ctx, func(repos Repositories) error {
+		if err := repos.Translations.CreateTranslation(ctx, translation); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditCreate, models.AuditResourceTranslation,
+			translationResourceID(translation), nil, translation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return translation, nil
+}
+
+// UpdateTranslation заменяет куплеты существующего перевода
+func (s *SongService) UpdateTranslation(ctx context.Context, songID int, language string, verses []string) (*models.Translation, error) {
+	translation, err := s.buildTranslation(ctx, songID, language, verses)
+	if err != nil {
+		return nil, err
+	}
+	var updated *models.Translation
+	err = s.uow.Do(ctx, func(repos Repositories) error {
+		before, err := repos.Translations.GetTranslation(ctx, songID, translation.Language)
+		if err != nil {
+			return err
+		}
+		if err := repos.Translations.UpdateTranslation(ctx, translation); err != nil {
+			return err
+		}
+		updated, err = repos.Translations.GetTranslation(ctx, songID, translation.Language)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditUpdate, models.AuditResourceTranslation,
+			translationResourceID(translation), before, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// translationResourceID возвращает id перевода в журнале аудита: id песни и язык через косую черту
+func translationResourceID(translation *models.Translation) string {
+	return fmt.Sprintf("%d/%s", translation.SongID, translation.Language)
+}
+
+func (s *SongService) ListTranslations(ctx context.Context, songID int) ([]models.Translation, error) {
+	if _, err := s.songRepository.GetSongByID(ctx, songID); err != nil {
+		return nil, err
+	}
+	translations, err := s.translationRepository.ListTranslations(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	if translations == nil {
+		translations = []models.Translation{}
+	}
+	return translations, nil
+}
+
+// GetTranslatedVerses возвращает страницу куплетов оригинала в паре с куплетами перевода на язык language
+func (s *SongService) GetTranslatedVerses(ctx context.Context, id int, language string, page, pageSize int) (*models.VersePage, error) {
+	const op = "service.SongService.GetTranslatedVerses"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("song_id", id),
+		slog.String("language", language),
+	)
+	text, err := s.songRepository.GetVerseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	translation, err := s.translationRepository.GetTranslation(ctx, id, strings.ToLower(language))
+	if err != nil {
+		return nil, err
+	}
+	verses := lyrics.Verses(text)
+	if len(translation.Verses) != len(verses) {
+		// Оригинал мог измениться после добавления перевода, недостающие куплеты остаются пустыми
+		log.Debug("translation is out of sync with the original",
+			slog.Int("original_verses", len(verses)),
+			slog.Int("translated_verses", len(translation.Verses)),
+		)
+	}
+	result := newVersePage(models.PaginationVerses, verses, len(verses), page, pageSize)
+	start, end := pageBounds(len(verses), page, pageSize)
+	pairs := make([]models.VersePair, 0, end-start)
+	for i := start; i < end; i++ {
+		pair := models.VersePair{Index: i, Original: verses[i]}
+		if i < len(translation.Verses) {
+			pair.Translation = translation.Verses[i]
+		}
+		pairs = append(pairs, pair)
+	}
+	result.Translations = &pairs
+	return result, nil
+}
+
+func (s *SongService) buildTranslation(ctx context.Context, songID int, language string, verses []string) (*models.Translation, error) {
+	song, err := s.songRepository.GetSongByID(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	original := lyrics.Verses(song.Text)
+	if len(verses) != len(original) {
+		return nil, models.NewValidationError("verses", fmt.Sprintf("must contain %d verses to match the original", len(original)))
+	}
+	translated := make([]string, len(verses))
+	for i, verse := range verses {
+		translated[i] = lyrics.Normalize(verse)
+	}
+	return &models.Translation{
+		SongID:   song.ID,
+		Language: strings.ToLower(language),
+		Verses:   translated,
+	}, nil
+}
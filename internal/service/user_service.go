@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testEffectiveMobile/internal/controller"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/tenant"
+	"time"
+)
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+	AddFavorite(ctx context.Context, favorite *models.Favorite) error
+	RemoveFavorite(ctx context.Context, userID, songID uint) error
+	ListFavorites(ctx context.Context, userID uint, offset, limit int) ([]models.FavoriteSong, error)
+	AddPlay(ctx context.Context, play *models.Play) error
+	ListHistory(ctx context.Context, userID uint, offset, limit int) ([]models.HistoryEntry, error)
+	GetRating(ctx context.Context, userID, songID uint) (*models.Rating, error)
+	SetRating(ctx context.Context, rating *models.Rating) (*models.SongRating, error)
+	DeleteRating(ctx context.Context, userID, songID uint) (*models.SongRating, error)
+}
+
+// TokenIssuer выдает токен доступа аутентифицированному клиенту
+type TokenIssuer interface {
+	IssueToken(principal *models.Principal) (*models.AccessToken, error)
+}
+
+// dummyPasswordHash сравнивается с паролем, когда пользователь не найден, чтобы время ответа
+// не выдавало, зарегистрирован ли адрес
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+type UserService struct {
+	log             *slog.Logger
+	userRepository  UserRepository
+	auditRepository AuditRepository
+	uow             UnitOfWork
+	// tokens равен nil, если JWT не настроены. Тогда регистрация работает, а вход - нет
+	tokens TokenIssuer
+}
+
+func NewUserService(repository UserRepository, audit AuditRepository, uow UnitOfWork, log *slog.Logger, tokens TokenIssuer) controller.UserService {
+	return &UserService{
+		log:             log,
+		userRepository:  repository,
+		auditRepository: audit,
+		uow:             uow,
+		tokens:          tokens,
+	}
+}
+
+// Register создает пользователя с ролью reader в арендаторе запроса. Адрес почты приводится к нижнему регистру
+func (s *UserService) Register(ctx context.Context, email, password string) (*models.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return nil, models.NewValidationError("password", "must be at most 72 bytes long")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+	user := models.User{
+		Email:        strings.ToLower(email),
+		Role:         models.RoleReader,
+		TenantID:     tenant.FromContext(ctx),
+		PasswordHash: string(hash),
+	}
+	if err := s.userRepository.CreateUser(ctx, &user); err != nil {
+		return nil, err
+	}
+	recordCommittedAudit(ctx, s.log, s.auditRepository, models.AuditCreate, models.AuditResourceUser, user.ID, nil, user)
+	return &user, nil
+}
+
+// Login проверяет пароль и выдает токен с субъектом user:<id>, ролью и арендатором пользователя.
+// При неверном адресе или пароле возвращает ошибку с models.ErrUnauthorized
+func (s *UserService) Login(ctx context.Context, email, password string) (*models.AccessToken, error) {
+	const op = "service.UserService.Login"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+	if s.tokens == nil {
+		return nil, fmt.Errorf("user login is not configured: %w", models.ErrUnavailable)
+	}
+	user, err := s.userRepository.GetUserByEmail(ctx, strings.ToLower(email))
+	if errors.Is(err, models.ErrNotFound) {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return nil, fmt.Errorf("invalid email or password: %w", models.ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		log.Debug("wrong password", slog.Any("user_id", user.ID))
+		return nil, fmt.Errorf("invalid email or password: %w", models.ErrUnauthorized)
+	}
+	return s.tokens.IssueToken(&models.Principal{
+		Subject: models.UserSubject(user.ID),
+		Name:    user.Email,
+		Method:  models.AuthMethodJWT,
+		Roles:   []string{user.Role},
+		Tenant:  user.Tenant,
+	})
+}
+
+func (s *UserService) GetUser(ctx context.Context, id uint) (*models.User, error) {
+	return s.userRepository.GetUserByID(ctx, id)
+}
+
+// AddFavorite добавляет песню в избранное. Повторное добавление не считается ошибкой
+func (s *UserService) AddFavorite(ctx context.Context, userID uint, songID int) error {
+	favorite := models.Favorite{UserID: userID, SongID: uint(songID)}
+	return s.uow.Do(ctx, func(repos Repositories) error {
+		if err := repos.Users.AddFavorite(ctx, &favorite); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditCreate, models.AuditResourceFavorite,
+			userSongResourceID(userID, favorite.SongID), nil, favorite)
+	})
+}
+
+func (s *UserService) RemoveFavorite(ctx context.Context, userID uint, songID int) error {
+	favorite := models.Favorite{UserID: userID, SongID: uint(songID)}
+	return s.uow.Do(ctx, func(repos Repositories) error {
+		if err := repos.Users.RemoveFavorite(ctx, userID, favorite.SongID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditDelete, models.AuditResourceFavorite,
+			userSongResourceID(userID, favorite.SongID), favorite, nil)
+	})
+}
+
+// ListFavorites возвращает страницу избранного, начиная с последних добавленных песен
+func (s *UserService) ListFavorites(ctx context.Context, userID uint, page, pageSize int) ([]models.FavoriteSong, error) {
+	favorites, err := s.userRepository.ListFavorites(ctx, userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if favorites == nil {
+		favorites = []models.FavoriteSong{}
+	}
+	return favorites, nil
+}
+
+// RecordPlay записывает прослушивание песни в историю пользователя
+func (s *UserService) RecordPlay(ctx context.Context, userID uint, songID int) (*models.Play, error) {
+	play := models.Play{UserID: userID, SongID: uint(songID), PlayedAt: time.Now()}
+	err := s.uow.Do(ctx, func(repos Repositories) error {
+		if err := repos.Users.AddPlay(ctx, &play); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditCreate, models.AuditResourcePlay, play.ID, nil, play)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &play, nil
+}
+
+// ListHistory возвращает страницу истории прослушиваний, начиная с последних
+func (s *UserService) ListHistory(ctx context.Context, userID uint, page, pageSize int) ([]models.HistoryEntry, error) {
+	history, err := s.userRepository.ListHistory(ctx, userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []models.HistoryEntry{}
+	}
+	return history, nil
+}
+
+// RateSong сохраняет оценку песни пользователем. Повторная оценка заменяет предыдущую
+func (s *UserService) RateSong(ctx context.Context, userID uint, songID, rating int) (*models.SongRating, error) {
+	updated := models.Rating{UserID: userID, SongID: uint(songID), Rating: rating}
+	var result *models.SongRating
+	err := s.uow.Do(ctx, func(repos Repositories) error {
+		action := models.AuditUpdate
+		before, err := repos.Users.GetRating(ctx, userID, updated.SongID)
+		if errors.Is(err, models.ErrNotFound) {
+			action = models.AuditCreate
+		} else if err != nil {
+			return err
+		}
+		if result, err = repos.Users.SetRating(ctx, &updated); err != nil {
+			return err
+		}
+		after := updated
+		if before != nil {
+			after = *before
+			after.Rating = rating
+		}
+		return recordAudit(ctx, repos.Audit, action, models.AuditResourceRating,
+			userSongResourceID(userID, updated.SongID), before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *UserService) DeleteRating(ctx context.Context, userID uint, songID int) (*models.SongRating, error) {
+	var result *models.SongRating
+	err := s.uow.Do(ctx, func(repos Repositories) error {
+		before, err := repos.Users.GetRating(ctx, userID, uint(songID))
+		if err != nil {
+			return err
+		}
+		if result, err = repos.Users.DeleteRating(ctx, userID, uint(songID)); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, models.AuditDelete, models.AuditResourceRating,
+			userSongResourceID(userID, uint(songID)), before, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// userSongResourceID - id избранного или оценки в журнале аудита
+func userSongResourceID(userID, songID uint) string {
+	return fmt.Sprintf("%d/%d", userID, songID)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testEffectiveMobile/internal/models"
+	"testing"
+)
+
+func TestLoginWithoutTokenIssuerIsUnavailable(t *testing.T) {
+	users := NewUserService(nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+
+	_, err := users.Login(context.Background(), "user@example.com", "password")
+	if !errors.Is(err, models.ErrUnavailable) {
+		t.Fatalf("Login() error = %v, want %v", err, models.ErrUnavailable)
+	}
+}
+
+// ratingUsers хранит оценки в памяти. Остальные методы UserRepository не используются
+type ratingUsers struct {
+	UserRepository
+	ratings map[uint]models.Rating
+}
+
+func (r *ratingUsers) GetRating(_ context.Context, _, songID uint) (*models.Rating, error) {
+	rating, ok := r.ratings[songID]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return &rating, nil
+}
+
+func (r *ratingUsers) SetRating(_ context.Context, rating *models.Rating) (*models.SongRating, error) {
+	r.ratings[rating.SongID] = *rating
+	return &models.SongRating{SongID: rating.SongID, Rating: rating.Rating}, nil
+}
+
+func (r *ratingUsers) DeleteRating(_ context.Context, _, songID uint) (*models.SongRating, error) {
+	delete(r.ratings, songID)
+	return &models.SongRating{SongID: songID}, nil
+}
+
+type memoryAudit struct {
+	AuditRepository
+	entries []models.AuditEntry
+}
+
+func (r *memoryAudit) CreateAuditEntry(_ context.Context, entry *models.AuditEntry) error {
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+// memoryUnitOfWork сохраняет записи журнала, только если fn завершилась без ошибки
+type memoryUnitOfWork struct {
+	users UserRepository
+	audit *memoryAudit
+}
+
+func (u memoryUnitOfWork) Do(_ context.Context, fn func(repos Repositories) error) error {
+	staged := &memoryAudit{}
+	if err := fn(Repositories{Users: u.users, Audit: staged}); err != nil {
+		return err
+	}
+	u.audit.entries = append(u.audit.entries, staged.entries...)
+	return nil
+}
+
+func TestRatingChangesAreAudited(t *testing.T) {
+	audit := &memoryAudit{}
+	uow := memoryUnitOfWork{users: &ratingUsers{ratings: make(map[uint]models.Rating)}, audit: audit}
+	users := NewUserService(nil, audit, uow, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+	ctx := context.Background()
+
+	if _, err := users.RateSong(ctx, 1, 2, 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.RateSong(ctx, 1, 2, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.DeleteRating(ctx, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.DeleteRating(ctx, 1, 2); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("DeleteRating() error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	want := []string{models.AuditCreate, models.AuditUpdate, models.AuditDelete}
+	if len(audit.entries) != len(want) {
+		t.Fatalf("got %d audit entries, want %d", len(audit.entries), len(want))
+	}
+	for i, entry := range audit.entries {
+		if entry.Action != want[i] || entry.Resource != models.AuditResourceRating || entry.ResourceID != "1/2" {
+			t.Errorf("entry %d = %s %s %s, want %s rating 1/2", i, entry.Action, entry.Resource, entry.ResourceID, want[i])
+		}
+	}
+}
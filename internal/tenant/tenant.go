@@ -0,0 +1,17 @@
+package tenant
+
+import "context"
+
+type key struct{}
+
+// WithID возвращает контекст с id арендатора, к данным которого обращается запрос
+func WithID(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// FromContext возвращает id арендатора запроса или 0, если арендатор не определен. Арендатора с id 0 нет,
+// поэтому запросы без арендатора не видят ничьих данных
+func FromContext(ctx context.Context) uint {
+	id, _ := ctx.Value(key{}).(uint)
+	return id
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// Cache - потокобезопасный кэш в памяти с временем жизни записей. При ttl = 0 записи не устаревают.
+// Если задан maxEntries, при переполнении вытесняется запись, к которой дольше всего не обращались
+type Cache[K comparable, V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	// order - записи от недавно использованных к давно использованным
+	order   *list.List
+	entries map[K]*list.Element
+}
+
+// New создает кэш без ограничения количества записей
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return NewLRU[K, V](ttl, 0)
+}
+
+// NewLRU создает кэш, в котором хранится не больше maxEntries записей. При maxEntries = 0 количество не ограничено
+func NewLRU[K comparable, V any](ttl time.Duration, maxEntries int) *Cache[K, V] {
+	return &Cache[K, V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[K]*list.Element),
+	}
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	element, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	e := element.Value.(*entry[K, V])
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		c.remove(element)
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &entry[K, V]{key: key, value: value}
+	if c.ttl > 0 {
+		e.expiresAt = time.Now().Add(c.ttl)
+	}
+	if element, ok := c.entries[key]; ok {
+		element.Value = e
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(e)
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// Clear удаляет все записи
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[K]*list.Element)
+}
+
+// Len возвращает количество записей, включая устаревшие, которые еще не были вытеснены
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache[K, V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry[K, V]).key)
+}
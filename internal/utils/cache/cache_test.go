@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[string, int](0, 2)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a is missing")
+	}
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := c.Get(key); !ok || got != want {
+			t.Errorf("Get(%q) = %d, %t, want %d, true", key, got, ok, want)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestExpiredEntryIsRemoved(t *testing.T) {
+	c := New[string, int](time.Millisecond)
+	c.Set("a", 1)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("expired entry was returned")
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", c.Len())
+	}
+}
@@ -0,0 +1,218 @@
+package chordpro
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testEffectiveMobile/internal/models"
+)
+
+var (
+	// directiveRe - директива вида {name} или {name: value}
+	directiveRe = regexp.MustCompile(`^\{\s*([a-zA-Z_]+)\s*(?::(.*))?\}$`)
+	// chordRe - аккорд: основной тон, необязательные знак альтерации и обозначение типа, бас после /
+	chordRe = regexp.MustCompile(`^([A-G])([#b]?)([a-zA-Z0-9#+\-()°ø]*)(?:/([A-G])([#b]?))?$`)
+)
+
+// noChord - обозначение паузы в аккомпанементе
+const noChord = "N.C."
+
+// sectionDirectives сопоставляет директивам начала и конца части песни ее тип
+var sectionDirectives = map[string]struct {
+	section string
+	start   bool
+}{
+	"start_of_chorus": {models.SectionChorus, true},
+	"soc":             {models.SectionChorus, true},
+	"end_of_chorus":   {models.SectionChorus, false},
+	"eoc":             {models.SectionChorus, false},
+	"start_of_verse":  {models.SectionVerse, true},
+	"sov":             {models.SectionVerse, true},
+	"end_of_verse":    {models.SectionVerse, false},
+	"eov":             {models.SectionVerse, false},
+	"start_of_bridge": {models.SectionBridge, true},
+	"sob":             {models.SectionBridge, true},
+	"end_of_bridge":   {models.SectionBridge, false},
+	"eob":             {models.SectionBridge, false},
+}
+
+// ParseError описывает ошибку в конкретной строке текста ChordPro
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Parse разбирает текст в формате ChordPro: аккорды в квадратных скобках внутри строк ([Am]текст),
+// директивы title, artist, key, comment и директивы начала и конца куплета, припева и бриджа.
+// Остальные директивы и строки, начинающиеся с #, пропускаются
+func Parse(text string) (*models.Chords, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	sheet := &models.Chords{Lines: []models.ChordLine{}}
+	section, sectionStart := "", 0
+	lines := strings.Split(text, "\n")
+	for i, raw := range lines {
+		lineNumber := i + 1
+		raw = strings.TrimRight(raw, " \t")
+		if strings.HasPrefix(raw, "#") {
+			continue
+		}
+		if match := directiveRe.FindStringSubmatch(strings.TrimSpace(raw)); match != nil {
+			name, value := strings.ToLower(match[1]), strings.TrimSpace(match[2])
+			if directive, ok := sectionDirectives[name]; ok {
+				switch {
+				case directive.start && section != "":
+					return nil, &ParseError{Line: lineNumber, Message: fmt.Sprintf("%s started inside %s", directive.section, section)}
+				case directive.start:
+					section, sectionStart = directive.section, lineNumber
+				case section != directive.section:
+					return nil, &ParseError{Line: lineNumber, Message: fmt.Sprintf("end of %s without start", directive.section)}
+				default:
+					section = ""
+				}
+				continue
+			}
+			switch name {
+			case "title", "t":
+				sheet.Title = value
+			case "artist":
+				sheet.Artist = value
+			case "key":
+				if !chordRe.MatchString(value) {
+					return nil, &ParseError{Line: lineNumber, Message: fmt.Sprintf("invalid key %q", value)}
+				}
+				sheet.Key = value
+			case "comment", "c", "comment_italic", "ci":
+				sheet.Lines = append(sheet.Lines, models.ChordLine{Section: section, Comment: true, Text: value, Chords: []models.ChordPosition{}})
+			}
+			continue
+		}
+		line, err := parseLine(raw)
+		if err != nil {
+			return nil, &ParseError{Line: lineNumber, Message: err.Error()}
+		}
+		line.Section = section
+		sheet.Lines = append(sheet.Lines, line)
+	}
+	if section != "" {
+		return nil, &ParseError{Line: sectionStart, Message: fmt.Sprintf("%s is not closed", section)}
+	}
+	sheet.Lines = trimEmptyLines(sheet.Lines)
+	return sheet, nil
+}
+
+// parseLine выделяет из строки аккорды и их позиции в тексте без аккордов
+func parseLine(raw string) (models.ChordLine, error) {
+	line := models.ChordLine{Chords: []models.ChordPosition{}}
+	var text []rune
+	runes := []rune(raw)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' && runes[end] != '[' {
+				end++
+			}
+			if end == len(runes) || runes[end] == '[' {
+				return line, fmt.Errorf("unclosed chord bracket at position %d", i+1)
+			}
+			chord := strings.TrimSpace(string(runes[i+1 : end]))
+			if !IsChord(chord) {
+				return line, fmt.Errorf("invalid chord %q", chord)
+			}
+			line.Chords = append(line.Chords, models.ChordPosition{Chord: chord, Position: len(text)})
+			i = end
+		case ']':
+			return line, fmt.Errorf("unexpected ] at position %d", i+1)
+		default:
+			text = append(text, runes[i])
+		}
+	}
+	line.Text = string(text)
+	return line, nil
+}
+
+// IsChord сообщает, является ли строка записью аккорда (Am, F#m7, Bb/D, N.C.)
+func IsChord(chord string) bool {
+	return chord == noChord || chordRe.MatchString(chord)
+}
+
+// trimEmptyLines убирает пустые строки в начале и в конце текста
+func trimEmptyLines(lines []models.ChordLine) []models.ChordLine {
+	isEmpty := func(line models.ChordLine) bool {
+		return line.Text == "" && len(line.Chords) == 0
+	}
+	for len(lines) > 0 && isEmpty(lines[0]) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && isEmpty(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Render выводит текст песни с аккордами над строками. Если аккорды не помещаются над своими слогами,
+// в строку текста добавляются пробелы, чтобы каждый аккорд остался над своим местом
+func Render(sheet *models.Chords) string {
+	var b strings.Builder
+	if sheet.Title != "" {
+		b.WriteString(sheet.Title + "\n")
+	}
+	if sheet.Artist != "" {
+		b.WriteString(sheet.Artist + "\n")
+	}
+	if sheet.Key != "" {
+		b.WriteString("Key: " + sheet.Key + "\n")
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	section := ""
+	for _, line := range sheet.Lines {
+		if line.Section != section && line.Section != "" {
+			b.WriteString("[" + strings.ToUpper(line.Section[:1]) + line.Section[1:] + "]\n")
+		}
+		section = line.Section
+		if line.Comment {
+			b.WriteString("(" + line.Text + ")\n")
+			continue
+		}
+		if len(line.Chords) == 0 {
+			b.WriteString(line.Text + "\n")
+			continue
+		}
+		chords, text := renderLine(line)
+		b.WriteString(chords + "\n")
+		if text != "" {
+			b.WriteString(text + "\n")
+		}
+	}
+	return b.String()
+}
+
+// renderLine возвращает строку аккордов и выровненную под нее строку текста
+func renderLine(line models.ChordLine) (string, string) {
+	var chords, text []rune
+	runes := []rune(line.Text)
+	cursor := 0
+	for _, chord := range line.Chords {
+		position := min(chord.Position, len(runes))
+		text = append(text, runes[cursor:position]...)
+		cursor = position
+		// Между соседними аккордами должен остаться хотя бы один пробел
+		if len(chords) > 0 {
+			for len(text) < len(chords)+1 {
+				text = append(text, ' ')
+			}
+		}
+		for len(chords) < len(text) {
+			chords = append(chords, ' ')
+		}
+		chords = append(chords, []rune(chord.Chord)...)
+	}
+	text = append(text, runes[cursor:]...)
+	return string(chords), strings.TrimRight(string(text), " ")
+}
@@ -0,0 +1,104 @@
+package chordpro
+
+import (
+	"strings"
+	"testEffectiveMobile/internal/models"
+)
+
+var (
+	sharpNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	flatNames  = [12]string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+	// flatMajorKeys - мажорные тональности с бемолями при ключе (F, Bb, Eb, Ab, Db). Gb записывается как F#
+	flatMajorKeys = map[int]bool{1: true, 3: true, 5: true, 8: true, 10: true}
+	naturals      = map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+)
+
+// Transpose транспонирует все аккорды и тональность на semitones полутонов. Знаки альтерации выбираются
+// по новой тональности: бемоли для бемольных тональностей, иначе диезы. Тональность берется из директивы key,
+// а если ее нет - по первому аккорду
+func Transpose(sheet *models.Chords, semitones int) {
+	sheet.Transpose = semitones
+	if semitones%12 == 0 {
+		return
+	}
+	key := sheet.Key
+	if key == "" {
+		key = firstChord(sheet)
+	}
+	flats := usesFlats(key, semitones)
+	if sheet.Key != "" {
+		sheet.Key = TransposeChord(sheet.Key, semitones, flats)
+	}
+	for i := range sheet.Lines {
+		for j := range sheet.Lines[i].Chords {
+			chord := &sheet.Lines[i].Chords[j]
+			chord.Chord = TransposeChord(chord.Chord, semitones, flats)
+		}
+	}
+}
+
+// TransposeChord транспонирует аккорд и его бас на semitones полутонов. flats выбирает запись через бемоли.
+// Строки, не являющиеся аккордом, и N.C. возвращаются без изменений
+func TransposeChord(chord string, semitones int, flats bool) string {
+	match := chordRe.FindStringSubmatch(chord)
+	if match == nil {
+		return chord
+	}
+	result := noteName(pitch(match[1], match[2])+semitones, flats) + match[3]
+	if match[4] != "" {
+		result += "/" + noteName(pitch(match[4], match[5])+semitones, flats)
+	}
+	return result
+}
+
+// usesFlats определяет, пишется ли тональность key после транспонирования через бемоли.
+// Минорная тональность пишется так же, как параллельный мажор
+func usesFlats(key string, semitones int) bool {
+	match := chordRe.FindStringSubmatch(key)
+	if match == nil {
+		return false
+	}
+	tonic := pitch(match[1], match[2]) + semitones
+	if isMinor(match[3]) {
+		tonic += 3
+	}
+	return flatMajorKeys[mod12(tonic)]
+}
+
+// isMinor сообщает, обозначает ли тип аккорда минор (m, m7, min), но не мажорный септаккорд (maj7)
+func isMinor(quality string) bool {
+	return strings.HasPrefix(quality, "m") && !strings.HasPrefix(quality, "maj")
+}
+
+func firstChord(sheet *models.Chords) string {
+	for _, line := range sheet.Lines {
+		for _, chord := range line.Chords {
+			if chord.Chord != noChord {
+				return chord.Chord
+			}
+		}
+	}
+	return ""
+}
+
+func pitch(note, accidental string) int {
+	value := naturals[note[0]]
+	switch accidental {
+	case "#":
+		value++
+	case "b":
+		value--
+	}
+	return mod12(value)
+}
+
+func noteName(value int, flats bool) string {
+	if flats {
+		return flatNames[mod12(value)]
+	}
+	return sharpNames[mod12(value)]
+}
+
+func mod12(value int) int {
+	return (value%12 + 12) % 12
+}
@@ -0,0 +1,90 @@
+package chordpro
+
+import (
+	"testEffectiveMobile/internal/models"
+	"testing"
+)
+
+func TestTransposeChord(t *testing.T) {
+	tests := []struct {
+		chord     string
+		semitones int
+		flats     bool
+		want      string
+	}{
+		{"C", 2, false, "D"},
+		{"C", 0, false, "C"},
+		{"Am", 3, false, "Cm"},
+		{"F#m7", -1, false, "Fm7"},
+		{"Bb/D", 2, false, "C/E"},
+		{"G", 1, false, "G#"},
+		{"G", 1, true, "Ab"},
+		{"Dsus4", 1, true, "Ebsus4"},
+		{"Cmaj7/E", 1, true, "Dbmaj7/F"},
+		{"B", 1, true, "C"},
+		{"Cb", 0, false, "B"},
+		{"E#", 0, false, "F"},
+		// Сдвиги больше октавы и вниз сводятся к 12 полутонам
+		{"C", -14, false, "A#"},
+		{"C", 26, true, "D"},
+		{"Eb7", 12, false, "D#7"},
+		{"N.C.", 2, false, "N.C."},
+		{"Hm", 2, false, "Hm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chord, func(t *testing.T) {
+			if got := TransposeChord(tt.chord, tt.semitones, tt.flats); got != tt.want {
+				t.Errorf("TransposeChord(%q, %d, %t) = %q, want %q", tt.chord, tt.semitones, tt.flats, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransposeChoosesAccidentalsByKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		chords    []string
+		semitones int
+		wantKey   string
+		want      []string
+	}{
+		{name: "C to F uses flats", key: "C", chords: []string{"C", "A#", "G7"}, semitones: 5,
+			wantKey: "F", want: []string{"F", "Eb", "C7"}},
+		{name: "G to Bb uses flats", key: "G", chords: []string{"G", "D/F#", "Em"}, semitones: 3,
+			wantKey: "Bb", want: []string{"Bb", "F/A", "Gm"}},
+		{name: "D to F# uses sharps", key: "D", chords: []string{"D", "Bm", "Gmaj7"}, semitones: 4,
+			wantKey: "F#", want: []string{"F#", "D#m", "Bmaj7"}},
+		{name: "E to Gb is written as F#", key: "E", chords: []string{"E", "B7"}, semitones: 2,
+			wantKey: "F#", want: []string{"F#", "C#7"}},
+		{name: "Ab to A uses sharps", key: "Ab", chords: []string{"Ab", "Db", "Eb7"}, semitones: 1,
+			wantKey: "A", want: []string{"A", "D", "E7"}},
+		{name: "minor key follows relative major", key: "Em", chords: []string{"Em", "C", "D"}, semitones: 1,
+			wantKey: "Fm", want: []string{"Fm", "Db", "Eb"}},
+		{name: "minor key with sharps", key: "Am", chords: []string{"Am", "F", "G"}, semitones: 2,
+			wantKey: "Bm", want: []string{"Bm", "G", "A"}},
+		{name: "key from first chord", chords: []string{"N.C.", "F", "C"}, semitones: -2,
+			want: []string{"N.C.", "Eb", "Bb"}},
+		{name: "octave keeps chords", key: "C#", chords: []string{"C#", "F#"}, semitones: -12,
+			wantKey: "C#", want: []string{"C#", "F#"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := models.ChordLine{}
+			for _, chord := range tt.chords {
+				line.Chords = append(line.Chords, models.ChordPosition{Chord: chord})
+			}
+			sheet := &models.Chords{Key: tt.key, Lines: []models.ChordLine{line}}
+
+			Transpose(sheet, tt.semitones)
+			if sheet.Key != tt.wantKey || sheet.Transpose != tt.semitones {
+				t.Errorf("key = %q, transpose = %d, want %q and %d", sheet.Key, sheet.Transpose, tt.wantKey, tt.semitones)
+			}
+			for i, chord := range sheet.Lines[0].Chords {
+				if chord.Chord != tt.want[i] {
+					t.Errorf("chord %d = %q, want %q", i, chord.Chord, tt.want[i])
+				}
+			}
+		})
+	}
+}
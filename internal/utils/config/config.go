@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
+	"time"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Logger   LoggerConfig
-	Server   ServerConfig
+	Database  DatabaseConfig
+	Logger    LoggerConfig
+	Server    ServerConfig
+	Content   ContentConfig
+	Auth      AuthConfig
+	RateLimit RateLimitConfig
+	CORS      CORSConfig
 }
 
 type DatabaseConfig struct {
@@ -26,6 +31,75 @@ type LoggerConfig struct {
 
 type ServerConfig struct {
 	Port string `env:"PORT"`
+	// RequestTimeout ограничивает время обработки одного запроса, включая запросы к БД и внешнему API
+	RequestTimeout time.Duration `env:"REQUEST_TIMEOUT" envDefault:"10s"`
+	// IdempotencyTTL - время хранения ключей идемпотентности и ответов на запросы с ними
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+	// ShutdownTimeout - время на завершение активных запросов при остановке сервера
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"5s"`
+	// MaxBodySize - наибольший размер тела запроса в байтах, запросы больше отклоняются с 413
+	MaxBodySize int64 `env:"MAX_BODY_SIZE" envDefault:"1048576"`
+	// HSTSMaxAge - срок заголовка Strict-Transport-Security. 0 не отправляет заголовок, например если TLS
+	// не завершается перед сервисом
+	HSTSMaxAge time.Duration `env:"HSTS_MAX_AGE" envDefault:"0s"`
+}
+
+type ContentConfig struct {
+	// ExplicitWordsDir - каталог со списками слов для определения откровенного содержания. Если не задан,
+	// используются встроенные списки
+	ExplicitWordsDir string `env:"EXPLICIT_WORDS_DIR"`
+}
+
+type AuthConfig struct {
+	// Enabled включает проверку учетных данных на всех маршрутах, кроме документации
+	Enabled bool `env:"AUTH_ENABLED" envDefault:"true"`
+	// AdminKey - ключ для первоначальной настройки (создания ключей API), в базе не хранится
+	AdminKey string `env:"AUTH_ADMIN_KEY"`
+	// RolePermissions - разрешения ролей в виде role=perm1,perm2;role2=perm3
+	RolePermissions string `env:"AUTH_ROLE_PERMISSIONS" envDefault:"reader=songs:read,songs:suggest;editor=songs:read,songs:suggest,songs:write,songs:moderate;admin=*"`
+	// JWTAlgorithm - HS256 или RS256. Если не задан, JWT не принимаются
+	JWTAlgorithm string `env:"JWT_ALGORITHM"`
+	// JWTSecret - секрет для HS256, JWTPublicKeyFile - PEM-файл открытого ключа для RS256.
+	// JWTPrivateKeyFile нужен для RS256, чтобы выдавать токены пользователям при входе
+	JWTSecret         string `env:"JWT_SECRET"`
+	JWTPublicKeyFile  string `env:"JWT_PUBLIC_KEY_FILE"`
+	JWTPrivateKeyFile string `env:"JWT_PRIVATE_KEY_FILE"`
+	// JWTIssuer и JWTAudience проверяются в токене, если заданы
+	JWTIssuer   string `env:"JWT_ISSUER"`
+	JWTAudience string `env:"JWT_AUDIENCE"`
+	// JWTTTL - срок действия токенов, выдаваемых пользователям при входе
+	JWTTTL time.Duration `env:"JWT_TTL" envDefault:"1h"`
+	// DefaultTenant - арендатор запросов клиентов, не привязанных к арендатору и не передавших заголовок X-Tenant.
+	// Если пустой, такие запросы отклоняются
+	DefaultTenant string `env:"AUTH_DEFAULT_TENANT" envDefault:"default"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `env:"RATE_LIMIT_ENABLED" envDefault:"true"`
+	// Store - хранилище счетчиков: memory для одного экземпляра или postgres для нескольких
+	Store  string        `env:"RATE_LIMIT_STORE" envDefault:"memory"`
+	Window time.Duration `env:"RATE_LIMIT_WINDOW" envDefault:"1m"`
+	// ReadRequests и WriteRequests - количество запросов клиента за окно к читающим и изменяющим маршрутам
+	ReadRequests  int `env:"RATE_LIMIT_READ" envDefault:"300"`
+	WriteRequests int `env:"RATE_LIMIT_WRITE" envDefault:"30"`
+	// AuthRequests - количество запросов с одного IP-адреса за окно ко всем маршрутам с аутентификацией,
+	// считается до проверки учетных данных. LoginRequests - то же для входа и регистрации
+	AuthRequests  int `env:"RATE_LIMIT_AUTH" envDefault:"600"`
+	LoginRequests int `env:"RATE_LIMIT_LOGIN" envDefault:"10"`
+	// FailOpen пропускает запросы без ограничения, если хранилище счетчиков недоступно. Если false,
+	// такие запросы отклоняются с 503
+	FailOpen bool `env:"RATE_LIMIT_FAIL_OPEN" envDefault:"true"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins - источники браузерных клиентов через запятую, * разрешает любой. Если пустой, CORS отключен
+	AllowedOrigins []string `env:"CORS_ALLOWED_ORIGINS" envSeparator:","`
+	AllowedMethods []string `env:"CORS_ALLOWED_METHODS" envSeparator:"," envDefault:"GET,HEAD,POST,PUT,PATCH,DELETE"`
+	AllowedHeaders []string `env:"CORS_ALLOWED_HEADERS" envSeparator:"," envDefault:"Authorization,Content-Type,X-API-Key,X-Tenant,X-Request-Id,Idempotency-Key"`
+	// ExposedHeaders - заголовки ответа, доступные скриптам клиента
+	ExposedHeaders []string `env:"CORS_EXPOSED_HEADERS" envSeparator:"," envDefault:"X-Request-Id,Retry-After,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Idempotent-Replayed,X-Pagination-Mode,X-Page,X-Page-Size,X-Total-Pages,X-Total-Verses,X-Total-Lines"`
+	// MaxAge - время, на которое браузер кэширует ответ на предварительный запрос
+	MaxAge time.Duration `env:"CORS_MAX_AGE" envDefault:"10m"`
 }
 
 // MustLoad загружает конфигурацию из файла .env или выдаёт панику
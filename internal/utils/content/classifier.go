@@ -0,0 +1,118 @@
+package content
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"testEffectiveMobile/internal/utils/textstats"
+)
+
+//go:embed wordlists/*.txt
+var defaultLists embed.FS
+
+// wordList - список нежелательных слов одного языка
+type wordList struct {
+	words    map[string]struct{}
+	prefixes []string
+}
+
+// Classifier ищет в тексте слова из списков нежелательных слов
+type Classifier struct {
+	lists map[string]*wordList
+}
+
+// MustLoadClassifier загружает списки слов из каталога dir (файлы <язык>.txt) или встроенные списки,
+// если каталог не задан. Выдает панику при ошибке чтения
+func MustLoadClassifier(dir string) *Classifier {
+	var fsys fs.FS = defaultLists
+	root := "wordlists"
+	if dir != "" {
+		fsys, root = os.DirFS(dir), "."
+	}
+	classifier, err := LoadClassifier(fsys, root)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load explicit word lists: %s", err.Error()))
+	}
+	return classifier
+}
+
+// LoadClassifier читает файлы <язык>.txt из каталога root. Пустые строки и строки, начинающиеся с #, пропускаются,
+// * в конце слова означает совпадение по началу слова
+func LoadClassifier(fsys fs.FS, root string) (*Classifier, error) {
+	files, err := fs.Glob(fsys, path.Join(root, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	classifier := &Classifier{lists: make(map[string]*wordList)}
+	for _, file := range files {
+		f, err := fsys.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		list := &wordList{words: make(map[string]struct{})}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if word == "" || strings.HasPrefix(word, "#") {
+				continue
+			}
+			if prefix, ok := strings.CutSuffix(word, "*"); ok {
+				list.prefixes = append(list.prefixes, prefix)
+				continue
+			}
+			list.words[word] = struct{}{}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		classifier.lists[strings.TrimSuffix(path.Base(file), ".txt")] = list
+	}
+	return classifier, nil
+}
+
+// Classify возвращает true, если в тексте найдено хотя бы одно нежелательное слово, и найденные слова
+// с количеством употреблений. Если languages не пуст, проверяются только списки этих языков
+func (c *Classifier) Classify(text string, languages ...string) (bool, []models.ContentFlagReason) {
+	counts := make(map[[2]string]int)
+	for _, word := range textstats.Words(text) {
+		for language, list := range c.lists {
+			if len(languages) > 0 && !slices.Contains(languages, language) {
+				continue
+			}
+			if list.matches(word) {
+				counts[[2]string{language, word}]++
+			}
+		}
+	}
+	reasons := make([]models.ContentFlagReason, 0, len(counts))
+	for key, count := range counts {
+		reasons = append(reasons, models.ContentFlagReason{Language: key[0], Word: key[1], Count: count})
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].Count != reasons[j].Count {
+			return reasons[i].Count > reasons[j].Count
+		}
+		return reasons[i].Word < reasons[j].Word
+	})
+	return len(reasons) > 0, reasons
+}
+
+func (l *wordList) matches(word string) bool {
+	if _, ok := l.words[word]; ok {
+		return true
+	}
+	for _, prefix := range l.prefixes {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testEffectiveMobile/internal/models"
+)
+
+// Store хранит ключи идемпотентности и ответы на запросы
+type Store interface {
+	// Begin сохраняет новую запись о запросе. Если неустаревшая запись с таким ключом уже есть,
+	// возвращает ее и false
+	Begin(ctx context.Context, record *models.IdempotencyKey) (*models.IdempotencyKey, bool, error)
+	// Complete сохраняет ответ на запрос
+	Complete(ctx context.Context, record *models.IdempotencyKey) error
+	// Delete удаляет запись, чтобы запрос с тем же ключом можно было повторить
+	Delete(ctx context.Context, key string) error
+}
+
+// Fingerprint возвращает отпечаток запроса: метод, путь и тело
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,137 @@
+package langdetect
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+	"unicode"
+)
+
+//go:embed profiles/*.txt
+var defaultProfiles embed.FS
+
+const (
+	// maxNGram - наибольшая длина n-граммы, учитываемой при определении языка
+	maxNGram = 3
+	// minLetters - минимальное количество букв в тексте, при котором язык определяется
+	minLetters = 20
+	// sampleLength ограничивает количество n-грамм текста, по которым считается уверенность,
+	// чтобы на длинных текстах она не становилась равной 1 при небольшом перевесе одного языка
+	sampleLength = 30
+)
+
+// profile - частоты n-грамм одного языка
+type profile struct {
+	counts map[string]int
+	total  int
+}
+
+// Detector определяет язык текста по частотам буквенных n-грамм (наивный байесовский классификатор)
+type Detector struct {
+	profiles map[string]*profile
+	// vocabulary - количество различных n-грамм во всех профилях, используется для сглаживания
+	vocabulary int
+}
+
+// MustLoadDetector строит детектор по встроенным образцам текстов. Выдает панику при ошибке чтения
+func MustLoadDetector() *Detector {
+	detector, err := LoadDetector(defaultProfiles, "profiles")
+	if err != nil {
+		panic(fmt.Sprintf("failed to load language profiles: %s", err.Error()))
+	}
+	return detector
+}
+
+// LoadDetector строит профили языков по образцам текстов <язык>.txt из каталога root.
+// Строки, начинающиеся с #, пропускаются
+func LoadDetector(fsys fs.FS, root string) (*Detector, error) {
+	files, err := fs.Glob(fsys, path.Join(root, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	detector := &Detector{profiles: make(map[string]*profile)}
+	vocabulary := make(map[string]struct{})
+	for _, file := range files {
+		f, err := fsys.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		p := &profile{counts: make(map[string]int)}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			for _, gram := range nGrams(line) {
+				p.counts[gram]++
+				p.total++
+				vocabulary[gram] = struct{}{}
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		detector.profiles[strings.TrimSuffix(path.Base(file), ".txt")] = p
+	}
+	detector.vocabulary = len(vocabulary) + 1
+	return detector, nil
+}
+
+// Detect возвращает код языка текста и уверенность от 0 до 1. Для слишком коротких текстов
+// и текстов без букв возвращает пустой код и нулевую уверенность
+func (d *Detector) Detect(text string) (string, float64) {
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	grams := nGrams(text)
+	if letters < minLetters || len(grams) == 0 || len(d.profiles) == 0 {
+		return "", 0
+	}
+	scores := make(map[string]float64, len(d.profiles))
+	best := ""
+	for language, p := range d.profiles {
+		score := 0.0
+		for _, gram := range grams {
+			score += math.Log(float64(p.counts[gram]+1) / float64(p.total+d.vocabulary))
+		}
+		// Средняя оценка на n-грамму, приведенная к длине sampleLength
+		scores[language] = score / float64(len(grams)) * float64(min(len(grams), sampleLength))
+		if best == "" || scores[language] > scores[best] || scores[language] == scores[best] && language < best {
+			best = language
+		}
+	}
+	sum := 0.0
+	for _, score := range scores {
+		sum += math.Exp(score - scores[best])
+	}
+	return best, math.Round(100/sum) / 100
+}
+
+// nGrams возвращает n-граммы длиной от 1 до maxNGram для каждого слова текста, дополненного пробелами по краям
+func nGrams(text string) []string {
+	var grams []string
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= maxNGram; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				gram := string(runes[i : i+n])
+				if gram != " " {
+					grams = append(grams, gram)
+				}
+			}
+		}
+	}
+	return grams
+}
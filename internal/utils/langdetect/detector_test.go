@@ -0,0 +1,78 @@
+package langdetect
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestDetectEmbeddedLanguages(t *testing.T) {
+	detector := MustLoadDetector()
+	tests := []struct {
+		language string
+		text     string
+	}{
+		{"en", "I was walking down the street tonight, thinking about the time we spent together"},
+		{"ru", "Я шел по улице сегодня вечером и думал о времени, которое мы провели вместе"},
+		{"uk", "Я йшов вулицею сьогодні ввечері і думав про час, який ми провели разом"},
+		{"de", "Ich ging heute Abend die Straße entlang und dachte an die Zeit, die wir zusammen verbracht haben"},
+		{"fr", "Je marchais dans la rue ce soir en pensant au temps que nous avons passé ensemble"},
+		{"es", "Caminaba por la calle esta noche pensando en el tiempo que pasamos juntos"},
+		{"it", "Camminavo per la strada stasera pensando al tempo che abbiamo passato insieme"},
+		{"pt", "Eu estava andando pela rua esta noite pensando no tempo que passamos juntos"},
+	}
+	if len(detector.profiles) != len(tests) {
+		t.Fatalf("detector has %d profiles, test covers %d languages", len(detector.profiles), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			language, confidence := detector.Detect(tt.text)
+			if language != tt.language {
+				t.Errorf("Detect() = %q (%.2f), want %q", language, confidence, tt.language)
+			}
+			if confidence < 0.5 || confidence > 1 {
+				t.Errorf("confidence = %.2f, want between 0.5 and 1", confidence)
+			}
+		})
+	}
+}
+
+func TestDetectShortText(t *testing.T) {
+	detector := MustLoadDetector()
+	// Меньше minLetters букв или совсем без букв - язык не определяется
+	for _, text := range []string{"", "la la la", "Hey, yeah!", "1234567890 1234567890 !!! ???"} {
+		if language, confidence := detector.Detect(text); language != "" || confidence != 0 {
+			t.Errorf("Detect(%q) = %q, %.2f, want no language", text, language, confidence)
+		}
+	}
+}
+
+func TestDetectAmbiguousText(t *testing.T) {
+	detector := MustLoadDetector()
+	// Слова, которые пишутся одинаково в нескольких языках, не дают уверенного ответа
+	language, ambiguous := detector.Detect("idea normal total final idea normal")
+	_, clear := detector.Detect("Caminaba por la calle esta noche pensando en el tiempo que pasamos juntos")
+	if ambiguous >= 0.8 || ambiguous >= clear {
+		t.Errorf("Detect() = %q, %.2f, want confidence below 0.8 and %.2f", language, ambiguous, clear)
+	}
+}
+
+func TestLoadDetector(t *testing.T) {
+	fsys := fstest.MapFS{
+		"p/aa.txt": {Data: []byte("# comment zzzz\naaaa aaaa aaaa\n")},
+		"p/bb.txt": {Data: []byte("bbbb bbbb bbbb\n")},
+		"p/cc.md":  {Data: []byte("cccc\n")},
+	}
+	detector, err := LoadDetector(fsys, "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detector.profiles) != 2 {
+		t.Fatalf("profiles = %d, want 2", len(detector.profiles))
+	}
+	if detector.profiles["aa"].counts["z"] != 0 {
+		t.Error("comment line is counted")
+	}
+	if language, _ := detector.Detect("bbbb bbbb bbbb bbbb bbbb bbbb"); language != "bb" {
+		t.Errorf("Detect() = %q, want bb", language)
+	}
+}
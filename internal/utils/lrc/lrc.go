@@ -0,0 +1,134 @@
+package lrc
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testEffectiveMobile/internal/models"
+)
+
+var (
+	// timestampRe - метка времени [mm:ss], [mm:ss.xx] или [mm:ss.xxx] в начале строки
+	timestampRe = regexp.MustCompile(`^\[(\d{1,3}):(\d{2})(?:[.:](\d{1,3}))?\]`)
+	// tagRe - ID-тег вида [ar:Artist]
+	tagRe = regexp.MustCompile(`^\[([a-zA-Z]+):(.*)\]$`)
+)
+
+// ParseError описывает ошибку в конкретной строке LRC-файла
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Parse разбирает текст в формате LRC. Поддерживаются несколько меток времени на одной строке
+// и тег [offset:±ms]. Метки времени строк должны идти по неубыванию
+func Parse(text string) ([]models.SyncedLine, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var (
+		lines    []models.SyncedLine
+		offset   int
+		previous = -1
+	)
+	for i, raw := range strings.Split(text, "\n") {
+		lineNumber := i + 1
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if !timestampRe.MatchString(raw) {
+			match := tagRe.FindStringSubmatch(raw)
+			if match == nil {
+				return nil, &ParseError{Line: lineNumber, Message: "expected timestamp or tag"}
+			}
+			if strings.EqualFold(match[1], "offset") {
+				value, err := strconv.Atoi(strings.TrimSpace(match[2]))
+				if err != nil {
+					return nil, &ParseError{Line: lineNumber, Message: "offset must be an integer number of milliseconds"}
+				}
+				offset = value
+			}
+			continue
+		}
+
+		var times []int
+		rest := raw
+		for {
+			match := timestampRe.FindStringSubmatch(rest)
+			if match == nil {
+				break
+			}
+			ms, err := toMs(match[1], match[2], match[3])
+			if err != nil {
+				return nil, &ParseError{Line: lineNumber, Message: err.Error()}
+			}
+			times = append(times, ms)
+			rest = rest[len(match[0]):]
+		}
+		if times[0] < previous {
+			return nil, &ParseError{Line: lineNumber, Message: fmt.Sprintf("timestamp %s is earlier than the previous line", Format(times[0]))}
+		}
+		previous = times[0]
+		for _, ms := range times {
+			lines = append(lines, models.SyncedLine{TimeMs: ms, Text: strings.TrimSpace(rest)})
+		}
+	}
+
+	// Положительный offset по спецификации LRC сдвигает все строки раньше
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].TimeMs < lines[j].TimeMs })
+	for i := range lines {
+		lines[i].TimeMs = max(lines[i].TimeMs-offset, 0)
+		lines[i].Position = i + 1
+		lines[i].Time = Format(lines[i].TimeMs)
+	}
+	return lines, nil
+}
+
+// Export собирает LRC-файл из строк. Если указаны исполнитель и название, они добавляются тегами ar и ti
+func Export(artist, title string, lines []models.SyncedLine) string {
+	var b strings.Builder
+	if artist != "" {
+		fmt.Fprintf(&b, "[ar:%s]\n", artist)
+	}
+	if title != "" {
+		fmt.Fprintf(&b, "[ti:%s]\n", title)
+	}
+	for _, line := range lines {
+		fmt.Fprintf(&b, "[%s]%s\n", Format(line.TimeMs), line.Text)
+	}
+	return b.String()
+}
+
+// Format переводит миллисекунды в метку времени mm:ss.xx
+func Format(ms int) string {
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, ms/1000%60, ms%1000/10)
+}
+
+// ParseTimestamp разбирает метку времени mm:ss или mm:ss.xx без квадратных скобок
+func ParseTimestamp(value string) (int, error) {
+	match := timestampRe.FindStringSubmatch("[" + value + "]")
+	if match == nil || len(match[0]) != len(value)+2 {
+		return 0, fmt.Errorf("timestamp must be in mm:ss or mm:ss.xx format")
+	}
+	return toMs(match[1], match[2], match[3])
+}
+
+func toMs(minutes, seconds, fraction string) (int, error) {
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.Atoi(seconds)
+	if s >= 60 {
+		return 0, fmt.Errorf("seconds must be less than 60")
+	}
+	ms := 0
+	if fraction != "" {
+		// .5 = 500 мс, .05 = 50 мс, .005 = 5 мс
+		f, _ := strconv.Atoi((fraction + "00")[:3])
+		ms = f
+	}
+	return (m*60+s)*1000 + ms, nil
+}
@@ -0,0 +1,148 @@
+package lrc
+
+import (
+	"errors"
+	"testEffectiveMobile/internal/models"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []models.SyncedLine
+	}{
+		{
+			name: "timestamps and tags",
+			text: "[ar:Muse]\r\n[ti:Uprising]\r\n[00:01.50] Paranoia is in bloom \r\n\r\n[01:02]The PR transmissions\r\n",
+			want: []models.SyncedLine{
+				{Position: 1, TimeMs: 1500, Time: "00:01.50", Text: "Paranoia is in bloom"},
+				{Position: 2, TimeMs: 62000, Time: "01:02.00", Text: "The PR transmissions"},
+			},
+		},
+		{
+			name: "fraction precision",
+			text: "[00:01.005]c\n[00:01.05]b\n[00:01.5]a\n[00:01:50]d",
+			want: []models.SyncedLine{
+				{Position: 1, TimeMs: 1005, Time: "00:01.00", Text: "c"},
+				{Position: 2, TimeMs: 1050, Time: "00:01.05", Text: "b"},
+				{Position: 3, TimeMs: 1500, Time: "00:01.50", Text: "a"},
+				{Position: 4, TimeMs: 1500, Time: "00:01.50", Text: "d"},
+			},
+		},
+		{
+			name: "several timestamps on one line",
+			text: "[00:10.00][00:30.00]Chorus\n[00:20.00]Verse",
+			want: []models.SyncedLine{
+				{Position: 1, TimeMs: 10000, Time: "00:10.00", Text: "Chorus"},
+				{Position: 2, TimeMs: 20000, Time: "00:20.00", Text: "Verse"},
+				{Position: 3, TimeMs: 30000, Time: "00:30.00", Text: "Chorus"},
+			},
+		},
+		{
+			name: "positive offset shifts lines earlier",
+			text: "[offset:+500]\n[00:00.20]a\n[00:01.00]b",
+			want: []models.SyncedLine{
+				{Position: 1, TimeMs: 0, Time: "00:00.00", Text: "a"},
+				{Position: 2, TimeMs: 500, Time: "00:00.50", Text: "b"},
+			},
+		},
+		{
+			name: "negative offset shifts lines later",
+			text: "[offset:-250]\n[00:01.00]a",
+			want: []models.SyncedLine{
+				{Position: 1, TimeMs: 1250, Time: "00:01.25", Text: "a"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("line %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		line int
+	}{
+		{name: "plain text", text: "[00:01.00]a\njust text", line: 2},
+		{name: "decreasing timestamps", text: "[00:02.00]a\n\n[00:01.00]b", line: 3},
+		{name: "seconds out of range", text: "[00:61.00]a", line: 1},
+		{name: "bad offset", text: "[offset:soon]", line: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.text)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse() error = %v, want *ParseError", err)
+			}
+			if parseErr.Line != tt.line {
+				t.Errorf("error line = %d, want %d", parseErr.Line, tt.line)
+			}
+		})
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	lines, err := Parse("[00:01.50]Paranoia is in bloom\n[01:02.00]The PR transmissions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported := Export("Muse", "Uprising", lines)
+	want := "[ar:Muse]\n[ti:Uprising]\n[00:01.50]Paranoia is in bloom\n[01:02.00]The PR transmissions\n"
+	if exported != want {
+		t.Fatalf("Export() = %q, want %q", exported, want)
+	}
+	again, err := Parse(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range lines {
+		if again[i] != lines[i] {
+			t.Errorf("line %d after round trip = %+v, want %+v", i, again[i], lines[i])
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "00:00", want: 0},
+		{value: "01:02", want: 62000},
+		{value: "01:02.5", want: 62500},
+		{value: "123:00.01", want: 7380010},
+		{value: "1:2", wantErr: true},
+		{value: "00:60", wantErr: true},
+		{value: "00:01]x[00:02", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimestamp(%q) error = %v, want error %t", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTimestamp(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package lyrics
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxLabelLength - длина колонки song_sections.label в символах. Более длинные подписи маркеров обрезаются
+const maxLabelLength = 255
+
+// markerRe разбирает содержимое маркера части: "Verse 2", "Chorus x2", "Bridge: Artist", "Припев"
+var markerRe = regexp.MustCompile(`^([\p{L}][\p{L}\- ]*?)\s*(\d+)?\s*(?:[xх×]\s*\d+)?\s*(?::.*)?$`)
+
+// sectionAliases сопоставляет названия частей (в нижнем регистре) с их типом
+var sectionAliases = map[string]string{
+	"verse":       models.SectionVerse,
+	"куплет":      models.SectionVerse,
+	"chorus":      models.SectionChorus,
+	"refrain":     models.SectionChorus,
+	"припев":      models.SectionChorus,
+	"pre-chorus":  models.SectionPreChorus,
+	"prechorus":   models.SectionPreChorus,
+	"pre chorus":  models.SectionPreChorus,
+	"предприпев":  models.SectionPreChorus,
+	"пред-припев": models.SectionPreChorus,
+	"bridge":      models.SectionBridge,
+	"бридж":       models.SectionBridge,
+	"intro":       models.SectionIntro,
+	"вступление":  models.SectionIntro,
+	"интро":       models.SectionIntro,
+	"outro":       models.SectionOutro,
+	"аутро":       models.SectionOutro,
+	"концовка":    models.SectionOutro,
+	"hook":        models.SectionHook,
+	"хук":         models.SectionHook,
+}
+
+// Normalize приводит переводы строк к \n, убирает пробелы в конце строк,
+// лишние пустые строки между частями и пустые строки в начале и конце текста
+func Normalize(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	lines := strings.Split(text, "\n")
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" && (len(result) == 0 || result[len(result)-1] == "") {
+			continue
+		}
+		result = append(result, line)
+	}
+	return strings.TrimRight(strings.Join(result, "\n"), "\n")
+}
+
+// Parse разбивает текст песни на части. Части разделяются пустыми строками или маркерами
+// вида [Chorus], [Verse 2], [Припев]. Часть без маркера считается очередным куплетом.
+// Маркер без текста (например, повторный [Chorus]) разворачивается в копию ранее встреченной части того же типа
+func Parse(text string) []models.LyricsSection {
+	var (
+		sections []models.LyricsSection
+		current  *models.LyricsSection
+		counters = map[string]int{}
+	)
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if len(current.Lines) == 0 {
+			// Маркер без текста ссылается на уже встречавшуюся часть
+			if prev := findPrevious(sections, current); prev != nil {
+				current.Lines = slices.Clone(prev.Lines)
+				current.Number = prev.Number
+				current.Repeat = true
+			}
+		} else if prev := findSameText(sections, current); prev != nil {
+			current.Number = prev.Number
+			current.Repeat = true
+		}
+		if len(current.Lines) > 0 {
+			if current.Number == 0 {
+				counters[current.Type]++
+				current.Number = counters[current.Type]
+			} else if current.Number > counters[current.Type] {
+				counters[current.Type] = current.Number
+			}
+			sections = append(sections, *current)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(Normalize(text), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		if section, ok := parseMarker(trimmed); ok {
+			flush()
+			current = &section
+			continue
+		}
+		if current == nil {
+			current = &models.LyricsSection{Type: models.SectionVerse}
+		}
+		current.Lines = append(current.Lines, trimmed)
+	}
+	flush()
+
+	for i := range sections {
+		sections[i].Position = i + 1
+		sections[i].Text = strings.Join(sections[i].Lines, "\n")
+	}
+	return sections
+}
+
+// Verses делит текст на куплеты по пустым строкам, как /verses отдавал их до разбора текста на части:
+// маркеры остаются строками куплета, а повторы не разворачиваются. Текст предварительно нормализуется,
+// поэтому переводы строк \r\n и несколько пустых строк подряд не дают пустых куплетов
+func Verses(text string) []string {
+	text = Normalize(text)
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n\n")
+}
+
+// parseMarker распознает строку-маркер вида [Verse 2]
+func parseMarker(line string) (models.LyricsSection, bool) {
+	if len(line) < 3 || line[0] != '[' || line[len(line)-1] != ']' {
+		return models.LyricsSection{}, false
+	}
+	label := strings.TrimSpace(line[1 : len(line)-1])
+	section := models.LyricsSection{Type: models.SectionOther, Label: truncate(label, maxLabelLength)}
+	match := markerRe.FindStringSubmatch(label)
+	if match == nil {
+		return section, true
+	}
+	if sectionType, ok := sectionAliases[strings.ToLower(strings.TrimSpace(match[1]))]; ok {
+		section.Type = sectionType
+	}
+	if match[2] != "" {
+		section.Number, _ = strconv.Atoi(match[2])
+	}
+	return section, true
+}
+
+// truncate обрезает s до n символов
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// findPrevious ищет последнюю часть того же типа (и номера, если он указан)
+func findPrevious(sections []models.LyricsSection, section *models.LyricsSection) *models.LyricsSection {
+	for i := len(sections) - 1; i >= 0; i-- {
+		if sections[i].Type == section.Type && (section.Number == 0 || sections[i].Number == section.Number) {
+			return &sections[i]
+		}
+	}
+	return nil
+}
+
+// findSameText ищет часть того же типа с таким же текстом, чтобы пометить повтор
+func findSameText(sections []models.LyricsSection, section *models.LyricsSection) *models.LyricsSection {
+	if section.Type == models.SectionVerse {
+		return nil
+	}
+	for i := range sections {
+		if sections[i].Type == section.Type && slices.Equal(sections[i].Lines, section.Lines) {
+			return &sections[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package lyrics
+
+import (
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseTruncatesLongLabels(t *testing.T) {
+	label := strings.Repeat("ж", maxLabelLength+10)
+	sections := Parse("[" + label + "]\nline")
+	if len(sections) != 1 {
+		t.Fatalf("got %d sections, want 1", len(sections))
+	}
+	if got := utf8.RuneCountInString(sections[0].Label); got != maxLabelLength {
+		t.Errorf("label length = %d, want %d", got, maxLabelLength)
+	}
+	if sections[0].Type != models.SectionOther || sections[0].Text != "line" {
+		t.Errorf("section = %+v, want other section with text %q", sections[0], "line")
+	}
+}
+
+func TestVersesKeepLegacySplit(t *testing.T) {
+	text := "[Chorus]\r\n  Indented line\r\nsecond line\r\n\r\n\r\nverse two\n\n[Chorus]"
+	want := []string{"[Chorus]\n  Indented line\nsecond line", "verse two", "[Chorus]"}
+	got := Verses(text)
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Verses() = %q, want %q", got, want)
+	}
+	if got := Verses(" \n\n "); got != nil {
+		t.Errorf("Verses() of blank text = %q, want nil", got)
+	}
+}
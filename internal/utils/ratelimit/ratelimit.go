@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limit - допустимое количество запросов Requests за окно Window
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Store считает запросы клиента в фиксированных окнах времени
+type Store interface {
+	// Increment увеличивает счетчик key в окне, начавшемся в windowStart, и возвращает новое значение
+	Increment(ctx context.Context, key string, windowStart time.Time) (int, error)
+}
+
+// WindowStart возвращает начало окна длиной window, в которое попадает момент now
+func WindowStart(now time.Time, window time.Duration) time.Time {
+	return now.Truncate(window)
+}
+
+type counter struct {
+	windowStart time.Time
+	count       int
+}
+
+// MemoryStore хранит счетчики в памяти процесса. Подходит для одного экземпляра сервиса
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]*counter)}
+}
+
+func (s *MemoryStore) Increment(_ context.Context, key string, windowStart time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(windowStart)
+	c, ok := s.counters[key]
+	if !ok || c.windowStart.Before(windowStart) {
+		c = &counter{windowStart: windowStart}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, nil
+}
+
+// sweep удаляет счетчики прошедших окон не чаще одного раза за окно
+func (s *MemoryStore) sweep(windowStart time.Time) {
+	if !s.lastSweep.Before(windowStart) {
+		return
+	}
+	for key, c := range s.counters {
+		if c.windowStart.Before(windowStart) {
+			delete(s.counters, key)
+		}
+	}
+	s.lastSweep = windowStart
+}
@@ -0,0 +1,157 @@
+package similarity
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Веса составляющих итоговой оценки похожести
+const (
+	lyricsWeight = 0.7
+	artistWeight = 0.2
+	yearWeight   = 0.1
+	// yearWindow - разница в годах, при которой близость по году релиза становится нулевой
+	yearWindow = 10
+)
+
+// Document - песня в индексе
+type Document struct {
+	ID    uint
+	Group string
+	Song  string
+	Year  int // 0, если год неизвестен
+	Words []string
+}
+
+// Result - похожая песня и составляющие ее оценки
+type Result struct {
+	Document
+	Score       float64
+	LyricsScore float64
+	SameArtist  bool
+	YearScore   float64
+}
+
+type entry struct {
+	doc   Document
+	terms map[string]int
+}
+
+// Index - индекс TF-IDF по текстам песен, хранится в памяти и обновляется по одной песне
+type Index struct {
+	mu   sync.RWMutex
+	docs map[uint]*entry
+	df   map[string]int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs: make(map[uint]*entry),
+		df:   make(map[string]int),
+	}
+}
+
+// Upsert добавляет песню в индекс или заменяет ранее добавленную
+func (x *Index) Upsert(doc Document) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(doc.ID)
+	terms := make(map[string]int)
+	for _, word := range doc.Words {
+		terms[word]++
+	}
+	for term := range terms {
+		x.df[term]++
+	}
+	doc.Words = nil
+	x.docs[doc.ID] = &entry{doc: doc, terms: terms}
+}
+
+func (x *Index) Remove(id uint) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+}
+
+// Contains сообщает, есть ли песня в индексе
+func (x *Index) Contains(id uint) bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	_, ok := x.docs[id]
+	return ok
+}
+
+func (x *Index) remove(id uint) {
+	old, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	for term := range old.terms {
+		if x.df[term]--; x.df[term] == 0 {
+			delete(x.df, term)
+		}
+	}
+	delete(x.docs, id)
+}
+
+// Similar возвращает до limit песен, наиболее похожих на песню id, по убыванию оценки.
+// Оценка складывается из косинусной близости TF-IDF векторов текстов, совпадения исполнителя и близости года релиза
+func (x *Index) Similar(id uint, limit int) []Result {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	target, ok := x.docs[id]
+	if !ok {
+		return nil
+	}
+	targetVector, targetNorm := x.vector(target)
+
+	results := make([]Result, 0, len(x.docs)-1)
+	for otherID, other := range x.docs {
+		if otherID == id {
+			continue
+		}
+		result := Result{Document: other.doc}
+		vector, norm := x.vector(other)
+		if targetNorm > 0 && norm > 0 {
+			dot := 0.0
+			for term, weight := range targetVector {
+				dot += weight * vector[term]
+			}
+			result.LyricsScore = dot / (targetNorm * norm)
+		}
+		result.SameArtist = target.doc.Group != "" && target.doc.Group == other.doc.Group
+		if target.doc.Year > 0 && other.doc.Year > 0 {
+			distance := math.Abs(float64(target.doc.Year - other.doc.Year))
+			result.YearScore = math.Max(0, 1-distance/yearWindow)
+		}
+		result.Score = lyricsWeight*result.LyricsScore + yearWeight*result.YearScore
+		if result.SameArtist {
+			result.Score += artistWeight
+		}
+		if result.Score > 0 {
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results[:min(limit, len(results))]
+}
+
+// vector считает TF-IDF веса терминов документа и норму вектора
+func (x *Index) vector(e *entry) (map[string]float64, float64) {
+	n := float64(len(x.docs))
+	vector := make(map[string]float64, len(e.terms))
+	norm := 0.0
+	for term, count := range e.terms {
+		idf := math.Log((n+1)/float64(x.df[term]+1)) + 1
+		weight := (1 + math.Log(float64(count))) * idf
+		vector[term] = weight
+		norm += weight * weight
+	}
+	return vector, math.Sqrt(norm)
+}
@@ -9,7 +9,10 @@ import (
 
 func MustLoadPostgres(cfg config.DatabaseConfig) *gorm.DB {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		//Преобразует ошибки драйвера в gorm.ErrDuplicatedKey и т.п., чтобы репозиторий не зависел от кодов PostgreSQL
+		TranslateError: true,
+	})
 	if err != nil {
 		panic(fmt.Sprintf("failed to connect to database: %s", err.Error()))
 	}
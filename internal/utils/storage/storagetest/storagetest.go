@@ -0,0 +1,112 @@
+// Package storagetest подготавливает базу данных PostgreSQL для тестов, которым нужна настоящая база
+package storagetest
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DatabaseURLEnv - переменная окружения со строкой подключения к тестовой базе.
+// Пользователь должен иметь право создавать схемы
+const DatabaseURLEnv = "TEST_DATABASE_URL"
+
+// Open создает в тестовой базе отдельную схему, применяет к ней миграции из каталога migrations и возвращает
+// подключение, работающее в этой схеме. Схема удаляется по завершении теста.
+// Если TEST_DATABASE_URL не задана, тест пропускается
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(DatabaseURLEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", DatabaseURLEnv)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+
+	admin := open(t, dsn)
+	if err := admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", schema)).Error; err != nil {
+		t.Fatalf("create schema: %s", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(fmt.Sprintf("DROP SCHEMA %s CASCADE", schema))
+		closeDB(admin)
+	})
+
+	db := open(t, withSearchPath(dsn, schema))
+	t.Cleanup(func() { closeDB(db) })
+	migrate(t, db)
+	return db
+}
+
+func open(t testing.TB, dsn string) *gorm.DB {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connect to test database: %s", err)
+	}
+	return db
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// withSearchPath добавляет к строке подключения схему по умолчанию. Поддерживаются URL и формат key=value
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		if u, err := url.Parse(dsn); err == nil {
+			query := u.Query()
+			query.Set("search_path", schema)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+// migrate применяет разделы Up всех миграций по порядку имен файлов, как goose up
+func migrate(t testing.TB, db *gorm.DB) {
+	t.Helper()
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "migrations")
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found in %s", dir)
+	}
+	sort.Strings(files)
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql.DB: %s", err)
+	}
+	for _, name := range files {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read migration: %s", err)
+		}
+		if _, err := sqlDB.Exec(upSection(string(content))); err != nil {
+			t.Fatalf("apply migration %s: %s", filepath.Base(name), err)
+		}
+	}
+}
+
+// upSection возвращает SQL между "-- +goose Up" и "-- +goose Down"
+func upSection(content string) string {
+	if _, after, ok := strings.Cut(content, "-- +goose Up"); ok {
+		content = after
+	}
+	up, _, _ := strings.Cut(content, "-- +goose Down")
+	return up
+}
@@ -0,0 +1,129 @@
+package textdiff
+
+import (
+	"strings"
+	"testEffectiveMobile/internal/models"
+)
+
+// maxCells ограничивает размер таблицы наибольшей общей подпоследовательности. Для текстов большего размера
+// сравнение не строится, и весь старый текст считается удаленным, а новый - добавленным
+const maxCells = 4_000_000
+
+// Lines сравнивает тексты построчно по наибольшей общей подпоследовательности строк.
+// Удаленные строки идут перед добавленными на их месте
+func Lines(oldText, newText string) []models.DiffLine {
+	a, b := splitLines(oldText), splitLines(newText)
+	// Общие начало и конец не участвуют в построении таблицы
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	lines := make([]models.DiffLine, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, models.DiffLine{Op: models.DiffEqual, Text: a[i], OldLine: i + 1, NewLine: i + 1})
+	}
+	lines = append(lines, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix, prefix)...)
+	for i := 0; i < suffix; i++ {
+		oldIndex, newIndex := len(a)-suffix+i, len(b)-suffix+i
+		lines = append(lines, models.DiffLine{Op: models.DiffEqual, Text: a[oldIndex], OldLine: oldIndex + 1, NewLine: newIndex + 1})
+	}
+	return lines
+}
+
+// middle сравнивает различающиеся части текстов. oldOffset и newOffset - номера их первых строк минус 1
+func middle(a, b []string, oldOffset, newOffset int) []models.DiffLine {
+	var lines []models.DiffLine
+	deleted := func(i int) {
+		lines = append(lines, models.DiffLine{Op: models.DiffDelete, Text: a[i], OldLine: oldOffset + i + 1})
+	}
+	inserted := func(j int) {
+		lines = append(lines, models.DiffLine{Op: models.DiffInsert, Text: b[j], NewLine: newOffset + j + 1})
+	}
+	if (len(a)+1)*(len(b)+1) > maxCells {
+		for i := range a {
+			deleted(i)
+		}
+		for j := range b {
+			inserted(j)
+		}
+		return lines
+	}
+	// lcs[i][j] - длина наибольшей общей подпоследовательности a[i:] и b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, models.DiffLine{Op: models.DiffEqual, Text: a[i], OldLine: oldOffset + i + 1, NewLine: newOffset + j + 1})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			deleted(i)
+			i++
+		default:
+			inserted(j)
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		deleted(i)
+	}
+	for ; j < len(b); j++ {
+		inserted(j)
+	}
+	return lines
+}
+
+// Count возвращает количество добавленных и удаленных строк
+func Count(lines []models.DiffLine) (added, removed int) {
+	for _, line := range lines {
+		switch line.Op {
+		case models.DiffInsert:
+			added++
+		case models.DiffDelete:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// Render выводит сравнение в виде текста: добавленные строки начинаются с "+ ", удаленные - с "- ",
+// неизмененные - с двух пробелов
+func Render(lines []models.DiffLine) string {
+	var b strings.Builder
+	for _, line := range lines {
+		switch line.Op {
+		case models.DiffInsert:
+			b.WriteString("+ ")
+		case models.DiffDelete:
+			b.WriteString("- ")
+		default:
+			b.WriteString("  ")
+		}
+		b.WriteString(line.Text + "\n")
+	}
+	return b.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,87 @@
+package textstats
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// stopWords - служебные слова русского и английского языков, не учитываемые в частотных словарях
+var stopWords = toSet(`a an the and or but if of to in on at by for with from as is are was were be been am
+i me my you your he him his she her it its we us our they them their this that these those there here
+not no so do does did have has had will would can could just all oh yeah
+и в во не что он на я с со как а то все она так его но да ты к у же вы за бы по только ее мне было вот
+от меня еще нет о из ему теперь когда даже ну вдруг ли если уже или ни быть был него до вас нибудь опять
+уж вам ведь там потом себя ничего ей может они тут где есть надо ней для мы тебя их чем была сам чтоб без
+будто чего раз тоже себе под будет ж тогда кто этот того потому этого какой совсем ним здесь этом один
+почти мой тем чтобы нее сейчас были куда зачем всех никогда можно при наконец два об другой хоть после
+над больше тот через эти нас про всего них какая много разве три эту моя впрочем хорошо свою этой перед
+иногда лучше чуть том нельзя такой им более всегда конечно всю между`)
+
+func toSet(words string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, word := range strings.Fields(words) {
+		set[word] = struct{}{}
+	}
+	return set
+}
+
+// Words разбивает текст на слова в нижнем регистре. Апостроф и дефис внутри слова сохраняются (don't, из-за)
+func Words(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’' && r != '-'
+	})
+	result := words[:0]
+	for _, word := range words {
+		word = strings.Trim(word, "'’-")
+		if word != "" {
+			result = append(result, strings.ReplaceAll(word, "’", "'"))
+		}
+	}
+	return result
+}
+
+// IsStopWord сообщает, является ли слово служебным
+func IsStopWord(word string) bool {
+	_, ok := stopWords[word]
+	return ok
+}
+
+// Frequency - слово и количество его употреблений
+type Frequency struct {
+	Word  string
+	Count int
+}
+
+// Frequencies считает употребления слов без учета служебных и сортирует их по убыванию частоты,
+// при равной частоте - по алфавиту
+func Frequencies(words []string) []Frequency {
+	counts := make(map[string]int)
+	for _, word := range words {
+		if !IsStopWord(word) {
+			counts[word]++
+		}
+	}
+	result := make([]Frequency, 0, len(counts))
+	for word, count := range counts {
+		result = append(result, Frequency{Word: word, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Word < result[j].Word
+	})
+	return result
+}
+
+// Count возвращает количество употреблений слова word в тексте
+func Count(text, word string) int {
+	count := 0
+	for _, w := range Words(text) {
+		if w == word {
+			count++
+		}
+	}
+	return count
+}
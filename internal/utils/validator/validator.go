@@ -0,0 +1,151 @@
+package validator
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testEffectiveMobile/internal/models"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	langRe = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z]{2})?$`)
+	slugRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// Validate обрезает пробелы в строковых полях структуры и проверяет их по правилам из тега validate.
+// Поддерживаемые правила:
+//
+//	required        - поле не пустое
+//	min=N, max=N    - длина строки в символах
+//	url             - абсолютный http(s) URL
+//	email           - адрес электронной почты без имени (user@example.com)
+//	date            - дата в формате YYYY-MM-DD
+//	datemin=DATE    - дата не раньше указанной
+//	notfuture       - дата не позже сегодняшнего дня
+//	oneof=A B       - одно из перечисленных через пробел значений
+//	lang            - код языка ISO 639-1/639-2 с необязательным регионом (en, ru, pt-BR)
+//	slug            - строчные латинские буквы и цифры, разделенные дефисами (acme, acme-music)
+//	notrim          - не обрезать пробелы, например в паролях
+//
+// Правила, кроме required, к пустым полям не применяются. Имя поля в ошибке берется из тега json.
+// Возвращает *models.ValidationError со списком всех невалидных полей или nil
+func Validate(v any) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		panic("validator: Validate expects a pointer to struct")
+	}
+	value = value.Elem()
+	t := value.Type()
+
+	var fields []models.FieldError
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.String {
+			continue
+		}
+		rules := field.Tag.Get("validate")
+		fieldValue := value.Field(i)
+		str := fieldValue.String()
+		if !slices.Contains(strings.Split(rules, ","), "notrim") {
+			str = strings.TrimSpace(str)
+			fieldValue.SetString(str)
+		}
+
+		if rules == "" {
+			continue
+		}
+		if message := check(str, rules); message != "" {
+			fields = append(fields, models.FieldError{Field: fieldName(field), Message: message})
+		}
+	}
+	if len(fields) > 0 {
+		return &models.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// check применяет правила по порядку и возвращает сообщение для первого нарушенного
+func check(value, rules string) string {
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if value == "" {
+			if name == "required" {
+				return "is required"
+			}
+			continue
+		}
+		switch name {
+		case "required", "notrim":
+		case "min":
+			if utf8.RuneCountInString(value) < mustAtoi(param) {
+				return fmt.Sprintf("must be at least %s characters long", param)
+			}
+		case "max":
+			if utf8.RuneCountInString(value) > mustAtoi(param) {
+				return fmt.Sprintf("must be at most %s characters long", param)
+			}
+		case "url":
+			u, err := url.ParseRequestURI(value)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return "must be a valid http(s) URL"
+			}
+		case "email":
+			address, err := mail.ParseAddress(value)
+			if err != nil || address.Address != value || address.Name != "" {
+				return "must be a valid email address"
+			}
+		case "date":
+			if _, err := time.Parse(time.DateOnly, value); err != nil {
+				return "must be a date in YYYY-MM-DD format"
+			}
+		case "datemin":
+			date, err := time.Parse(time.DateOnly, value)
+			if err == nil && date.Format(time.DateOnly) < param {
+				return "must not be earlier than " + param
+			}
+		case "notfuture":
+			date, err := time.Parse(time.DateOnly, value)
+			if err == nil && date.After(time.Now()) {
+				return "must not be in the future"
+			}
+		case "oneof":
+			if !slices.Contains(strings.Fields(param), value) {
+				return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+			}
+		case "lang":
+			if !langRe.MatchString(value) {
+				return "must be a language code like en, ru or pt-BR"
+			}
+		case "slug":
+			if !slugRe.MatchString(value) {
+				return "must contain only lowercase letters, digits and hyphens"
+			}
+		default:
+			panic("validator: unknown rule " + name)
+		}
+	}
+	return ""
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic("validator: bad rule parameter " + s)
+	}
+	return n
+}
@@ -0,0 +1,142 @@
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"testEffectiveMobile/internal/models"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(time.DateOnly)
+	tests := []struct {
+		rules string
+		value string
+		want  string
+	}{
+		{"required", "", "is required"},
+		{"required", "x", ""},
+		// Правила, кроме required, к пустым значениям не применяются
+		{"min=3,email,url,date,lang,slug,oneof=a b", "", ""},
+		{"min=3", "ab", "must be at least 3 characters long"},
+		{"min=3", "abc", ""},
+		{"min=3", "ёжи", ""},
+		{"max=3", "abcd", "must be at most 3 characters long"},
+		{"max=3", "ёжик", "must be at most 3 characters long"},
+		{"max=3", "ёжи", ""},
+		{"oneof=asc desc", "asc", ""},
+		{"oneof=asc desc", "up", "must be one of: asc, desc"},
+		{"email", "user@example.com", ""},
+		{"email", "User <user@example.com>", "must be a valid email address"},
+		{"email", "user", "must be a valid email address"},
+		{"url", "https://example.com/song", ""},
+		{"url", "ftp://example.com", "must be a valid http(s) URL"},
+		{"url", "/relative", "must be a valid http(s) URL"},
+		{"url", "http://", "must be a valid http(s) URL"},
+		{"date", "2009-09-07", ""},
+		{"date", "07.09.2009", "must be a date in YYYY-MM-DD format"},
+		{"date", "2009-02-30", "must be a date in YYYY-MM-DD format"},
+		{"date,datemin=1900-01-01", "1899-12-31", "must not be earlier than 1900-01-01"},
+		{"date,datemin=1900-01-01", "1900-01-01", ""},
+		{"date,notfuture", tomorrow, "must not be in the future"},
+		{"date,notfuture", "2009-09-07", ""},
+		{"lang", "en", ""},
+		{"lang", "pt-BR", ""},
+		{"lang", "eng", ""},
+		{"lang", "english", "must be a language code like en, ru or pt-BR"},
+		{"lang", "e1", "must be a language code like en, ru or pt-BR"},
+		{"slug", "acme-music", ""},
+		{"slug", "Acme", "must contain only lowercase letters, digits and hyphens"},
+		{"slug", "acme--music", "must contain only lowercase letters, digits and hyphens"},
+		{"slug", "-acme", "must contain only lowercase letters, digits and hyphens"},
+		// Сообщение возвращается для первого нарушенного правила
+		{"min=5,email", "a@b", "must be at least 5 characters long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rules+" "+tt.value, func(t *testing.T) {
+			if got := check(tt.value, tt.rules); got != tt.want {
+				t.Errorf("check(%q, %q) = %q, want %q", tt.value, tt.rules, got, tt.want)
+			}
+		})
+	}
+}
+
+type request struct {
+	Name     string `json:"name" validate:"required,max=5"`
+	Password string `json:"password" validate:"notrim,required,min=3"`
+	Comment  string `json:"comment,omitempty"`
+	Email    string `validate:"email"`
+	Count    int    `json:"count" validate:"required"`
+	internal string
+}
+
+func TestValidate(t *testing.T) {
+	req := request{Name: "  Muse  ", Password: " pw ", Comment: " note ", Email: "bad", internal: " x "}
+	err := Validate(&req)
+
+	var validationErr *models.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate() error = %v, want *models.ValidationError", err)
+	}
+	want := []models.FieldError{{Field: "Email", Message: "must be a valid email address"}}
+	if !reflect.DeepEqual(validationErr.Fields, want) {
+		t.Errorf("fields = %+v, want %+v", validationErr.Fields, want)
+	}
+	if req.Name != "Muse" || req.Comment != "note" {
+		t.Errorf("strings are not trimmed: name %q, comment %q", req.Name, req.Comment)
+	}
+	if req.Password != " pw " {
+		t.Errorf("notrim field changed to %q", req.Password)
+	}
+	if req.internal != " x " {
+		t.Errorf("unexported field changed to %q", req.internal)
+	}
+}
+
+func TestValidateCollectsAllFields(t *testing.T) {
+	err := Validate(&request{Name: "   ", Password: "ab", Email: "user@example.com"})
+
+	var validationErr *models.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate() error = %v, want *models.ValidationError", err)
+	}
+	want := []models.FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "password", Message: "must be at least 3 characters long"},
+	}
+	if !reflect.DeepEqual(validationErr.Fields, want) {
+		t.Errorf("fields = %+v, want %+v", validationErr.Fields, want)
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	if err := Validate(&request{Name: "Muse", Password: "secret", Email: "user@example.com"}); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
+
+func TestValidatePanics(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{name: "not a pointer", v: request{}},
+		{name: "unknown rule", v: &struct {
+			Name string `validate:"uppercase"`
+		}{Name: "x"}},
+		{name: "bad parameter", v: &struct {
+			Name string `validate:"max=ten"`
+		}{Name: "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Validate() did not panic")
+				}
+			}()
+			_ = Validate(tt.v)
+		})
+	}
+}
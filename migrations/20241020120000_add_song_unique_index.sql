@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE UNIQUE INDEX songs_group_song_uindex ON songs ("group", song);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX songs_group_song_uindex;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_sections (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    number INTEGER NOT NULL,
+    label VARCHAR(255) NOT NULL DEFAULT '',
+    repeat BOOLEAN NOT NULL DEFAULT FALSE,
+    text TEXT NOT NULL,
+    PRIMARY KEY (song_id, position)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE song_sections;
+-- +goose StatementEnd
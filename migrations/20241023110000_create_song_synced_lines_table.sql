@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_synced_lines (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    time_ms INTEGER NOT NULL CHECK (time_ms >= 0),
+    text TEXT NOT NULL,
+    PRIMARY KEY (song_id, position)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE song_synced_lines;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_translations (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    language VARCHAR(16) NOT NULL,
+    verses JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, language)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE song_translations;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE songs
+    ADD COLUMN explicit BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN explicit_override BOOLEAN NULL,
+    ADD COLUMN explicit_reasons JSONB NOT NULL DEFAULT '[]';
+CREATE INDEX songs_explicit_index ON songs (explicit);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX songs_explicit_index;
+ALTER TABLE songs
+    DROP COLUMN explicit_reasons,
+    DROP COLUMN explicit_override,
+    DROP COLUMN explicit;
+-- +goose StatementEnd
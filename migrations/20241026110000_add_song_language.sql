@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE songs
+    ADD COLUMN language VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN language_confidence DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN language_manual BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX songs_language_index ON songs (language);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX songs_language_index;
+ALTER TABLE songs
+    DROP COLUMN language_manual,
+    DROP COLUMN language_confidence,
+    DROP COLUMN language;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_chord_sheets (
+    song_id INTEGER PRIMARY KEY REFERENCES songs (id) ON DELETE CASCADE,
+    source TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE song_chord_sheets;
+-- +goose StatementEnd
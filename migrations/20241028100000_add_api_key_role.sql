@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE api_keys ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'reader';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE api_keys DROP COLUMN role;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE UNLOGGED TABLE rate_limits (
+    key TEXT NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (key, window_start)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE rate_limits;
+-- +goose StatementEnd
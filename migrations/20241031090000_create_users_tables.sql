@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    role VARCHAR(32) NOT NULL DEFAULT 'reader',
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE user_favorites (
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, song_id)
+);
+
+CREATE INDEX user_favorites_user_id_created_at_index ON user_favorites (user_id, created_at DESC);
+
+CREATE TABLE song_plays (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    played_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX song_plays_user_id_played_at_index ON song_plays (user_id, played_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE song_plays;
+DROP TABLE user_favorites;
+DROP TABLE users;
+-- +goose StatementEnd
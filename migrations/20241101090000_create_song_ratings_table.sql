@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_ratings (
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, song_id)
+);
+
+CREATE INDEX song_ratings_updated_at_index ON song_ratings (updated_at);
+CREATE INDEX song_plays_played_at_index ON song_plays (played_at);
+
+ALTER TABLE songs
+    ADD COLUMN play_count BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN rating_sum INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN rating_average NUMERIC(3, 2) GENERATED ALWAYS AS (
+        CASE WHEN rating_count > 0 THEN ROUND(rating_sum::NUMERIC / rating_count, 2) END
+    ) STORED;
+
+UPDATE songs SET play_count = plays.count
+FROM (SELECT song_id, COUNT(*) AS count FROM song_plays GROUP BY song_id) AS plays
+WHERE songs.id = plays.song_id;
+
+CREATE INDEX songs_play_count_index ON songs (play_count DESC, id);
+CREATE INDEX songs_rating_average_index ON songs (rating_average DESC NULLS LAST, rating_count DESC, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX songs_rating_average_index;
+DROP INDEX songs_play_count_index;
+ALTER TABLE songs
+    DROP COLUMN rating_average,
+    DROP COLUMN rating_sum,
+    DROP COLUMN rating_count,
+    DROP COLUMN play_count;
+DROP INDEX song_plays_played_at_index;
+DROP TABLE song_ratings;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_suggestions (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    base_text TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    author VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    reason TEXT NOT NULL DEFAULT '',
+    reviewed_by VARCHAR(255) NOT NULL DEFAULT '',
+    reviewed_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX song_suggestions_status_index ON song_suggestions (status, id);
+CREATE INDEX song_suggestions_song_id_index ON song_suggestions (song_id);
+
+CREATE TABLE song_revisions (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    previous_text TEXT NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    approved_by VARCHAR(255) NOT NULL,
+    suggestion_id INTEGER NULL REFERENCES song_suggestions (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX song_revisions_song_id_index ON song_revisions (song_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE song_revisions;
+DROP TABLE song_suggestions;
+-- +goose StatementEnd
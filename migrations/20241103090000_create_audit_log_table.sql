@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    resource VARCHAR(32) NOT NULL,
+    resource_id VARCHAR(64) NOT NULL,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    before JSONB NULL,
+    after JSONB NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX audit_log_created_at_index ON audit_log (created_at DESC, id DESC);
+CREATE INDEX audit_log_actor_created_at_index ON audit_log (actor, created_at DESC);
+CREATE INDEX audit_log_resource_resource_id_created_at_index ON audit_log (resource, resource_id, created_at DESC);
+
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_append_only();
+-- +goose StatementEnd
@@ -0,0 +1,128 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tenants (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(64) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+INSERT INTO tenants (slug, name) VALUES ('default', 'Default');
+
+ALTER TABLE songs ADD COLUMN tenant_id INTEGER NULL REFERENCES tenants (id);
+UPDATE songs SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default');
+ALTER TABLE songs ALTER COLUMN tenant_id SET NOT NULL;
+
+DROP INDEX songs_group_song_uindex;
+CREATE UNIQUE INDEX songs_tenant_id_group_song_uindex ON songs (tenant_id, "group", song);
+
+ALTER TABLE api_keys ADD COLUMN tenant VARCHAR(64) NULL REFERENCES tenants (slug);
+
+ALTER TABLE songs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE songs FORCE ROW LEVEL SECURITY;
+CREATE POLICY songs_tenant_isolation ON songs
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::INTEGER)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::INTEGER);
+
+ALTER TABLE song_sections ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_sections FORCE ROW LEVEL SECURITY;
+CREATE POLICY song_sections_tenant_isolation ON song_sections
+    USING (song_id IN (SELECT id FROM songs))
+    WITH CHECK (song_id IN (SELECT id FROM songs));
+
+ALTER TABLE song_synced_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_synced_lines FORCE ROW LEVEL SECURITY;
+CREATE POLICY song_synced_lines_tenant_isolation ON song_synced_lines
+    USING (song_id IN (SELECT id FROM songs))
+    WITH CHECK (song_id IN (SELECT id FROM songs));
+
+ALTER TABLE song_translations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_translations FORCE ROW LEVEL SECURITY;
+CREATE POLICY song_translations_tenant_isolation ON song_translations
+    USING (song_id IN (SELECT id FROM songs))
+    WITH CHECK (song_id IN (SELECT id FROM songs));
+
+ALTER TABLE song_annotations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_annotations FORCE ROW LEVEL SECURITY;
+CREATE POLICY song_annotations_tenant_isolation ON song_annotations
+    USING (song_id IN (SELECT id FROM songs))
+    WITH CHECK (song_id IN (SELECT id FROM songs));
+
+ALTER TABLE song_chord_sheets ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_chord_sheets FORCE ROW LEVEL SECURITY;
+CREATE POLICY song_chord_sheets_tenant_isolation ON song_chord_sheets
+    USING (song_id IN (SELECT id FROM songs))
+    WITH CHECK (song_id IN (SELECT id FROM songs));
+
+ALTER TABLE song_suggestions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_suggestions FORCE ROW LEVEL SECURITY;
+CREATE POLICY song_suggestions_tenant_isolation ON song_suggestions
+    USING (song_id IN (SELECT id FROM songs))
+    WITH CHECK (song_id IN (SELECT id FROM songs));
+
+ALTER TABLE song_revisions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_revisions FORCE ROW LEVEL SECURITY;
+CREATE POLICY song_revisions_tenant_isolation ON song_revisions
+    USING (song_id IN (SELECT id FROM songs))
+    WITH CHECK (song_id IN (SELECT id FROM songs));
+
+ALTER TABLE user_favorites ENABLE ROW LEVEL SECURITY;
+ALTER TABLE user_favorites FORCE ROW LEVEL SECURITY;
+CREATE POLICY user_favorites_tenant_isolation ON user_favorites
+    USING (song_id IN (SELECT id FROM songs))
+    WITH CHECK (song_id IN (SELECT id FROM songs));
+
+ALTER TABLE song_plays ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_plays FORCE ROW LEVEL SECURITY;
+CREATE POLICY song_plays_tenant_isolation ON song_plays
+    USING (song_id IN (SELECT id FROM songs))
+    WITH CHECK (song_id IN (SELECT id FROM songs));
+
+ALTER TABLE song_ratings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_ratings FORCE ROW LEVEL SECURITY;
+CREATE POLICY song_ratings_tenant_isolation ON song_ratings
+    USING (song_id IN (SELECT id FROM songs))
+    WITH CHECK (song_id IN (SELECT id FROM songs));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY song_ratings_tenant_isolation ON song_ratings;
+ALTER TABLE song_ratings NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_ratings DISABLE ROW LEVEL SECURITY;
+DROP POLICY song_plays_tenant_isolation ON song_plays;
+ALTER TABLE song_plays NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_plays DISABLE ROW LEVEL SECURITY;
+DROP POLICY user_favorites_tenant_isolation ON user_favorites;
+ALTER TABLE user_favorites NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE user_favorites DISABLE ROW LEVEL SECURITY;
+DROP POLICY song_revisions_tenant_isolation ON song_revisions;
+ALTER TABLE song_revisions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_revisions DISABLE ROW LEVEL SECURITY;
+DROP POLICY song_suggestions_tenant_isolation ON song_suggestions;
+ALTER TABLE song_suggestions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_suggestions DISABLE ROW LEVEL SECURITY;
+DROP POLICY song_chord_sheets_tenant_isolation ON song_chord_sheets;
+ALTER TABLE song_chord_sheets NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_chord_sheets DISABLE ROW LEVEL SECURITY;
+DROP POLICY song_annotations_tenant_isolation ON song_annotations;
+ALTER TABLE song_annotations NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_annotations DISABLE ROW LEVEL SECURITY;
+DROP POLICY song_translations_tenant_isolation ON song_translations;
+ALTER TABLE song_translations NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_translations DISABLE ROW LEVEL SECURITY;
+DROP POLICY song_synced_lines_tenant_isolation ON song_synced_lines;
+ALTER TABLE song_synced_lines NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_synced_lines DISABLE ROW LEVEL SECURITY;
+DROP POLICY song_sections_tenant_isolation ON song_sections;
+ALTER TABLE song_sections NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_sections DISABLE ROW LEVEL SECURITY;
+DROP POLICY songs_tenant_isolation ON songs;
+ALTER TABLE songs NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE songs DISABLE ROW LEVEL SECURITY;
+
+ALTER TABLE api_keys DROP COLUMN tenant;
+DROP INDEX songs_tenant_id_group_song_uindex;
+CREATE UNIQUE INDEX songs_group_song_uindex ON songs ("group", song);
+ALTER TABLE songs DROP COLUMN tenant_id;
+DROP TABLE tenants;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE songs
+    ALTER COLUMN explicit DROP DEFAULT,
+    ALTER COLUMN explicit DROP NOT NULL;
+ALTER TABLE songs NO FORCE ROW LEVEL SECURITY;
+UPDATE songs SET explicit = NULL WHERE explicit_override IS NULL AND NOT explicit;
+ALTER TABLE songs FORCE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE songs NO FORCE ROW LEVEL SECURITY;
+UPDATE songs SET explicit = FALSE WHERE explicit IS NULL;
+ALTER TABLE songs FORCE ROW LEVEL SECURITY;
+ALTER TABLE songs
+    ALTER COLUMN explicit SET DEFAULT FALSE,
+    ALTER COLUMN explicit SET NOT NULL;
+-- +goose StatementEnd
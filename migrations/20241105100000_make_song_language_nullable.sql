@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE songs
+    ALTER COLUMN language DROP DEFAULT,
+    ALTER COLUMN language DROP NOT NULL;
+ALTER TABLE songs NO FORCE ROW LEVEL SECURITY;
+UPDATE songs SET language = NULL WHERE language = '' AND NOT language_manual;
+ALTER TABLE songs FORCE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE songs NO FORCE ROW LEVEL SECURITY;
+UPDATE songs SET language = '' WHERE language IS NULL;
+ALTER TABLE songs FORCE ROW LEVEL SECURITY;
+ALTER TABLE songs
+    ALTER COLUMN language SET DEFAULT '',
+    ALTER COLUMN language SET NOT NULL;
+-- +goose StatementEnd
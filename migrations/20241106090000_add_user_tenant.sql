@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN tenant_id INTEGER NULL REFERENCES tenants (id);
+UPDATE users SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default');
+ALTER TABLE users ALTER COLUMN tenant_id SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN tenant_id;
+-- +goose StatementEnd